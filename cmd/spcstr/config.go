@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and edit spcstr settings",
+	Long: `Read and edit spcstr settings. Settings are layered, lowest precedence first: built-in defaults, the user
+file $XDG_CONFIG_HOME/spcstr/settings.json (~/.config when unset), the project file .spcstr/settings.json and
+SPCSTR_* environment variables such as SPCSTR_TODOS_RECENT_LIMIT.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := loadProjectSettings()
+		if err != nil {
+			return err
+		}
+		value, err := settings.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting in the project or user settings file",
+	Long: `Store a setting in .spcstr/settings.json, or with --global in the user settings file. Lists such as
+hooks.install take comma-separated values.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		global, _ := cmd.Flags().GetBool("global")
+
+		path, err := settingsPath(global)
+		if err != nil {
+			return err
+		}
+		if err := config.SetSetting(path, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Set %s in %s\n", args[0], path)
+
+		// An environment variable would still win over the file
+		if env := config.SettingEnvVar(args[0]); os.Getenv(env) != "" {
+			fmt.Printf("! %s is set and overrides this value\n", env)
+		}
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every setting with its value and source",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := loadProjectSettings()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, key := range config.SettingKeys() {
+			value, err := settings.Get(key)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, settings.Sources[key])
+		}
+		return w.Flush()
+	},
+}
+
+// loadProjectSettings loads the settings of the project in the working
+// directory
+func loadProjectSettings() (*config.Settings, error) {
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return config.LoadSettings(projectRoot)
+}
+
+// settingsPath returns the settings file config set writes to
+func settingsPath(global bool) (string, error) {
+	if global {
+		return config.UserSettingsPath()
+	}
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return config.ProjectSettingsPath(projectRoot), nil
+}
+
+func init() {
+	configSetCmd.Flags().Bool("global", false, "Write the user settings file instead of the project's")
+
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configListCmd)
+	rootCmd.AddCommand(configCmd)
+}
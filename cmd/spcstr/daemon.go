@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/dylan/spcstr/internal/daemon"
+	"github.com/dylan/spcstr/internal/hooks"
+	"github.com/dylan/spcstr/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve hook events from a long-running process",
+	Long: `Run a hook daemon for the project on .spcstr/daemon.sock. While it is running, "spcstr hook" forwards
+events to it instead of processing them itself. Session state is kept in memory: every event is appended to the
+session journal as it arrives and state.json snapshots are flushed every few seconds and on exit, so the TUI and hooks
+run without the daemon see the same data. Stop it with Ctrl+C or SIGTERM.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwdFlag, _ := cmd.Flags().GetString("cwd")
+		projectDir, err := filepath.Abs(cwdFlag)
+		if err != nil {
+			return fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		if info, err := os.Stat(filepath.Join(projectDir, ".spcstr")); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not an spcstr project (run spcstr init)", projectDir)
+		}
+
+		// Handlers resolve .spcstr from the working directory
+		if err := os.Chdir(projectDir); err != nil {
+			return fmt.Errorf("failed to change to project directory '%s': %w", projectDir, err)
+		}
+		state.EnableCache()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		stateManager := state.NewStateManager(filepath.Join(projectDir, ".spcstr"))
+		go flushSnapshots(ctx, stateManager)
+
+		server := daemon.NewServer(projectDir, hooks.RunHook)
+		fmt.Printf("spcstr daemon listening on %s\n", server.SocketPath())
+		serveErr := server.Serve(ctx)
+
+		// Requests have drained, so this flush leaves every snapshot current
+		if err := stateManager.FlushSnapshots(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to flush session state: %v\n", err)
+		}
+		if serveErr != nil {
+			return serveErr
+		}
+		fmt.Println("spcstr daemon stopped")
+		return nil
+	},
+}
+
+// snapshotFlushInterval is how often the daemon writes state.json snapshots
+// of sessions whose journals have grown
+const snapshotFlushInterval = 2 * time.Second
+
+// flushSnapshots writes session snapshots until ctx is cancelled
+func flushSnapshots(ctx context.Context, stateManager *state.StateManager) {
+	ticker := time.NewTicker(snapshotFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := stateManager.FlushSnapshots(ctx); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to flush session state: %v\n", err)
+			}
+		}
+	}
+}
+
+func init() {
+	daemonCmd.Flags().StringP("cwd", "c", ".", "Project root to serve")
+
+	rootCmd.AddCommand(daemonCmd)
+}
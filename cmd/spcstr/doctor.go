@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dylan/spcstr/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the spcstr installation of a project",
+	Long: `Check that spcstr is on PATH, that the hooks in .claude/settings.json are current, that .spcstr/sessions
+and .spcstr/logs are writable, that every session's state and journal load, and that no temp files were left
+by interrupted writes. --fix merges missing hooks, creates missing directories, rebuilds corrupt state.json
+files from their journal and deletes stray temp files. Exits non-zero when a check fails.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fix, _ := cmd.Flags().GetBool("fix")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+
+		report := doctor.Run(context.Background(), projectRoot, fix)
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				return fmt.Errorf("failed to encode report: %w", err)
+			}
+		} else {
+			printDoctorReport(report)
+		}
+
+		if failed := report.Count(doctor.StatusFail); failed > 0 {
+			// The report already explains the failures
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+		}
+		return nil
+	},
+}
+
+// printDoctorReport prints each check with its details and hint
+func printDoctorReport(report doctor.Report) {
+	for _, check := range report.Checks {
+		icon := "✓"
+		switch check.Status {
+		case doctor.StatusWarn:
+			icon = "!"
+		case doctor.StatusFail:
+			icon = "✗"
+		}
+		fixed := ""
+		if check.Fixed {
+			fixed = " (fixed)"
+		}
+		fmt.Printf("%s %s: %s%s\n", icon, check.Name, check.Message, fixed)
+		for _, detail := range check.Details {
+			fmt.Printf("    %s\n", detail)
+		}
+		if check.Hint != "" {
+			fmt.Printf("    → %s\n", check.Hint)
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d warnings, %d failed\n",
+		report.Count(doctor.StatusPass), report.Count(doctor.StatusWarn), report.Count(doctor.StatusFail))
+}
+
+func init() {
+	doctorCmd.Flags().Bool("fix", false, "Repair problems that can be fixed without losing data")
+	doctorCmd.Flags().Bool("json", false, "Print the report as JSON")
+	rootCmd.AddCommand(doctorCmd)
+}
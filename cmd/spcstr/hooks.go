@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dylan/spcstr/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Inspect spcstr hook execution",
+}
+
+var hooksStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show hook latency and failure metrics",
+	Long: fmt.Sprintf(`Summarize the samples in .spcstr/metrics.jsonl and .spcstr/metrics.json: the p50/p95/max latency of each hook over its last %d executions,
+split into parse, handler and logging time, with failure counts and the last error. Hooks whose p95 exceeds
+%s are flagged slow; hooks whose last run failed or that fail %.0f%% of the time are flagged failing.`,
+		metrics.WindowSize, metrics.SlowThreshold, metrics.FailingRate*100),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+
+		file, err := metrics.Load(filepath.Join(projectRoot, ".spcstr"))
+		if err != nil {
+			return err
+		}
+		if len(file.Hooks) == 0 {
+			fmt.Println("No hook metrics recorded yet")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOOK\tRUNS\tFAIL\tP50\tP95\tMAX\tSETUP P95\tPARSE P95\tHANDLER P95\tLOG P95\tSTATUS")
+		for _, name := range file.Names() {
+			h := file.Hooks[name]
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				name,
+				h.Count,
+				h.Failures,
+				formatMicros(h.Total.P50Us),
+				formatMicros(h.Total.P95Us),
+				formatMicros(h.Total.MaxUs),
+				formatMicros(h.Setup.P95Us),
+				formatMicros(h.Parse.P95Us),
+				formatMicros(h.Handler.P95Us),
+				formatMicros(h.Logging.P95Us),
+				formatHealth(h.Health()),
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		for _, name := range file.Names() {
+			h := file.Hooks[name]
+			if h.LastError == "" || h.LastErrorAt == nil {
+				continue
+			}
+			fmt.Printf("\n%s last failed %s:\n  %s\n", name, h.LastErrorAt.Local().Format("2006-01-02 15:04:05"), h.LastError)
+		}
+		return nil
+	},
+}
+
+// formatMicros renders a microsecond latency compactly
+func formatMicros(us int64) string {
+	d := time.Duration(us) * time.Microsecond
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(100 * time.Microsecond).String()
+	default:
+		return d.String()
+	}
+}
+
+// formatHealth marks flagged hooks for the stats table
+func formatHealth(health string) string {
+	switch health {
+	case metrics.HealthFailing:
+		return "✗ failing"
+	case metrics.HealthSlow:
+		return "! slow"
+	default:
+		return "✓ ok"
+	}
+}
+
+func init() {
+	hooksCmd.AddCommand(hooksStatsCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
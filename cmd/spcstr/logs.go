@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dylan/spcstr/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Manage hook event logs",
+}
+
+var logsConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert array-format hook logs to JSON Lines",
+	Long: `Convert .spcstr/logs/<hook>.json files written by older versions into JSON Lines segments that sort before
+newer events. Each original is kept as <hook>.json.bak.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		logsDir := filepath.Join(projectRoot, ".spcstr", "logs")
+
+		legacy, err := hooks.LegacyLogs(logsDir)
+		if err != nil {
+			return fmt.Errorf("failed to list legacy logs: %w", err)
+		}
+		if len(legacy) == 0 {
+			fmt.Println("No array-format logs to convert")
+			return nil
+		}
+
+		failed := 0
+		for _, hookName := range legacy {
+			converted, err := hooks.ConvertLegacyLog(logsDir, hookName)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", hookName, err)
+				continue
+			}
+			fmt.Printf("✓ %s: converted %d events\n", hookName, converted)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d logs could not be converted", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	logsCmd.AddCommand(logsConvertCmd)
+	rootCmd.AddCommand(logsCmd)
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/daemon"
 	"github.com/dylan/spcstr/internal/hooks"
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/state"
 	"github.com/dylan/spcstr/internal/tui/app"
 	"github.com/spf13/cobra"
 )
@@ -58,10 +62,19 @@ var versionCmd = &cobra.Command{
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize spcstr for a project",
-	Long:  `Initialize spcstr by creating the .spcstr directory structure and configuring Claude Code hooks in .claude/settings.json`,
+	Long:  `Initialize spcstr by creating the .spcstr directory structure and configuring Claude Code hooks in .claude/settings.json.
+Existing hooks from other tools are preserved: spcstr only adds its missing entries and updates stale ones,
+printing a diff of the changes. With --global the hooks go into the user's Claude Code settings instead, and
+every project gets its .spcstr directory on the first hook event, subject to projects.allow and projects.deny.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		force, _ := cmd.Flags().GetBool("force")
-		return config.InitializeProject(force)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		global, _ := cmd.Flags().GetBool("global")
+		opts := config.InitOptions{Force: force, DryRun: dryRun, Global: global}
+		if cmd.Flags().Changed("hooks") {
+			opts.Hooks, _ = cmd.Flags().GetStringSlice("hooks")
+		}
+		return config.InitializeProjectWithOptions(opts)
 	},
 }
 
@@ -96,13 +109,86 @@ var hookCmd = &cobra.Command{
 			return fmt.Errorf("failed to read stdin: %w", err)
 		}
 
-		// Execute the hook
-		err = hooks.ExecuteHook(hookName, absPath, input)
+		// Global hooks run for every project, so skip the ones that are not
+		// tracked and set up the rest on first use
+		if global, _ := cmd.Flags().GetBool("global"); global {
+			track, err := config.PrepareGlobalHook(absPath, hookName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Hook execution failed: %v\n", err)
+				os.Exit(1) // Never block Claude Code in an untracked project
+			}
+			if !track {
+				return nil
+			}
+		}
+
+		// Hand the event to a running daemon, or execute the hook in-process
+		output, err := daemon.Call(absPath, hookName, input)
+		if errors.Is(err, daemon.ErrNotRunning) {
+			output, err = hooks.ExecuteHookWithOutput(hookName, absPath, input)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Hook execution failed: %v\n", err)
+			if !events.IsBlocking(err) {
+				os.Exit(1) // Reported to the user without blocking Claude Code
+			}
 			os.Exit(2) // Block operation exit code
 		}
 
+		// Structured replies (e.g. permission decisions) go to stdout
+		if len(output) > 0 {
+			fmt.Fprintln(os.Stdout, string(output))
+		}
+
+		return nil
+	},
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade session state files to the current schema",
+	Long:  `Rewrite every session under .spcstr/sessions to the current state.json schema version`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		ctx := context.Background()
+		stateManager, err := projectStateManager()
+		if err != nil {
+			return err
+		}
+		sessionIDs, err := stateManager.ListSessions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+
+		migrated, failed := 0, 0
+		for _, sessionID := range sessionIDs {
+			result, err := stateManager.MigrateSession(ctx, sessionID, dryRun)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", sessionID, err)
+				continue
+			}
+			if !result.Migrated() {
+				continue
+			}
+			migrated++
+			if dryRun {
+				fmt.Printf("~ %s: would migrate v%d → v%d\n", sessionID, result.FromVersion, result.ToVersion)
+			} else {
+				fmt.Printf("✓ %s: migrated v%d → v%d\n", sessionID, result.FromVersion, result.ToVersion)
+			}
+		}
+
+		verb := "Migrated"
+		if dryRun {
+			verb = "Would migrate"
+		}
+		fmt.Printf("%s %d of %d sessions to schema v%d\n", verb, migrated, len(sessionIDs), state.CurrentSchemaVersion)
+
+		if failed > 0 {
+			return fmt.Errorf("%d sessions could not be migrated", failed)
+		}
 		return nil
 	},
 }
@@ -110,12 +196,20 @@ var hookCmd = &cobra.Command{
 func init() {
 	// Init command flags
 	initCmd.Flags().BoolP("force", "f", false, "Force reinitialization without prompting")
+	initCmd.Flags().Bool("dry-run", false, "Show the changes to .claude/settings.json without writing anything")
+	initCmd.Flags().StringSlice("hooks", nil, "Hooks to install, such as session_start,stop (saved as hooks.install)")
+	initCmd.Flags().Bool("global", false, "Install the hooks in the user's Claude Code settings to track every project")
 
 	// Hook command flags
 	hookCmd.Flags().StringP("cwd", "c", "", "Working directory for hook execution (project root)")
+	hookCmd.Flags().Bool("global", false, "Run as a hook installed by init --global")
+
+	// Migrate command flags
+	migrateCmd.Flags().Bool("dry-run", false, "Report sessions that need migration without rewriting them")
 
 	// Add commands to root
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(hookCmd)
+	rootCmd.AddCommand(migrateCmd)
 }
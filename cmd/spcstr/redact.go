@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/hooks"
+	"github.com/dylan/spcstr/internal/redact"
+	"github.com/spf13/cobra"
+)
+
+var redactCmd = &cobra.Command{
+	Use:   "redact",
+	Short: "Scrub secrets from recorded sessions and hook logs",
+	Long: `Apply the detectors from .spcstr/redaction.yaml to every session journal, state.json and hook log already
+on disk, replacing secrets with [REDACTED:<detector>] in place. New hook data is redacted as it is written;
+use this after adding a pattern or on data recorded by an older version. Run it while no Claude Code session is
+active, since hook logs are rewritten without a lock.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		redactor, err := redact.Load(filepath.Join(projectRoot, ".spcstr", redact.FileName))
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		stateManager, err := projectStateManager()
+		if err != nil {
+			return err
+		}
+		sessionIDs, err := stateManager.ListSessions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+
+		mark := "✓"
+		if dryRun {
+			mark = "~"
+		}
+
+		total := redact.Counts{}
+		failed := 0
+		for _, sessionID := range sessionIDs {
+			counts, err := stateManager.RedactSession(ctx, sessionID, dryRun)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", sessionID, err)
+				continue
+			}
+			if counts.Total() > 0 {
+				fmt.Printf("%s %s: %s\n", mark, sessionID, formatCounts(counts))
+				total.Add(counts)
+			}
+		}
+
+		logCounts, err := hooks.RedactLogs(filepath.Join(projectRoot, ".spcstr", "logs"), redactor, dryRun)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ logs: %v\n", err)
+		} else if logCounts.Total() > 0 {
+			fmt.Printf("%s logs: %s\n", mark, formatCounts(logCounts))
+			total.Add(logCounts)
+		}
+
+		verb := "Redacted"
+		if dryRun {
+			verb = "Would redact"
+		}
+		fmt.Printf("%s %d secrets across %d sessions and the hook logs\n", verb, total.Total(), len(sessionIDs))
+
+		if failed > 0 {
+			return fmt.Errorf("%d sessions or logs could not be redacted", failed)
+		}
+		return nil
+	},
+}
+
+// formatCounts renders redaction counts as "name=n" pairs in name order
+func formatCounts(counts redact.Counts) string {
+	parts := make([]string, 0, len(counts))
+	for _, name := range counts.Names() {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, counts[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func init() {
+	redactCmd.Flags().Bool("dry-run", false, "Report secrets that would be redacted without rewriting anything")
+
+	rootCmd.AddCommand(redactCmd)
+}
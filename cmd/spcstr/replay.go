@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks"
+	"github.com/dylan/spcstr/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Re-run logged hook events into a scratch project",
+	Long: `Read the events in .spcstr/logs, order them by time and execute them again through the hook handlers with
+a scratch directory as the project root. Open the TUI in that directory to watch the session being rebuilt;
+use --speed 1 for real-time playback.
+
+--since and --until accept an RFC 3339 time or a duration before now, e.g. 2h.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessionID, _ := cmd.Flags().GetString("session")
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		untilFlag, _ := cmd.Flags().GetString("until")
+		speed, _ := cmd.Flags().GetFloat64("speed")
+		maxGap, _ := cmd.Flags().GetDuration("max-gap")
+		outDir, _ := cmd.Flags().GetString("out")
+
+		now := time.Now()
+		filter := replay.Filter{SessionID: sessionID}
+		var err error
+		if filter.Since, err = parseTimeFlag(sinceFlag, now); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		if filter.Until, err = parseTimeFlag(untilFlag, now); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		if speed < 0 {
+			return fmt.Errorf("--speed must not be negative")
+		}
+
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+
+		events, err := replay.Load(filepath.Join(projectRoot, ".spcstr", "logs"), filter)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			fmt.Println("No logged events match")
+			return nil
+		}
+
+		// Never replay into the project the events came from
+		if outDir == "" {
+			if outDir, err = os.MkdirTemp("", "spcstr-replay-"); err != nil {
+				return fmt.Errorf("failed to create scratch directory: %w", err)
+			}
+		}
+		if outDir, err = filepath.Abs(outDir); err != nil {
+			return fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		if sameDir(outDir, projectRoot) {
+			return fmt.Errorf("--out must not be the current project")
+		}
+		if err := replay.PrepareTarget(outDir); err != nil {
+			return err
+		}
+
+		fmt.Printf("Replaying %d events into %s\n", len(events), outDir)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		result, err := replay.Run(ctx, outDir, events, replay.Options{
+			Speed:  speed,
+			MaxGap: maxGap,
+			Progress: func(index int, event hooks.HookEvent, hookErr error) {
+				status := "✓"
+				if hookErr != nil {
+					status = "✗"
+				}
+				fmt.Printf("%s %s %-18s %s\n", status, event.Timestamp.Local().Format("15:04:05"), event.HookName, event.SessionID)
+				if hookErr != nil {
+					fmt.Fprintf(os.Stderr, "    %v\n", hookErr)
+				}
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("replay stopped after %d events: %w", result.Events, err)
+		}
+
+		fmt.Printf("Replayed %d events (%d failed) for %d sessions\n", result.Events, result.Failed, len(result.Sessions))
+		fmt.Printf("View with: cd %s && spcstr\n", outDir)
+		return nil
+	},
+}
+
+// sameDir reports whether two paths name the same existing directory
+func sameDir(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// parseTimeFlag accepts an RFC 3339 timestamp or a duration before now.
+// An empty value yields the zero time.
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration", value)
+	}
+	return now.Add(-d), nil
+}
+
+func init() {
+	replayCmd.Flags().StringP("session", "s", "", "Only replay sessions with this ID or ID prefix")
+	replayCmd.Flags().String("since", "", "Only replay events at or after this time")
+	replayCmd.Flags().String("until", "", "Only replay events at or before this time")
+	replayCmd.Flags().Float64("speed", 0, "Playback speed relative to the recording (1 = real time, 0 = no waiting)")
+	replayCmd.Flags().Duration("max-gap", 0, "Cap the wait between events (0 = no cap)")
+	replayCmd.Flags().StringP("out", "o", "", "Scratch project directory (default: a new temp directory)")
+
+	rootCmd.AddCommand(replayCmd)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "2h", want: now.Add(-2 * time.Hour)},
+		{value: "2025-01-01T08:30:00Z", want: time.Date(2025, 1, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeFlag(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimeFlag(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeFlag(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
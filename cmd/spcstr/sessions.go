@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Inspect recorded Claude Code sessions",
+}
+
+var sessionsCommandsCmd = &cobra.Command{
+	Use:   "commands <session-id>",
+	Short: "List Bash commands run during a session",
+	Long:  `List every Bash command recorded for a session with exit code and duration. The session ID may be any unique prefix.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		ctx := context.Background()
+		stateManager, err := projectStateManager()
+		if err != nil {
+			return err
+		}
+
+		sessionID, err := resolveSessionID(ctx, stateManager, args[0])
+		if err != nil {
+			return err
+		}
+
+		sessionState, err := stateManager.LoadState(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+		}
+
+		if len(sessionState.Commands) == 0 {
+			fmt.Printf("No commands recorded for session %s\n", sessionID)
+			return nil
+		}
+
+		if verbose {
+			printCommandsVerbose(sessionState.Commands)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tEXIT\tDURATION\tBG\tCOMMAND")
+		for _, command := range sessionState.Commands {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				command.Timestamp.Local().Format("15:04:05"),
+				formatExitCode(command),
+				formatMillis(command.DurationMs),
+				formatBackground(command.Background),
+				firstCommandLine(command.Command),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+// printCommandsVerbose prints each command with its description and output
+func printCommandsVerbose(commands []state.CommandEntry) {
+	for i, command := range commands {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("[%s] exit=%s duration=%s background=%t timeout=%dms\n",
+			command.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			formatExitCode(command),
+			formatMillis(command.DurationMs),
+			command.Background,
+			command.TimeoutMs,
+		)
+		if command.Description != "" {
+			fmt.Printf("# %s\n", command.Description)
+		}
+		fmt.Printf("$ %s\n", command.Command)
+		if command.Stdout != "" {
+			fmt.Printf("--- stdout ---\n%s\n", strings.TrimRight(command.Stdout, "\n"))
+		}
+		if command.Stderr != "" {
+			fmt.Printf("--- stderr ---\n%s\n", strings.TrimRight(command.Stderr, "\n"))
+		}
+	}
+}
+
+// projectStateManager returns a StateManager for .spcstr in the working directory
+func projectStateManager() (*state.StateManager, error) {
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return state.NewStateManager(filepath.Join(projectRoot, ".spcstr")), nil
+}
+
+// resolveSessionID expands a unique session ID prefix to the full ID
+func resolveSessionID(ctx context.Context, stateManager *state.StateManager, prefix string) (string, error) {
+	sessionIDs, err := stateManager.ListSessions(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var matches []string
+	for _, id := range sessionIDs {
+		if id == prefix {
+			return id, nil
+		}
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no session matches %q", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("session prefix %q is ambiguous (%d matches)", prefix, len(matches))
+	}
+}
+
+// formatExitCode renders a command's exit status for listings
+func formatExitCode(command state.CommandEntry) string {
+	switch {
+	case command.ExitCode != nil:
+		return fmt.Sprintf("%d", *command.ExitCode)
+	case command.Interrupted:
+		return "int"
+	default:
+		return "-"
+	}
+}
+
+// formatMillis renders a millisecond duration compactly
+func formatMillis(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return (time.Duration(ms) * time.Millisecond).Round(time.Millisecond).String()
+}
+
+// formatBackground marks commands that ran in the background
+func formatBackground(background bool) string {
+	if background {
+		return "bg"
+	}
+	return ""
+}
+
+// firstCommandLine returns the first line of a command, marking elided lines
+func firstCommandLine(command string) string {
+	lines := strings.Split(strings.TrimSpace(command), "\n")
+	if len(lines) > 1 {
+		return lines[0] + " …"
+	}
+	return lines[0]
+}
+
+func init() {
+	sessionsCommandsCmd.Flags().BoolP("verbose", "v", false, "Show descriptions and captured stdout/stderr")
+
+	sessionsCmd.AddCommand(sessionsCommandsCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+func TestResolveSessionID(t *testing.T) {
+	manager := state.NewStateManager(t.TempDir())
+	ctx := context.Background()
+	for _, id := range []string{"abc123", "abd456", "xyz"} {
+		if _, err := manager.InitializeState(ctx, id); err != nil {
+			t.Fatalf("InitializeState(%s) error: %v", id, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{name: "exact match", prefix: "xyz", want: "xyz"},
+		{name: "unique prefix", prefix: "abc", want: "abc123"},
+		{name: "ambiguous prefix", prefix: "ab", wantErr: true},
+		{name: "no match", prefix: "nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSessionID(ctx, manager, tt.prefix)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveSessionID(%q) expected error, got %q", tt.prefix, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolveSessionID(%q) = %q, %v; want %q", tt.prefix, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatExitCode(t *testing.T) {
+	zero, one := 0, 1
+	tests := []struct {
+		name    string
+		command state.CommandEntry
+		want    string
+	}{
+		{name: "success", command: state.CommandEntry{ExitCode: &zero}, want: "0"},
+		{name: "failure", command: state.CommandEntry{ExitCode: &one}, want: "1"},
+		{name: "interrupted", command: state.CommandEntry{Interrupted: true}, want: "int"},
+		{name: "unknown", command: state.CommandEntry{}, want: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatExitCode(tt.command); got != tt.want {
+				t.Errorf("formatExitCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
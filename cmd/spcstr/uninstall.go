@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove spcstr hooks and data from a project",
+	Long: `Remove every hook whose command runs "spcstr hook" from .claude/settings.json, leaving hooks from other
+tools intact, and delete the .spcstr directory with its sessions and logs. Use --keep-data to keep .spcstr, or
+--archive to save it to a spcstr-archive-<time>.tar.gz in the project root before it is deleted. With --global the
+hooks installed by init --global are removed from the user's Claude Code settings and project data is kept.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		keepData, _ := cmd.Flags().GetBool("keep-data")
+		archive, _ := cmd.Flags().GetBool("archive")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		global, _ := cmd.Flags().GetBool("global")
+		return config.Uninstall(config.UninstallOptions{
+			Force:    force,
+			KeepData: keepData,
+			Archive:  archive,
+			DryRun:   dryRun,
+			Global:   global,
+		})
+	},
+}
+
+func init() {
+	uninstallCmd.Flags().BoolP("force", "f", false, "Uninstall without prompting")
+	uninstallCmd.Flags().Bool("keep-data", false, "Keep the .spcstr directory")
+	uninstallCmd.Flags().Bool("archive", false, "Archive .spcstr to a tar.gz before deleting it")
+	uninstallCmd.Flags().Bool("dry-run", false, "Show what would be removed without changing anything")
+	uninstallCmd.Flags().Bool("global", false, "Remove the hooks installed by init --global")
+	uninstallCmd.MarkFlagsMutuallyExclusive("keep-data", "archive")
+	uninstallCmd.MarkFlagsMutuallyExclusive("global", "keep-data")
+	uninstallCmd.MarkFlagsMutuallyExclusive("global", "archive")
+	rootCmd.AddCommand(uninstallCmd)
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ClaudeUserSettingsPath returns the user's Claude Code settings file in
+// $CLAUDE_CONFIG_DIR, falling back to ~/.claude
+func ClaudeUserSettingsPath() (string, error) {
+	dir := os.Getenv("CLAUDE_CONFIG_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".claude")
+	}
+	return filepath.Join(dir, "settings.json"), nil
+}
+
+// GlobalHookEntries returns the hooks init --global installs with their
+// configured matchers
+func (s *Settings) GlobalHookEntries() []HookEntry {
+	entries := s.HookEntries()
+	for i := range entries {
+		entries[i].Global = true
+	}
+	return entries
+}
+
+// ConfigureGlobalHooks merges spcstr's global hooks, selected by the user
+// settings, into the user's Claude Code settings and returns the changes.
+// With dryRun nothing is written.
+func ConfigureGlobalHooks(dryRun bool) ([]HookChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+
+	settings, err := LoadUserSettings()
+	if err != nil {
+		return nil, err
+	}
+	settingsPath, err := ClaudeUserSettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	return mergeClaudeSettings(ctx, settingsPath, settings.GlobalHookEntries(), dryRun)
+}
+
+// InstalledGlobalHooks returns the spcstr hooks configured in the user's
+// Claude Code settings, in the order of DefaultHookEntries
+func InstalledGlobalHooks() ([]HookEntry, error) {
+	settingsPath, err := ClaudeUserSettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	installed, err := installedHooksIn(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range installed {
+		installed[i].Global = true
+	}
+	return installed, nil
+}
+
+// CapturedHooks returns the hooks that run for the project at projectRoot:
+// those in its .claude/settings.json, then the global hooks it does not
+// install itself, if the project settings allow global tracking
+func CapturedHooks(projectRoot string) ([]HookEntry, error) {
+	local, err := InstalledHooks(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	global, err := InstalledGlobalHooks()
+	if err != nil || len(global) == 0 {
+		return local, err
+	}
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.ProjectAllowed(projectRoot) {
+		return local, nil
+	}
+
+	// The project's own hooks run in place of the global ones
+	byHook := make(map[string]HookEntry, len(global))
+	for _, entry := range global {
+		byHook[entry.Hook] = entry
+	}
+	for _, entry := range local {
+		byHook[entry.Hook] = entry
+	}
+	var captured []HookEntry
+	for _, entry := range DefaultHookEntries() {
+		if installed, ok := byHook[entry.Hook]; ok {
+			captured = append(captured, installed)
+		}
+	}
+	return captured, nil
+}
+
+// PrepareGlobalHook decides whether a hook run from the user's Claude Code
+// settings tracks the project at projectRoot, and creates the project's
+// .spcstr directories the first time it does. A hook the project installs
+// itself runs instead, so no event is recorded twice.
+func PrepareGlobalHook(projectRoot, hookName string) (bool, error) {
+	local, err := InstalledHooks(projectRoot)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range local {
+		if entry.Hook == hookName {
+			return false, nil
+		}
+	}
+
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		return false, err
+	}
+	if !settings.ProjectAllowed(projectRoot) {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	if err := createDirectoryStructure(ctx, projectRoot); err != nil {
+		return false, fmt.Errorf("failed to create .spcstr in %s: %w", projectRoot, err)
+	}
+	return true, nil
+}
+
+// ProjectAllowed reports whether projects.allow and projects.deny let
+// global hooks track the project at projectRoot
+func (s *Settings) ProjectAllowed(projectRoot string) bool {
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		projectRoot = abs
+	}
+	for _, pattern := range s.Projects.Deny {
+		if matchProject(pattern, projectRoot) {
+			return false
+		}
+	}
+	if len(s.Projects.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range s.Projects.Allow {
+		if matchProject(pattern, projectRoot) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchProject matches a project path pattern against projectRoot. A
+// pattern ending in /** also matches every directory below its base.
+func matchProject(pattern, projectRoot string) bool {
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = home + pattern[1:]
+	}
+	pattern = filepath.Clean(pattern)
+
+	base, tree := strings.CutSuffix(pattern, string(filepath.Separator)+"**")
+	if !tree {
+		matched, _ := filepath.Match(pattern, projectRoot)
+		return matched
+	}
+	for dir := projectRoot; ; dir = filepath.Dir(dir) {
+		if matched, _ := filepath.Match(base, dir); matched {
+			return true
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+// initializeGlobal installs spcstr's hooks in the user's Claude Code
+// settings. Projects get their .spcstr directory on the first hook event.
+func initializeGlobal(ctx context.Context, opts InitOptions) error {
+	settings, err := LoadUserSettings()
+	if err != nil {
+		return err
+	}
+	if opts.Hooks != nil {
+		if err := settings.Set("hooks.install", strings.Join(opts.Hooks, ",")); err != nil {
+			return err
+		}
+	}
+	settingsPath, err := ClaudeUserSettingsPath()
+	if err != nil {
+		return err
+	}
+
+	entries := settings.GlobalHookEntries()
+	changes, err := mergeClaudeSettings(ctx, settingsPath, entries, true)
+	if err != nil {
+		return fmt.Errorf("failed to plan Claude Code hooks: %w", err)
+	}
+	if opts.DryRun {
+		printHookChanges(settingsPath, changes)
+		fmt.Println("Dry run: nothing was written.")
+		return nil
+	}
+
+	// Remember the selection so re-init and doctor agree with it
+	if opts.Hooks != nil {
+		userPath, err := UserSettingsPath()
+		if err != nil {
+			return err
+		}
+		install, _ := settings.Get("hooks.install")
+		if err := SetSetting(userPath, "hooks.install", install); err != nil {
+			return fmt.Errorf("failed to save hook selection: %w", err)
+		}
+	}
+
+	if _, err := mergeClaudeSettings(ctx, settingsPath, entries, false); err != nil {
+		return fmt.Errorf("failed to configure Claude Code hooks: %w", err)
+	}
+
+	printHookChanges(settingsPath, changes)
+	if len(changes) > 0 {
+		fmt.Printf("✓ Configured global Claude Code hooks in %s\n", settingsPath)
+	} else {
+		fmt.Printf("✓ Global Claude Code hooks in %s are up to date\n", settingsPath)
+	}
+	fmt.Printf("✓ Capturing %s\n", DescribeCapture(entries))
+	fmt.Println("\nEvery project is now tracked in its own .spcstr, created on first use.")
+	fmt.Println("Limit which projects are tracked with projects.allow and projects.deny.")
+
+	return nil
+}
+
+// uninstallGlobal removes spcstr's hooks from the user's Claude Code
+// settings. The .spcstr directories of tracked projects are kept.
+func uninstallGlobal(ctx context.Context, opts UninstallOptions) error {
+	settingsPath, err := ClaudeUserSettingsPath()
+	if err != nil {
+		return err
+	}
+	settings, err := readSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+	changes, err := RemoveHooks(settings)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("Nothing to uninstall: no spcstr hooks in %s.\n", settingsPath)
+		return nil
+	}
+
+	printHookChanges(settingsPath, changes)
+	if opts.DryRun {
+		fmt.Println("Dry run: nothing was written.")
+		return nil
+	}
+
+	if !opts.Force {
+		confirmed, err := confirm("\nDo you want to remove the global spcstr hooks?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Uninstall cancelled.")
+			return nil
+		}
+	}
+
+	if err := writeSettingsAtomic(ctx, settingsPath, settings); err != nil {
+		return fmt.Errorf("failed to write %s: %w", settingsPath, err)
+	}
+	fmt.Printf("✓ Removed spcstr hooks from %s\n", settingsPath)
+	fmt.Println("Projects keep their .spcstr directories; run spcstr uninstall in each to delete them.")
+	return nil
+}
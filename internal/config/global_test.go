@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectAllowed(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		project string
+		want    bool
+	}{
+		{"defaults allow a project", nil, []string{"~", "/"}, "/srv/app", true},
+		{"defaults deny home", nil, []string{"~", "/"}, home, false},
+		{"defaults deny root", nil, []string{"~", "/"}, "/", false},
+		{"allow tree", []string{"~/work/**"}, nil, filepath.Join(home, "work", "team", "app"), true},
+		{"allow tree base", []string{"~/work/**"}, nil, filepath.Join(home, "work"), true},
+		{"outside allow", []string{"~/work/**"}, nil, "/srv/app", false},
+		{"wildcard", []string{"/srv/*"}, nil, "/srv/app", true},
+		{"wildcard is one level", []string{"/srv/*"}, nil, "/srv/app/sub", false},
+		{"deny beats allow", []string{"/srv/**"}, []string{"/srv/secret/**"}, "/srv/secret/app", false},
+		{"trailing slash", []string{"/srv/app/"}, nil, "/srv/app", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			s.Projects = ProjectSettings{Allow: tt.allow, Deny: tt.deny}
+			if got := s.ProjectAllowed(tt.project); got != tt.want {
+				t.Errorf("ProjectAllowed(%s) = %v, want %v", tt.project, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectPatternsValidated(t *testing.T) {
+	projectRoot, _ := settingsEnv(t)
+	path := ProjectSettingsPath(projectRoot)
+
+	for _, value := range []string{"work/**", "/srv/[app"} {
+		if err := SetSetting(path, "projects.allow", value); err == nil {
+			t.Errorf("SetSetting(projects.allow, %s) succeeded, want error", value)
+		}
+	}
+
+	// An empty list clears the default deny list
+	if err := SetSetting(path, "projects.deny", ""); err != nil {
+		t.Fatalf("SetSetting(projects.deny) error = %v", err)
+	}
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if len(settings.Projects.Deny) != 0 {
+		t.Errorf("Projects.Deny = %v, want empty", settings.Projects.Deny)
+	}
+}
+
+func TestGlobalHooks(t *testing.T) {
+	projectRoot, userPath := settingsEnv(t)
+	os.RemoveAll(filepath.Join(projectRoot, ".spcstr"))
+	writeFile(t, userPath, `{"hooks": {"install": ["session_start", "stop"]}, "projects": {"deny": ["/nowhere"]}}`)
+
+	if err := InitializeProjectWithOptions(InitOptions{Global: true}); err != nil {
+		t.Fatalf("InitializeProjectWithOptions(Global) error = %v", err)
+	}
+	claudePath, _ := ClaudeUserSettingsPath()
+	data, err := os.ReadFile(claudePath)
+	if err != nil {
+		t.Fatalf("global settings not written: %v", err)
+	}
+	if !strings.Contains(string(data), `spcstr hook stop --cwd=\"${CLAUDE_PROJECT_DIR}\" --global`) {
+		t.Errorf("global settings lack the stop hook:\n%s", data)
+	}
+	if dirExists(filepath.Join(projectRoot, ".spcstr")) {
+		t.Error("init --global created .spcstr in the working directory")
+	}
+
+	captured, err := CapturedHooks(projectRoot)
+	if err != nil {
+		t.Fatalf("CapturedHooks() error = %v", err)
+	}
+	if len(captured) != 2 || !captured[0].Global {
+		t.Errorf("CapturedHooks() = %+v, want the 2 global hooks", captured)
+	}
+
+	// The first event creates .spcstr
+	track, err := PrepareGlobalHook(projectRoot, "stop")
+	if err != nil || !track {
+		t.Fatalf("PrepareGlobalHook() = %v, %v, want true", track, err)
+	}
+	if !dirExists(filepath.Join(projectRoot, ".spcstr", "sessions")) || !dirExists(filepath.Join(projectRoot, ".spcstr", "logs")) {
+		t.Error("PrepareGlobalHook did not create .spcstr/sessions and .spcstr/logs")
+	}
+
+	// A hook the project installs itself takes over from the global one
+	if _, err := installClaudeHooks(context.Background(), projectRoot, HookEntriesFor([]string{"stop"}), false); err != nil {
+		t.Fatalf("installClaudeHooks() error = %v", err)
+	}
+	if track, _ := PrepareGlobalHook(projectRoot, "stop"); track {
+		t.Error("global stop hook ran although the project installs it")
+	}
+	if track, _ := PrepareGlobalHook(projectRoot, "session_start"); !track {
+		t.Error("global session_start hook skipped although the project does not install it")
+	}
+	captured, _ = CapturedHooks(projectRoot)
+	if len(captured) != 2 || !captured[0].Global || captured[1].Global {
+		t.Errorf("CapturedHooks() = %+v, want global session_start and project stop", captured)
+	}
+
+	// A denied project is never set up
+	denied := filepath.Join(t.TempDir(), "secret")
+	os.MkdirAll(denied, 0755)
+	t.Setenv(SettingEnvVar("projects.deny"), denied)
+	if track, _ := PrepareGlobalHook(denied, "stop"); track {
+		t.Error("global hook ran in a denied project")
+	}
+	if dirExists(filepath.Join(denied, ".spcstr")) {
+		t.Error("PrepareGlobalHook created .spcstr in a denied project")
+	}
+
+	if err := Uninstall(UninstallOptions{Global: true, Force: true}); err != nil {
+		t.Fatalf("Uninstall(Global) error = %v", err)
+	}
+	if installed, _ := InstalledGlobalHooks(); len(installed) != 0 {
+		t.Errorf("%d global hooks left after uninstall", len(installed))
+	}
+	if !dirExists(filepath.Join(projectRoot, ".spcstr")) {
+		t.Error("uninstall --global deleted project data")
+	}
+}
@@ -0,0 +1,382 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Hook change actions reported by MergeHooks
+const (
+	HookAdded   = "add"
+	HookUpdated = "update"
+	HookRemoved = "remove"
+)
+
+// HookEntry is a spcstr hook command installed for a Claude Code event
+type HookEntry struct {
+	// Event is the Claude Code event name, such as PreToolUse
+	Event string
+	// Matcher is the tool matcher; empty matches every event
+	Matcher string
+	// Hook is the spcstr hook name passed to `spcstr hook`
+	Hook string
+	// Global marks an entry installed in the user's Claude Code settings,
+	// which runs the hook for every project
+	Global bool
+}
+
+// Command returns the command Claude Code runs for the entry
+func (e HookEntry) Command() string {
+	if e.Global {
+		return fmt.Sprintf(`spcstr hook %s --cwd="${CLAUDE_PROJECT_DIR}" --global`, e.Hook)
+	}
+	return fmt.Sprintf(`spcstr hook %s --cwd="${CLAUDE_PROJECT_DIR}"`, e.Hook)
+}
+
+// TakesMatcher reports whether the entry's Claude Code event filters on a
+// matcher: the tool name for tool events, the trigger for PreCompact and
+// the source for SessionStart
+func (e HookEntry) TakesMatcher() bool {
+	switch e.Event {
+	case "PreToolUse", "PostToolUse", "PreCompact", "SessionStart":
+		return true
+	}
+	return false
+}
+
+// CapturesAll reports whether the entry's matcher lets every event through
+func (e HookEntry) CapturesAll() bool {
+	return e.Matcher == "" || e.Matcher == "*"
+}
+
+// DefaultHookEntries returns the hooks spcstr installs, in settings order
+func DefaultHookEntries() []HookEntry {
+	return []HookEntry{
+		{Event: "SessionStart", Hook: "session_start"},
+		{Event: "UserPromptSubmit", Hook: "user_prompt_submit"},
+		{Event: "PreToolUse", Matcher: "*", Hook: "pre_tool_use"},
+		{Event: "PostToolUse", Matcher: "*", Hook: "post_tool_use"},
+		{Event: "Notification", Hook: "notification"},
+		{Event: "PreCompact", Matcher: "*", Hook: "pre_compact"},
+		{Event: "SessionEnd", Hook: "session_end"},
+		{Event: "Stop", Hook: "stop"},
+		{Event: "SubagentStop", Hook: "subagent_stop"},
+	}
+}
+
+// HookEntriesFor returns the default entries of the named hooks, in
+// settings order
+func HookEntriesFor(names []string) []HookEntry {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var entries []HookEntry
+	for _, entry := range DefaultHookEntries() {
+		if wanted[entry.Hook] {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// HookChange is one edit MergeHooks made to the hooks in settings.json
+type HookChange struct {
+	Action  string
+	Event   string
+	Matcher string
+	Command string
+	// OldMatcher and OldCommand describe the entry an update replaced
+	OldMatcher string
+	OldCommand string
+}
+
+// MergeHooks installs entries into the "hooks" section of Claude Code
+// settings without touching hooks spcstr does not own. Existing spcstr
+// commands are kept when current, rewritten when stale, moved when their
+// matcher changed and removed when spcstr no longer installs them or they
+// are duplicated. Missing entries are added. settings is modified in place.
+func MergeHooks(settings map[string]interface{}, entries []HookEntry) ([]HookChange, error) {
+	hooks, err := hooksSection(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := make(map[string]HookEntry, len(entries))
+	for _, entry := range entries {
+		desired[entry.Hook] = entry
+	}
+
+	var changes []HookChange
+	var moved []HookEntry
+	found := make(map[string]bool)
+
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	for _, event := range events {
+		if hooks[event] == nil {
+			continue
+		}
+		groups, ok := hooks[event].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("hooks.%s in settings.json is not a list", event)
+		}
+
+		keptGroups := make([]interface{}, 0, len(groups))
+		for _, g := range groups {
+			group, ok := g.(map[string]interface{})
+			if !ok {
+				keptGroups = append(keptGroups, g)
+				continue
+			}
+			matcher, _ := group["matcher"].(string)
+			commands, ok := group["hooks"].([]interface{})
+			if !ok || len(commands) == 0 {
+				keptGroups = append(keptGroups, g)
+				continue
+			}
+
+			kept := make([]interface{}, 0, len(commands))
+			for _, h := range commands {
+				hook, ok := h.(map[string]interface{})
+				if !ok {
+					kept = append(kept, h)
+					continue
+				}
+				command, _ := hook["command"].(string)
+				name, ours := spcstrHookName(command)
+				if !ours {
+					kept = append(kept, h)
+					continue
+				}
+
+				entry, known := desired[name]
+				if !known || entry.Event != event || found[name] {
+					changes = append(changes, HookChange{Action: HookRemoved, Event: event, Matcher: matcher, Command: command})
+					continue
+				}
+				found[name] = true
+
+				if matcher != entry.Matcher {
+					// Re-added under the right matcher once every group is scanned
+					changes = append(changes, HookChange{
+						Action:     HookUpdated,
+						Event:      event,
+						Matcher:    entry.Matcher,
+						Command:    entry.Command(),
+						OldMatcher: matcher,
+						OldCommand: command,
+					})
+					moved = append(moved, entry)
+					continue
+				}
+				if command != entry.Command() {
+					hook["command"] = entry.Command()
+					changes = append(changes, HookChange{
+						Action:     HookUpdated,
+						Event:      event,
+						Matcher:    matcher,
+						Command:    entry.Command(),
+						OldMatcher: matcher,
+						OldCommand: command,
+					})
+				}
+				kept = append(kept, hook)
+			}
+
+			if len(kept) == 0 {
+				continue
+			}
+			group["hooks"] = kept
+			keptGroups = append(keptGroups, group)
+		}
+
+		if len(keptGroups) == 0 {
+			delete(hooks, event)
+			continue
+		}
+		hooks[event] = keptGroups
+	}
+
+	for _, entry := range moved {
+		addHook(hooks, entry)
+	}
+	for _, entry := range entries {
+		if found[entry.Hook] {
+			continue
+		}
+		addHook(hooks, entry)
+		changes = append(changes, HookChange{Action: HookAdded, Event: entry.Event, Matcher: entry.Matcher, Command: entry.Command()})
+	}
+
+	return changes, nil
+}
+
+// InstalledHooks returns the spcstr hooks configured in the project's
+// .claude/settings.json, in the order of DefaultHookEntries
+func InstalledHooks(projectRoot string) ([]HookEntry, error) {
+	return installedHooksIn(filepath.Join(projectRoot, ".claude", "settings.json"))
+}
+
+// installedHooksIn returns the spcstr hooks configured in a Claude Code
+// settings file
+func installedHooksIn(settingsPath string) ([]HookEntry, error) {
+	settings, err := readSettings(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+	hooks, _ := settings["hooks"].(map[string]interface{})
+
+	var installed []HookEntry
+	for _, entry := range DefaultHookEntries() {
+		groups, _ := hooks[entry.Event].([]interface{})
+		if matcher, ok := findHookMatcher(groups, entry.Hook); ok {
+			installed = append(installed, HookEntry{Event: entry.Event, Matcher: matcher, Hook: entry.Hook})
+		}
+	}
+	return installed, nil
+}
+
+// findHookMatcher returns the matcher of the first group in an event's
+// groups that runs the named spcstr hook
+func findHookMatcher(groups []interface{}, hookName string) (string, bool) {
+	for _, g := range groups {
+		group, _ := g.(map[string]interface{})
+		matcher, _ := group["matcher"].(string)
+		commands, _ := group["hooks"].([]interface{})
+		for _, h := range commands {
+			hook, _ := h.(map[string]interface{})
+			command, _ := hook["command"].(string)
+			if name, ours := spcstrHookName(command); ours && name == hookName {
+				return matcher, true
+			}
+		}
+	}
+	return "", false
+}
+
+// DescribeCapture summarizes which events entries capture, such as
+// "3 of 9 events: session_start, pre_tool_use [Write|Edit], stop"
+func DescribeCapture(entries []HookEntry) string {
+	total := len(DefaultHookEntries())
+	if len(entries) == total {
+		filtered := false
+		for _, entry := range entries {
+			filtered = filtered || !entry.CapturesAll()
+		}
+		if !filtered {
+			return fmt.Sprintf("all %d events", total)
+		}
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Hook
+		if !entry.CapturesAll() {
+			names[i] += " [" + entry.Matcher + "]"
+		}
+	}
+	return fmt.Sprintf("%d of %d events: %s", len(entries), total, strings.Join(names, ", "))
+}
+
+// RemoveHooks removes every spcstr hook command from settings, leaving the
+// hooks of other tools in place. The "hooks" section is dropped once empty.
+func RemoveHooks(settings map[string]interface{}) ([]HookChange, error) {
+	if settings["hooks"] == nil {
+		return nil, nil
+	}
+	changes, err := MergeHooks(settings, nil)
+	if err != nil {
+		return nil, err
+	}
+	if hooks, _ := settings["hooks"].(map[string]interface{}); len(hooks) == 0 && len(changes) > 0 {
+		delete(settings, "hooks")
+	}
+	return changes, nil
+}
+
+// hooksSection returns the "hooks" object of settings, creating it if absent
+func hooksSection(settings map[string]interface{}) (map[string]interface{}, error) {
+	switch hooks := settings["hooks"].(type) {
+	case nil:
+		created := make(map[string]interface{})
+		settings["hooks"] = created
+		return created, nil
+	case map[string]interface{}:
+		return hooks, nil
+	default:
+		return nil, fmt.Errorf("hooks in settings.json is not an object")
+	}
+}
+
+// addHook appends the entry's command to the event's group with the same
+// matcher, creating the group when there is none
+func addHook(hooks map[string]interface{}, entry HookEntry) {
+	command := map[string]interface{}{
+		"type":    "command",
+		"command": entry.Command(),
+	}
+
+	groups, _ := hooks[entry.Event].([]interface{})
+	for _, g := range groups {
+		group, ok := g.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matcher, _ := group["matcher"].(string)
+		commands, ok := group["hooks"].([]interface{})
+		if ok && matcher == entry.Matcher {
+			group["hooks"] = append(commands, command)
+			return
+		}
+	}
+
+	group := map[string]interface{}{
+		"hooks": []interface{}{command},
+	}
+	if entry.Matcher != "" {
+		group["matcher"] = entry.Matcher
+	}
+	hooks[entry.Event] = append(groups, group)
+}
+
+// spcstrHookName returns the hook name of a `spcstr hook <name>` command,
+// whether spcstr is called from PATH or by an absolute path
+func spcstrHookName(command string) (string, bool) {
+	fields := strings.Fields(command)
+	if len(fields) < 3 || filepath.Base(fields[0]) != "spcstr" || fields[1] != "hook" {
+		return "", false
+	}
+	return fields[2], true
+}
+
+// FormatHookChanges renders changes as a diff of settings.json hook commands
+func FormatHookChanges(changes []HookChange) string {
+	var b strings.Builder
+	for _, change := range changes {
+		switch change.Action {
+		case HookAdded:
+			fmt.Fprintf(&b, "+ %s: %s\n", hookLabel(change.Event, change.Matcher), change.Command)
+		case HookRemoved:
+			fmt.Fprintf(&b, "- %s: %s\n", hookLabel(change.Event, change.Matcher), change.Command)
+		case HookUpdated:
+			fmt.Fprintf(&b, "- %s: %s\n", hookLabel(change.Event, change.OldMatcher), change.OldCommand)
+			fmt.Fprintf(&b, "+ %s: %s\n", hookLabel(change.Event, change.Matcher), change.Command)
+		}
+	}
+	return b.String()
+}
+
+// hookLabel names an event and its matcher for FormatHookChanges
+func hookLabel(event, matcher string) string {
+	if matcher == "" {
+		return event
+	}
+	return fmt.Sprintf("%s [%s]", event, matcher)
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseSettings decodes settings JSON the way configureClaudeHooks does
+func parseSettings(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var settings map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		t.Fatalf("invalid test settings: %v", err)
+	}
+	return settings
+}
+
+// eventCommands lists every command configured for an event as
+// "matcher|command"
+func eventCommands(settings map[string]interface{}, event string) []string {
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	groups, _ := hooks[event].([]interface{})
+	var commands []string
+	for _, g := range groups {
+		group := g.(map[string]interface{})
+		matcher, _ := group["matcher"].(string)
+		for _, h := range group["hooks"].([]interface{}) {
+			command, _ := h.(map[string]interface{})["command"].(string)
+			commands = append(commands, matcher+"|"+command)
+		}
+	}
+	return commands
+}
+
+func TestMergeHooks(t *testing.T) {
+	stop := HookEntry{Event: "Stop", Hook: "stop"}
+	preToolUse := HookEntry{Event: "PreToolUse", Matcher: "*", Hook: "pre_tool_use"}
+
+	tests := []struct {
+		name     string
+		settings string
+		entries  []HookEntry
+		actions  []string
+		want     map[string][]string
+	}{
+		{
+			name:     "empty settings",
+			settings: `{}`,
+			entries:  []HookEntry{stop, preToolUse},
+			actions:  []string{HookAdded, HookAdded},
+			want: map[string][]string{
+				"Stop":       {"|" + stop.Command()},
+				"PreToolUse": {"*|" + preToolUse.Command()},
+			},
+		},
+		{
+			name:     "preserves other tools",
+			settings: `{"hooks": {"PreToolUse": [{"matcher": "Bash", "hooks": [{"type": "command", "command": "lint-guard"}]}], "Stop": [{"hooks": [{"type": "command", "command": "notify-send done"}]}]}}`,
+			entries:  []HookEntry{stop, preToolUse},
+			actions:  []string{HookAdded, HookAdded},
+			want: map[string][]string{
+				"Stop":       {"|notify-send done", "|" + stop.Command()},
+				"PreToolUse": {"Bash|lint-guard", "*|" + preToolUse.Command()},
+			},
+		},
+		{
+			name:     "up to date",
+			settings: `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "spcstr hook stop --cwd=\"${CLAUDE_PROJECT_DIR}\""}]}]}}`,
+			entries:  []HookEntry{stop},
+			actions:  nil,
+			want: map[string][]string{
+				"Stop": {"|" + stop.Command()},
+			},
+		},
+		{
+			name:     "updates stale command in place",
+			settings: `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "fmt-all"}, {"type": "command", "command": "/usr/local/bin/spcstr hook stop", "timeout": 30}]}]}}`,
+			entries:  []HookEntry{stop},
+			actions:  []string{HookUpdated},
+			want: map[string][]string{
+				"Stop": {"|fmt-all", "|" + stop.Command()},
+			},
+		},
+		{
+			name:     "moves entry to the right matcher",
+			settings: `{"hooks": {"PreToolUse": [{"matcher": "Bash", "hooks": [{"type": "command", "command": "spcstr hook pre_tool_use"}, {"type": "command", "command": "lint-guard"}]}]}}`,
+			entries:  []HookEntry{preToolUse},
+			actions:  []string{HookUpdated},
+			want: map[string][]string{
+				"PreToolUse": {"Bash|lint-guard", "*|" + preToolUse.Command()},
+			},
+		},
+		{
+			name:     "removes duplicates, misplaced and unknown spcstr hooks",
+			settings: `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "spcstr hook stop --cwd=\"${CLAUDE_PROJECT_DIR}\""}, {"type": "command", "command": "spcstr hook stop"}]}], "SubagentStop": [{"hooks": [{"type": "command", "command": "spcstr hook stop"}]}], "Notification": [{"hooks": [{"type": "command", "command": "spcstr hook retired"}]}]}}`,
+			entries:  []HookEntry{stop},
+			actions:  []string{HookRemoved, HookRemoved, HookRemoved},
+			want: map[string][]string{
+				"Stop":         {"|" + stop.Command()},
+				"SubagentStop": nil,
+				"Notification": nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := parseSettings(t, tt.settings)
+
+			changes, err := MergeHooks(settings, tt.entries)
+			if err != nil {
+				t.Fatalf("MergeHooks() error = %v", err)
+			}
+
+			var actions []string
+			for _, change := range changes {
+				actions = append(actions, change.Action)
+			}
+			if strings.Join(actions, ",") != strings.Join(tt.actions, ",") {
+				t.Errorf("actions = %v, want %v", actions, tt.actions)
+			}
+
+			for event, want := range tt.want {
+				got := eventCommands(settings, event)
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("%s commands = %q, want %q", event, got, want)
+				}
+			}
+
+			// A second merge must find nothing to do
+			again, err := MergeHooks(settings, tt.entries)
+			if err != nil {
+				t.Fatalf("second MergeHooks() error = %v", err)
+			}
+			if len(again) != 0 {
+				t.Errorf("second MergeHooks() changes = %+v, want none", again)
+			}
+		})
+	}
+}
+
+func TestMergeHooksKeepsExtraFields(t *testing.T) {
+	settings := parseSettings(t, `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "spcstr hook stop", "timeout": 30}]}]}}`)
+
+	if _, err := MergeHooks(settings, []HookEntry{{Event: "Stop", Hook: "stop"}}); err != nil {
+		t.Fatalf("MergeHooks() error = %v", err)
+	}
+
+	hook := settings["hooks"].(map[string]interface{})["Stop"].([]interface{})[0].(map[string]interface{})["hooks"].([]interface{})[0].(map[string]interface{})
+	if hook["timeout"] != float64(30) {
+		t.Errorf("timeout = %v, want 30", hook["timeout"])
+	}
+}
+
+func TestMergeHooksRejectsMalformedSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+	}{
+		{"hooks not an object", `{"hooks": []}`},
+		{"event not a list", `{"hooks": {"Stop": {"command": "x"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MergeHooks(parseSettings(t, tt.settings), DefaultHookEntries()); err == nil {
+				t.Error("MergeHooks() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestFormatHookChanges(t *testing.T) {
+	changes := []HookChange{
+		{Action: HookAdded, Event: "PreToolUse", Matcher: "*", Command: "spcstr hook pre_tool_use"},
+		{Action: HookUpdated, Event: "Stop", Command: "spcstr hook stop --cwd=x", OldCommand: "spcstr hook stop"},
+		{Action: HookRemoved, Event: "Notification", Command: "spcstr hook retired"},
+	}
+
+	want := "+ PreToolUse [*]: spcstr hook pre_tool_use\n" +
+		"- Stop: spcstr hook stop\n" +
+		"+ Stop: spcstr hook stop --cwd=x\n" +
+		"- Notification: spcstr hook retired\n"
+	if got := FormatHookChanges(changes); got != want {
+		t.Errorf("FormatHookChanges() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConfigureClaudeHooksDryRun(t *testing.T) {
+	projectRoot := t.TempDir()
+	claudeDir := filepath.Join(projectRoot, ".claude")
+	os.MkdirAll(claudeDir, 0755)
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+	original := []byte(`{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "notify-send done"}]}]}}`)
+	os.WriteFile(settingsPath, original, 0644)
+
+	changes, err := configureClaudeHooks(context.Background(), projectRoot, true)
+	if err != nil {
+		t.Fatalf("configureClaudeHooks() error = %v", err)
+	}
+	if len(changes) != len(DefaultHookEntries()) {
+		t.Errorf("dry run planned %d changes, want %d", len(changes), len(DefaultHookEntries()))
+	}
+	data, _ := os.ReadFile(settingsPath)
+	if string(data) != string(original) {
+		t.Errorf("dry run rewrote settings.json:\n%s", data)
+	}
+
+	if _, err := configureClaudeHooks(context.Background(), projectRoot, false); err != nil {
+		t.Fatalf("configureClaudeHooks() error = %v", err)
+	}
+	changes, err = configureClaudeHooks(context.Background(), projectRoot, false)
+	if err != nil {
+		t.Fatalf("re-init configureClaudeHooks() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("re-init changes = %+v, want none", changes)
+	}
+	data, _ = os.ReadFile(settingsPath)
+	if !strings.Contains(string(data), "notify-send done") {
+		t.Error("init dropped an existing Stop hook")
+	}
+}
@@ -13,32 +13,74 @@ import (
 
 const DEFAULT_TIMEOUT = 30 * time.Second
 
+// InitOptions controls how a project is initialized
+type InitOptions struct {
+	// Force reinitializes an existing project without prompting
+	Force bool
+	// DryRun prints the changes to .claude/settings.json without writing
+	// anything
+	DryRun bool
+	// Hooks selects the hooks to install, overriding hooks.install. The
+	// selection is saved to the project settings.
+	Hooks []string
+	// Global installs the hooks in the user's Claude Code settings instead
+	// of the project's, so every project is tracked without an init
+	Global bool
+}
+
 // InitializeProject initializes a project for spcstr usage
 func InitializeProject(force bool) error {
+	return InitializeProjectWithOptions(InitOptions{Force: force})
+}
+
+// InitializeProjectWithOptions initializes a project for spcstr usage. Hooks
+// are merged into .claude/settings.json, so hooks from other tools survive
+// and a re-init only rewrites stale spcstr entries.
+func InitializeProjectWithOptions(opts InitOptions) error {
 	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
 	defer cancel()
 
+	if opts.Global {
+		return initializeGlobal(ctx, opts)
+	}
+
 	// Get current working directory
 	projectRoot, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		return err
+	}
+	if opts.Hooks != nil {
+		if err := settings.Set("hooks.install", strings.Join(opts.Hooks, ",")); err != nil {
+			return err
+		}
+	}
+
+	if opts.DryRun {
+		changes, err := installClaudeHooks(ctx, projectRoot, settings.HookEntries(), true)
+		if err != nil {
+			return fmt.Errorf("failed to plan Claude Code hooks: %w", err)
+		}
+		printHookChanges(".claude/settings.json", changes)
+		fmt.Println("Dry run: nothing was written.")
+		return nil
+	}
+
 	// Check if .spcstr already exists
 	spcstrDir := filepath.Join(projectRoot, ".spcstr")
-	if dirExists(spcstrDir) && !force {
+	if dirExists(spcstrDir) && !opts.Force {
 		fmt.Printf("Directory .spcstr already exists in %s\n", projectRoot)
 
 		// Prompt for confirmation
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Do you want to reinitialize? This will preserve existing data. (y/N): ")
-		response, err := reader.ReadString('\n')
+		confirmed, err := confirm("Do you want to reinitialize? This will preserve existing data.")
 		if err != nil {
-			return fmt.Errorf("failed to read user input: %w", err)
+			return err
 		}
-
-		response = strings.TrimSpace(strings.ToLower(response))
-		if response != "y" && response != "yes" {
+		if !confirmed {
 			fmt.Println("Initialization cancelled.")
 			return nil
 		}
@@ -49,19 +91,46 @@ func InitializeProject(force bool) error {
 		return fmt.Errorf("failed to create directory structure: %w", err)
 	}
 
+	// Remember the selection so re-init and doctor agree with it
+	if opts.Hooks != nil {
+		install, _ := settings.Get("hooks.install")
+		if err := SetSetting(ProjectSettingsPath(projectRoot), "hooks.install", install); err != nil {
+			return fmt.Errorf("failed to save hook selection: %w", err)
+		}
+	}
+
 	// Configure Claude Code hooks
-	if err := configureClaudeHooks(ctx, projectRoot); err != nil {
+	entries := settings.HookEntries()
+	changes, err := installClaudeHooks(ctx, projectRoot, entries, false)
+	if err != nil {
 		return fmt.Errorf("failed to configure Claude Code hooks: %w", err)
 	}
 
+	printHookChanges(".claude/settings.json", changes)
 	fmt.Printf("✓ Successfully initialized spcstr in %s\n", projectRoot)
 	fmt.Println("✓ Created .spcstr/logs and .spcstr/sessions directories")
-	fmt.Println("✓ Configured Claude Code hooks in .claude/settings.json")
+	if len(changes) > 0 {
+		fmt.Println("✓ Configured Claude Code hooks in .claude/settings.json")
+	} else {
+		fmt.Println("✓ Claude Code hooks in .claude/settings.json are up to date")
+	}
+	fmt.Printf("✓ Capturing %s\n", DescribeCapture(entries))
 	fmt.Println("\nYour project is now ready for Claude Code session tracking!")
 
 	return nil
 }
 
+// printHookChanges prints the diff of hook commands in a settings file
+func printHookChanges(file string, changes []HookChange) {
+	if len(changes) == 0 {
+		fmt.Printf("No changes to %s\n", file)
+		return
+	}
+	fmt.Printf("Changes to %s:\n", file)
+	fmt.Print(FormatHookChanges(changes))
+	fmt.Println()
+}
+
 // createDirectoryStructure creates the .spcstr directory structure
 func createDirectoryStructure(ctx context.Context, projectRoot string) error {
 	// Check context before operations
@@ -86,34 +155,80 @@ func createDirectoryStructure(ctx context.Context, projectRoot string) error {
 	return nil
 }
 
-// configureClaudeHooks configures hooks in .claude/settings.json
-func configureClaudeHooks(ctx context.Context, projectRoot string) error {
-	// Check context before operations
+// ConfigureClaudeHooks merges spcstr's hooks into the project's
+// .claude/settings.json and returns the changes. With dryRun nothing is
+// written.
+func ConfigureClaudeHooks(projectRoot string, dryRun bool) ([]HookChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	return configureClaudeHooks(ctx, projectRoot, dryRun)
+}
+
+// configureClaudeHooks merges the hooks selected in the spcstr settings into
+// .claude/settings.json and returns the changes. With dryRun nothing is
+// written.
+func configureClaudeHooks(ctx context.Context, projectRoot string, dryRun bool) ([]HookChange, error) {
+	spcstrSettings, err := LoadSettings(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	return installClaudeHooks(ctx, projectRoot, spcstrSettings.HookEntries(), dryRun)
+}
+
+// installClaudeHooks merges entries into .claude/settings.json and returns
+// the changes. With dryRun nothing is written.
+func installClaudeHooks(ctx context.Context, projectRoot string, entries []HookEntry, dryRun bool) ([]HookChange, error) {
+	return mergeClaudeSettings(ctx, filepath.Join(projectRoot, ".claude", "settings.json"), entries, dryRun)
+}
+
+// mergeClaudeSettings merges entries into the Claude Code settings file at
+// settingsPath and returns the changes. With dryRun nothing is written.
+func mergeClaudeSettings(ctx context.Context, settingsPath string, entries []HookEntry, dryRun bool) ([]HookChange, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
+	settings, err := readSettings(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := MergeHooks(settings, entries)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || (len(changes) == 0 && fileExists(settingsPath)) {
+		return changes, nil
+	}
+
 	// Ensure .claude directory exists
-	claudeDir := filepath.Join(projectRoot, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .claude directory: %w", err)
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create .claude directory: %w", err)
 	}
 
-	settingsPath := filepath.Join(claudeDir, "settings.json")
+	// Write settings using atomic operation (temp file + rename)
+	if err := writeSettingsAtomic(ctx, settingsPath, settings); err != nil {
+		return nil, fmt.Errorf("failed to write settings.json: %w", err)
+	}
+
+	return changes, nil
+}
 
-	// Read existing settings if file exists
+// readSettings reads Claude Code settings, returning empty settings when the
+// file is missing or empty
+func readSettings(path string) (map[string]interface{}, error) {
 	var settings map[string]interface{}
-	if fileExists(settingsPath) {
-		data, err := os.ReadFile(settingsPath)
+	if fileExists(path) {
+		data, err := os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("failed to read existing settings.json: %w", err)
+			return nil, fmt.Errorf("failed to read existing settings.json: %w", err)
 		}
 
 		if len(data) > 0 {
 			if err := json.Unmarshal(data, &settings); err != nil {
-				return fmt.Errorf("failed to parse existing settings.json: %w", err)
+				return nil, fmt.Errorf("failed to parse existing settings.json: %w", err)
 			}
 		}
 	}
@@ -121,114 +236,20 @@ func configureClaudeHooks(ctx context.Context, projectRoot string) error {
 	if settings == nil {
 		settings = make(map[string]interface{})
 	}
+	return settings, nil
+}
 
-	// Define hooks in correct Claude Code format
-	hooks := map[string]interface{}{
-		"SessionStart": []map[string]interface{}{
-			{
-				"matcher": "",
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook session_start --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"UserPromptSubmit": []map[string]interface{}{
-			{
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook user_prompt_submit --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"PreToolUse": []map[string]interface{}{
-			{
-				"matcher": "*",
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook pre_tool_use --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"PostToolUse": []map[string]interface{}{
-			{
-				"matcher": "*",
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook post_tool_use --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"Notification": []map[string]interface{}{
-			{
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook notification --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"PreCompact": []map[string]interface{}{
-			{
-				"matcher": "*",
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook pre_compact --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"SessionEnd": []map[string]interface{}{
-			{
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook session_end --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"Stop": []map[string]interface{}{
-			{
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook stop --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-		"SubagentStop": []map[string]interface{}{
-			{
-				"hooks": []map[string]interface{}{
-					{
-						"type":    "command",
-						"command": `spcstr hook subagent_stop --cwd="${CLAUDE_PROJECT_DIR}"`,
-					},
-				},
-			},
-		},
-	}
-
-	// Set hooks in settings
-	settings["hooks"] = hooks
-
-	// Write settings using atomic operation (temp file + rename)
-	if err := writeSettingsAtomic(ctx, settingsPath, settings); err != nil {
-		return fmt.Errorf("failed to write settings.json: %w", err)
+// confirm asks a yes/no question on stdin; anything but y or yes is no
+func confirm(question string) (bool, error) {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("%s (y/N): ", question)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read user input: %w", err)
 	}
 
-	return nil
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes", nil
 }
 
 // writeSettingsAtomic writes settings.json using atomic file operation
@@ -89,7 +89,7 @@ func TestConfigureClaudeHooks(t *testing.T) {
 				os.WriteFile(settingsPath, data, 0644)
 			}
 
-			err := configureClaudeHooks(ctx, projectRoot)
+			_, err := configureClaudeHooks(ctx, projectRoot, false)
 
 			if tt.expectError && err == nil {
 				t.Error("expected error but got none")
@@ -412,3 +412,36 @@ func TestInitializeProject(t *testing.T) {
 		})
 	}
 }
+
+func TestInitializeProjectSelectsHooks(t *testing.T) {
+	tempDir, _ := settingsEnv(t)
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tempDir)
+
+	if err := InitializeProjectWithOptions(InitOptions{Force: true, Hooks: []string{"pre_commit"}}); err == nil {
+		t.Fatal("unknown hook was accepted")
+	}
+	if fileExists(filepath.Join(tempDir, ".claude", "settings.json")) {
+		t.Error("settings.json written although the hook selection is invalid")
+	}
+
+	if err := InitializeProjectWithOptions(InitOptions{Force: true, Hooks: []string{"session_start", "stop"}}); err != nil {
+		t.Fatalf("InitializeProjectWithOptions() error = %v", err)
+	}
+	installed, err := InstalledHooks(tempDir)
+	if err != nil {
+		t.Fatalf("InstalledHooks() error = %v", err)
+	}
+	if len(installed) != 2 || installed[0].Hook != "session_start" || installed[1].Hook != "stop" {
+		t.Errorf("installed hooks = %+v, want session_start and stop", installed)
+	}
+
+	// The selection is saved, so a plain re-init keeps it
+	if err := InitializeProject(true); err != nil {
+		t.Fatalf("InitializeProject() error = %v", err)
+	}
+	if installed, _ := InstalledHooks(tempDir); len(installed) != 2 {
+		t.Errorf("re-init installed %d hooks, want 2", len(installed))
+	}
+}
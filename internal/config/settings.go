@@ -0,0 +1,642 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SettingsFileName is the spcstr settings file, kept in .spcstr for a
+// project and in $XDG_CONFIG_HOME/spcstr for the user
+const SettingsFileName = "settings.json"
+
+// EnvPrefix starts the environment variables that override settings.
+// todos.recent_limit is overridden by SPCSTR_TODOS_RECENT_LIMIT.
+const EnvPrefix = "SPCSTR_"
+
+// Setting sources, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceUser    = "user"
+	SourceProject = "project"
+	SourceEnv     = "env"
+	// SourceFlag marks a value given on the command line
+	SourceFlag = "flag"
+)
+
+// Settings are the tunable spcstr behaviors, layered from built-in defaults,
+// the user settings file, the project settings file and SPCSTR_* variables
+type Settings struct {
+	Todos    TodoSettings
+	State    StateSettings
+	Hooks    HookSettings
+	Docs     DocsSettings
+	Logs     LogSettings
+	Projects ProjectSettings
+
+	// Sources maps each key to the layer its value came from
+	Sources map[string]string
+}
+
+// TodoSettings control how todo lists are recorded
+type TodoSettings struct {
+	// RecentLimit caps the todos kept in a session's recent list
+	RecentLimit int
+}
+
+// StateSettings control session state persistence
+type StateSettings struct {
+	// Timeout bounds each state file operation of a hook
+	Timeout time.Duration
+}
+
+// HookSettings control the hooks spcstr installs
+type HookSettings struct {
+	// Install names the hooks init writes to .claude/settings.json
+	Install []string
+	// Matchers override the default matcher of hooks that take one, such
+	// as "Write|Edit|Bash" to track only those tools in pre_tool_use
+	Matchers map[string]string
+}
+
+// DocsSettings locate the project documentation browsed in plan view
+type DocsSettings struct {
+	// Root is the directory scanned for markdown, relative to the project
+	Root string
+	// Epics is the directory holding epics, relative to the project
+	Epics string
+}
+
+// LogSettings control rotation of the hook logs in .spcstr/logs
+type LogSettings struct {
+	// MaxSizeMB rotates a hook's log once it reaches this size; 0 never does
+	MaxSizeMB int
+	// MaxAge rotates a hook's log once its first event is this old; 0 never does
+	MaxAge time.Duration
+	// MaxSegments is how many rotated segments to keep per hook; 0 keeps
+	// all of them, so replay can read the whole history
+	MaxSegments int
+	// Compress gzips rotated segments
+	Compress bool
+}
+
+// ProjectSettings choose the projects hooks installed by init --global
+// track. Patterns are absolute paths or start with ~, may use filepath.Match
+// wildcards and match a whole directory tree when they end in /**.
+type ProjectSettings struct {
+	// Allow limits tracking to matching projects; empty allows every project
+	Allow []string
+	// Deny excludes matching projects and beats Allow
+	Deny []string
+}
+
+// settingKind decides how a value is stored in a settings file
+type settingKind int
+
+const (
+	kindString settingKind = iota
+	kindInt
+	kindDuration
+	kindList
+	kindBool
+)
+
+// settingKey describes one dotted settings key
+type settingKey struct {
+	name string
+	kind settingKind
+	get  func(s *Settings) string
+	// set parses and validates a value, as written on the command line
+	set func(s *Settings, value string) error
+}
+
+var settingKeys = append([]settingKey{
+	{
+		name: "docs.epics",
+		kind: kindString,
+		get:  func(s *Settings) string { return s.Docs.Epics },
+		set: func(s *Settings, value string) error {
+			path, err := relativePath(value)
+			s.Docs.Epics = path
+			return err
+		},
+	},
+	{
+		name: "docs.root",
+		kind: kindString,
+		get:  func(s *Settings) string { return s.Docs.Root },
+		set: func(s *Settings, value string) error {
+			path, err := relativePath(value)
+			s.Docs.Root = path
+			return err
+		},
+	},
+	{
+		name: "hooks.install",
+		kind: kindList,
+		get:  func(s *Settings) string { return strings.Join(s.Hooks.Install, ",") },
+		set: func(s *Settings, value string) error {
+			names := splitList(value)
+			if len(names) == 0 {
+				return fmt.Errorf("must name at least one hook; use spcstr uninstall to remove them all")
+			}
+			for _, name := range names {
+				if !knownHook(name) {
+					return fmt.Errorf("unknown hook %q", name)
+				}
+			}
+			s.Hooks.Install = names
+			return nil
+		},
+	},
+	{
+		name: "logs.compress",
+		kind: kindBool,
+		get:  func(s *Settings) string { return strconv.FormatBool(s.Logs.Compress) },
+		set: func(s *Settings, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", value)
+			}
+			s.Logs.Compress = b
+			return nil
+		},
+	},
+	{
+		name: "logs.max_age",
+		kind: kindDuration,
+		get:  func(s *Settings) string { return s.Logs.MaxAge.String() },
+		set: func(s *Settings, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", value)
+			}
+			if d < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			s.Logs.MaxAge = d
+			return nil
+		},
+	},
+	{
+		name: "logs.max_segments",
+		kind: kindInt,
+		get:  func(s *Settings) string { return strconv.Itoa(s.Logs.MaxSegments) },
+		set: func(s *Settings, value string) error {
+			n, err := nonNegativeInt(value)
+			s.Logs.MaxSegments = n
+			return err
+		},
+	},
+	{
+		name: "logs.max_size_mb",
+		kind: kindInt,
+		get:  func(s *Settings) string { return strconv.Itoa(s.Logs.MaxSizeMB) },
+		set: func(s *Settings, value string) error {
+			n, err := nonNegativeInt(value)
+			s.Logs.MaxSizeMB = n
+			return err
+		},
+	},
+	{
+		name: "projects.allow",
+		kind: kindList,
+		get:  func(s *Settings) string { return strings.Join(s.Projects.Allow, ",") },
+		set: func(s *Settings, value string) error {
+			patterns, err := projectPatterns(value)
+			s.Projects.Allow = patterns
+			return err
+		},
+	},
+	{
+		name: "projects.deny",
+		kind: kindList,
+		get:  func(s *Settings) string { return strings.Join(s.Projects.Deny, ",") },
+		set: func(s *Settings, value string) error {
+			patterns, err := projectPatterns(value)
+			s.Projects.Deny = patterns
+			return err
+		},
+	},
+	{
+		name: "state.timeout",
+		kind: kindDuration,
+		get:  func(s *Settings) string { return s.State.Timeout.String() },
+		set: func(s *Settings, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", value)
+			}
+			if d <= 0 {
+				return fmt.Errorf("must be positive")
+			}
+			s.State.Timeout = d
+			return nil
+		},
+	},
+	{
+		name: "todos.recent_limit",
+		kind: kindInt,
+		get:  func(s *Settings) string { return strconv.Itoa(s.Todos.RecentLimit) },
+		set: func(s *Settings, value string) error {
+			n, err := nonNegativeInt(value)
+			s.Todos.RecentLimit = n
+			return err
+		},
+	},
+}, matcherKeys()...)
+
+// matcherKeys returns a hooks.matchers.<hook> key for every hook whose
+// Claude Code event takes a matcher
+func matcherKeys() []settingKey {
+	var keys []settingKey
+	for _, entry := range DefaultHookEntries() {
+		if !entry.TakesMatcher() {
+			continue
+		}
+		hook := entry.Hook
+		keys = append(keys, settingKey{
+			name: "hooks.matchers." + hook,
+			kind: kindString,
+			get: func(s *Settings) string {
+				if matcher, ok := s.Hooks.Matchers[hook]; ok {
+					return matcher
+				}
+				return entry.Matcher
+			},
+			set: func(s *Settings, value string) error {
+				value = strings.TrimSpace(value)
+				if value != "*" {
+					if _, err := regexp.Compile(value); err != nil {
+						return fmt.Errorf("invalid matcher %q: %w", value, err)
+					}
+				}
+				matchers := make(map[string]string, len(s.Hooks.Matchers)+1)
+				for name, matcher := range s.Hooks.Matchers {
+					matchers[name] = matcher
+				}
+				matchers[hook] = value
+				s.Hooks.Matchers = matchers
+				return nil
+			},
+		})
+	}
+	return keys
+}
+
+// DefaultSettings returns the built-in settings
+func DefaultSettings() *Settings {
+	s := &Settings{
+		Todos: TodoSettings{RecentLimit: 5},
+		State: StateSettings{Timeout: 5 * time.Second},
+		Docs:  DocsSettings{Root: "docs", Epics: "docs/epics"},
+		Logs:  LogSettings{MaxSizeMB: 10, MaxAge: 7 * 24 * time.Hour, Compress: true},
+		// Claude Code started outside a project must not litter the home
+		// directory or the filesystem root with .spcstr
+		Projects: ProjectSettings{Deny: []string{"~", "/"}},
+		Sources:  make(map[string]string, len(settingKeys)),
+	}
+	for _, entry := range DefaultHookEntries() {
+		s.Hooks.Install = append(s.Hooks.Install, entry.Hook)
+	}
+	for _, key := range settingKeys {
+		s.Sources[key.name] = SourceDefault
+	}
+	return s
+}
+
+// SettingKeys returns every settings key, sorted
+func SettingKeys() []string {
+	names := make([]string, len(settingKeys))
+	for i, key := range settingKeys {
+		names[i] = key.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SettingEnvVar returns the environment variable that overrides key
+func SettingEnvVar(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// ProjectSettingsPath returns the settings file of the project at projectRoot
+func ProjectSettingsPath(projectRoot string) string {
+	return filepath.Join(projectRoot, ".spcstr", SettingsFileName)
+}
+
+// UserSettingsPath returns the user's settings file in $XDG_CONFIG_HOME,
+// falling back to ~/.config
+func UserSettingsPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the user config directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "spcstr", SettingsFileName), nil
+}
+
+// LoadSettings layers the user settings file, the project's settings file
+// and SPCSTR_* environment variables over the defaults. Missing files are
+// skipped; invalid values are errors naming the file and key.
+func LoadSettings(projectRoot string) (*Settings, error) {
+	return loadSettings(ProjectSettingsPath(projectRoot))
+}
+
+// LoadUserSettings layers the user settings file and SPCSTR_* environment
+// variables over the defaults, for settings that apply outside a project
+func LoadUserSettings() (*Settings, error) {
+	return loadSettings("")
+}
+
+// loadSettings layers the user file, the project file at projectPath, if
+// any, and the environment over the defaults
+func loadSettings(projectPath string) (*Settings, error) {
+	s := DefaultSettings()
+
+	userPath, err := UserSettingsPath()
+	if err == nil {
+		if err := s.applyFile(userPath, SourceUser); err != nil {
+			return nil, err
+		}
+	}
+	if projectPath != "" {
+		if err := s.applyFile(projectPath, SourceProject); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range settingKeys {
+		env := SettingEnvVar(key.name)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := key.set(s, value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %w", env, key.name, err)
+		}
+		s.Sources[key.name] = SourceEnv
+	}
+
+	return s, nil
+}
+
+// Set validates and applies a value for a settings key, as given on the
+// command line, without storing it
+func (s *Settings) Set(name, value string) error {
+	key, err := lookupSettingKey(name)
+	if err != nil {
+		return err
+	}
+	if err := key.set(s, value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	s.Sources[name] = SourceFlag
+	return nil
+}
+
+// HookEntries returns the hooks to install with their configured matchers
+func (s *Settings) HookEntries() []HookEntry {
+	entries := HookEntriesFor(s.Hooks.Install)
+	for i, entry := range entries {
+		if matcher, ok := s.Hooks.Matchers[entry.Hook]; ok {
+			entries[i].Matcher = matcher
+		}
+	}
+	return entries
+}
+
+// Get returns the value of a settings key as text
+func (s *Settings) Get(name string) (string, error) {
+	key, err := lookupSettingKey(name)
+	if err != nil {
+		return "", err
+	}
+	return key.get(s), nil
+}
+
+// applyFile sets every key found in a settings file
+func (s *Settings) applyFile(path, source string) error {
+	values, err := readSettingsFile(path)
+	if err != nil {
+		return err
+	}
+
+	flat := make(map[string]interface{})
+	flattenSettings("", values, flat)
+	names := make([]string, 0, len(flat))
+	for name := range flat {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key, err := lookupSettingKey(name)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", path, err)
+		}
+		value, err := settingText(flat[name])
+		if err == nil {
+			err = key.set(s, value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s: %w", path, name, err)
+		}
+		s.Sources[name] = source
+	}
+	return nil
+}
+
+// SetSetting validates value for key and stores it in the settings file at
+// path, keeping the file's other keys
+func SetSetting(path, name, value string) error {
+	key, err := lookupSettingKey(name)
+	if err != nil {
+		return err
+	}
+	if err := key.set(DefaultSettings(), value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	values, err := readSettingsFile(path)
+	if err != nil {
+		return err
+	}
+
+	var stored interface{} = value
+	switch key.kind {
+	case kindInt:
+		stored, _ = strconv.Atoi(value)
+	case kindBool:
+		stored, _ = strconv.ParseBool(value)
+	case kindList:
+		// An empty list is stored as [], which clears the default
+		items := splitList(value)
+		if items == nil {
+			items = []string{}
+		}
+		stored = items
+	}
+
+	// Walk down to the key's section, creating it or replacing a
+	// misplaced scalar
+	parts := strings.Split(name, ".")
+	section := values
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := section[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			section[part] = next
+		}
+		section = next
+	}
+	section[parts[len(parts)-1]] = stored
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	if err := writeSettingsAtomic(ctx, path, values); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// readSettingsFile parses a settings file; a missing or empty file yields
+// no values
+func readSettingsFile(path string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return values, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return values, nil
+	}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// lookupSettingKey finds a key by its dotted name
+func lookupSettingKey(name string) (settingKey, error) {
+	for _, key := range settingKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	return settingKey{}, fmt.Errorf("unknown settings key %q (known keys: %s)", name, strings.Join(SettingKeys(), ", "))
+}
+
+// flattenSettings turns nested objects into dotted keys
+func flattenSettings(prefix string, values map[string]interface{}, flat map[string]interface{}) {
+	for name, value := range values {
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenSettings(name, nested, flat)
+			continue
+		}
+		flat[name] = value
+	}
+}
+
+// settingText converts a JSON value to the text form the setters parse
+func settingText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("list items must be strings")
+			}
+			items[i] = text
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// splitList parses a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// nonNegativeInt parses a count that may be zero
+func nonNegativeInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return n, nil
+}
+
+// relativePath validates a path inside the project
+func relativePath(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return "", fmt.Errorf("must not be empty")
+	case filepath.IsAbs(value):
+		return "", fmt.Errorf("must be relative to the project root")
+	}
+	return filepath.Clean(value), nil
+}
+
+// projectPatterns parses a list of project path patterns
+func projectPatterns(value string) ([]string, error) {
+	patterns := splitList(value)
+	for _, pattern := range patterns {
+		if pattern != "~" && !strings.HasPrefix(pattern, "~/") && !filepath.IsAbs(pattern) {
+			return nil, fmt.Errorf("pattern %q must be an absolute path or start with ~", pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// knownHook reports whether spcstr has a hook named name
+func knownHook(name string) bool {
+	for _, entry := range DefaultHookEntries() {
+		if entry.Hook == name {
+			return true
+		}
+	}
+	return false
+}
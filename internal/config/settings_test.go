@@ -0,0 +1,265 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// settingsEnv isolates a test from the user's settings and SPCSTR_* variables
+func settingsEnv(t *testing.T) (projectRoot, userPath string) {
+	t.Helper()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+	for _, key := range SettingKeys() {
+		t.Setenv(SettingEnvVar(key), "")
+		os.Unsetenv(SettingEnvVar(key))
+	}
+
+	projectRoot = t.TempDir()
+	os.MkdirAll(filepath.Join(projectRoot, ".spcstr"), 0755)
+	return projectRoot, filepath.Join(xdg, "spcstr", SettingsFileName)
+}
+
+// writeFile creates path with data, including its directory
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadSettingsLayers(t *testing.T) {
+	projectRoot, userPath := settingsEnv(t)
+	writeFile(t, userPath, `{"todos": {"recent_limit": 8}, "state": {"timeout": "2s"}, "docs": {"root": "manual"}}`)
+	writeFile(t, ProjectSettingsPath(projectRoot), `{"todos": {"recent_limit": 3}, "hooks": {"install": ["stop", "session_start"]}}`)
+	t.Setenv("SPCSTR_DOCS_ROOT", "handbook")
+
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+
+	if settings.Todos.RecentLimit != 3 {
+		t.Errorf("RecentLimit = %d, want 3 from the project", settings.Todos.RecentLimit)
+	}
+	if settings.State.Timeout != 2*time.Second {
+		t.Errorf("Timeout = %s, want 2s from the user file", settings.State.Timeout)
+	}
+	if settings.Docs.Root != "handbook" {
+		t.Errorf("Docs.Root = %q, want handbook from the environment", settings.Docs.Root)
+	}
+	if strings.Join(settings.Hooks.Install, ",") != "stop,session_start" {
+		t.Errorf("Hooks.Install = %v, want [stop session_start]", settings.Hooks.Install)
+	}
+
+	wantSources := map[string]string{
+		"todos.recent_limit": SourceProject,
+		"state.timeout":      SourceUser,
+		"docs.root":          SourceEnv,
+		"docs.epics":         SourceDefault,
+		"hooks.install":      SourceProject,
+	}
+	for key, want := range wantSources {
+		if got := settings.Sources[key]; got != want {
+			t.Errorf("Sources[%s] = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadSettingsDefaults(t *testing.T) {
+	projectRoot, _ := settingsEnv(t)
+
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	defaults := DefaultSettings()
+	for _, key := range SettingKeys() {
+		got, _ := settings.Get(key)
+		want, _ := defaults.Get(key)
+		if got != want {
+			t.Errorf("%s = %q, want default %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadSettingsErrorsNameKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		env      map[string]string
+		wantKey  string
+	}{
+		{"unknown key", `{"todos": {"recent": 3}}`, nil, "todos.recent"},
+		{"wrong type", `{"todos": {"recent_limit": "many"}}`, nil, "todos.recent_limit"},
+		{"negative limit", `{"todos": {"recent_limit": -1}}`, nil, "todos.recent_limit"},
+		{"bad duration", `{"state": {"timeout": 5}}`, nil, "state.timeout"},
+		{"unknown hook", `{"hooks": {"install": ["stop", "pre_commit"]}}`, nil, "hooks.install"},
+		{"bad compress", `{"logs": {"compress": "yes"}}`, nil, "logs.compress"},
+		{"no hooks", `{"hooks": {"install": []}}`, nil, "hooks.install"},
+		{"absolute docs", `{"docs": {"root": "/srv/docs"}}`, nil, "docs.root"},
+		{"bad matcher", `{"hooks": {"matchers": {"post_tool_use": "Write|("}}}`, nil, "hooks.matchers.post_tool_use"},
+		{"matcherless hook", `{"hooks": {"matchers": {"stop": "Bash"}}}`, nil, "hooks.matchers.stop"},
+		{"bad environment", `{}`, map[string]string{"SPCSTR_STATE_TIMEOUT": "soon"}, "SPCSTR_STATE_TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectRoot, _ := settingsEnv(t)
+			writeFile(t, ProjectSettingsPath(projectRoot), tt.settings)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := LoadSettings(projectRoot)
+			if err == nil {
+				t.Fatal("LoadSettings() succeeded, want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantKey) {
+				t.Errorf("error %q does not name %s", err, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestSetSetting(t *testing.T) {
+	projectRoot, _ := settingsEnv(t)
+	path := ProjectSettingsPath(projectRoot)
+	writeFile(t, path, `{"docs": {"root": "manual"}, "todos": 7}`)
+
+	steps := []struct {
+		key   string
+		value string
+	}{
+		{"todos.recent_limit", "9"},
+		{"state.timeout", "750ms"},
+		{"hooks.install", "stop, session_start"},
+		{"logs.compress", "false"},
+		{"logs.max_segments", "3"},
+	}
+	for _, step := range steps {
+		if err := SetSetting(path, step.key, step.value); err != nil {
+			t.Fatalf("SetSetting(%s) error = %v", step.key, err)
+		}
+	}
+
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if settings.Todos.RecentLimit != 9 || settings.State.Timeout != 750*time.Millisecond {
+		t.Errorf("settings = %+v, want recent_limit 9 and timeout 750ms", settings)
+	}
+	if strings.Join(settings.Hooks.Install, ",") != "stop,session_start" {
+		t.Errorf("Hooks.Install = %v", settings.Hooks.Install)
+	}
+	if settings.Logs.Compress || settings.Logs.MaxSegments != 3 {
+		t.Errorf("Logs = %+v, want compression off and 3 segments", settings.Logs)
+	}
+	if settings.Docs.Root != "manual" {
+		t.Errorf("SetSetting dropped docs.root, got %q", settings.Docs.Root)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"recent_limit": 9`) {
+		t.Errorf("recent_limit was not stored as a number:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"compress": false`) {
+		t.Errorf("compress was not stored as a boolean:\n%s", data)
+	}
+
+	invalid := []struct {
+		key   string
+		value string
+	}{
+		{"todos.recent_limit", "-2"},
+		{"state.timeout", "0s"},
+		{"logs.compress", "maybe"},
+		{"logs.max_age", "-1h"},
+		{"nope", "1"},
+	}
+	for _, step := range invalid {
+		if err := SetSetting(path, step.key, step.value); err == nil {
+			t.Errorf("SetSetting(%s, %s) succeeded, want error", step.key, step.value)
+		}
+	}
+}
+
+func TestConfigureClaudeHooksUsesSettings(t *testing.T) {
+	projectRoot, _ := settingsEnv(t)
+	writeFile(t, ProjectSettingsPath(projectRoot), `{"hooks": {"install": ["session_start", "stop"]}}`)
+
+	changes, err := configureClaudeHooks(context.Background(), projectRoot, false)
+	if err != nil {
+		t.Fatalf("configureClaudeHooks() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("installed %d hooks, want 2", len(changes))
+	}
+
+	settings, err := readSettings(filepath.Join(projectRoot, ".claude", "settings.json"))
+	if err != nil {
+		t.Fatalf("readSettings() error = %v", err)
+	}
+	hooks := settings["hooks"].(map[string]interface{})
+	if _, ok := hooks["PreToolUse"]; ok {
+		t.Error("PreToolUse was installed although hooks.install leaves it out")
+	}
+	if _, ok := hooks["Stop"]; !ok {
+		t.Error("Stop was not installed")
+	}
+}
+
+func TestSettingsHookEntries(t *testing.T) {
+	projectRoot, _ := settingsEnv(t)
+	writeFile(t, ProjectSettingsPath(projectRoot), `{
+		"hooks": {
+			"install": ["session_start", "pre_tool_use", "post_tool_use", "stop"],
+			"matchers": {"post_tool_use": "Write|Edit|Bash"}
+		}
+	}`)
+
+	settings, err := LoadSettings(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if err := settings.Set("hooks.matchers.pre_tool_use", " Bash "); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if settings.Sources["hooks.matchers.pre_tool_use"] != SourceFlag {
+		t.Errorf("source = %q, want %q", settings.Sources["hooks.matchers.pre_tool_use"], SourceFlag)
+	}
+
+	want := map[string]string{
+		"session_start": "",
+		"pre_tool_use":  "Bash",
+		"post_tool_use": "Write|Edit|Bash",
+		"stop":          "",
+	}
+	entries := settings.HookEntries()
+	if len(entries) != len(want) {
+		t.Fatalf("HookEntries() returned %d entries, want %d", len(entries), len(want))
+	}
+	for _, entry := range entries {
+		if matcher, ok := want[entry.Hook]; !ok || entry.Matcher != matcher {
+			t.Errorf("%s matcher = %q, want %q", entry.Hook, entry.Matcher, matcher)
+		}
+	}
+
+	if _, err := configureClaudeHooks(context.Background(), projectRoot, false); err != nil {
+		t.Fatalf("configureClaudeHooks() error = %v", err)
+	}
+	installed, err := InstalledHooks(projectRoot)
+	if err != nil {
+		t.Fatalf("InstalledHooks() error = %v", err)
+	}
+	if got := DescribeCapture(installed); got != "4 of 9 events: session_start, pre_tool_use, post_tool_use [Write|Edit|Bash], stop" {
+		t.Errorf("DescribeCapture() = %q", got)
+	}
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dylan/spcstr/internal/daemon"
+)
+
+// UninstallOptions controls what Uninstall removes
+type UninstallOptions struct {
+	// Force removes without prompting
+	Force bool
+	// KeepData leaves the .spcstr directory in place
+	KeepData bool
+	// Archive saves .spcstr to a tar.gz in the project root before deleting it
+	Archive bool
+	// DryRun prints what would be removed without changing anything
+	DryRun bool
+	// Global removes the hooks installed by init --global from the user's
+	// Claude Code settings instead
+	Global bool
+}
+
+// Uninstall removes spcstr from the project in the working directory: its
+// hook commands in .claude/settings.json and, unless kept, the .spcstr
+// directory. Hooks configured by other tools are left intact.
+func Uninstall(opts UninstallOptions) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+
+	if opts.Global {
+		return uninstallGlobal(ctx, opts)
+	}
+
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	settingsPath := filepath.Join(projectRoot, ".claude", "settings.json")
+	settings, err := readSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+	changes, err := RemoveHooks(settings)
+	if err != nil {
+		return err
+	}
+
+	spcstrDir := filepath.Join(projectRoot, ".spcstr")
+	removeData := dirExists(spcstrDir) && !opts.KeepData
+	// A running daemon would keep writing snapshots into the deleted directory
+	if removeData && daemon.Running(projectRoot) {
+		return fmt.Errorf("spcstr daemon is running for this project; stop it before deleting .spcstr, or pass --keep-data")
+	}
+	archivePath := ""
+	if removeData && opts.Archive {
+		archivePath = filepath.Join(projectRoot, fmt.Sprintf("spcstr-archive-%s.tar.gz", time.Now().Format("20060102-150405")))
+	}
+
+	if len(changes) == 0 && !removeData {
+		fmt.Println("Nothing to uninstall: no spcstr hooks or data found.")
+		return nil
+	}
+
+	printHookChanges(".claude/settings.json", changes)
+	switch {
+	case archivePath != "":
+		fmt.Printf("Archive .spcstr to %s, then delete it\n", filepath.Base(archivePath))
+	case removeData:
+		fmt.Println("Delete .spcstr and all recorded sessions and logs")
+	case dirExists(spcstrDir):
+		fmt.Println("Keep .spcstr")
+	}
+
+	if opts.DryRun {
+		fmt.Println("Dry run: nothing was written.")
+		return nil
+	}
+
+	if !opts.Force {
+		confirmed, err := confirm("\nDo you want to uninstall spcstr from this project?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Uninstall cancelled.")
+			return nil
+		}
+	}
+
+	if len(changes) > 0 {
+		if err := writeSettingsAtomic(ctx, settingsPath, settings); err != nil {
+			return fmt.Errorf("failed to write settings.json: %w", err)
+		}
+		fmt.Println("✓ Removed spcstr hooks from .claude/settings.json")
+	}
+
+	if archivePath != "" {
+		if err := archiveDir(ctx, projectRoot, ".spcstr", archivePath); err != nil {
+			return fmt.Errorf("failed to archive .spcstr: %w", err)
+		}
+		fmt.Printf("✓ Archived .spcstr to %s\n", archivePath)
+	}
+	if removeData {
+		if err := os.RemoveAll(spcstrDir); err != nil {
+			return fmt.Errorf("failed to delete .spcstr: %w", err)
+		}
+		fmt.Println("✓ Deleted .spcstr")
+	}
+
+	return nil
+}
+
+// archiveDir writes the regular files and directories under root/dir to a
+// gzipped tarball at archivePath, named relative to root. Sockets and other
+// special files are skipped.
+func archiveDir(ctx context.Context, root, dir, archivePath string) (err error) {
+	out, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(archivePath)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	walkErr := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/daemon"
+)
+
+func TestRemoveHooks(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  string
+		removed   int
+		wantHooks bool
+	}{
+		{"no hooks section", `{"model": "opus"}`, 0, false},
+		{"only spcstr hooks", `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "spcstr hook stop"}]}]}}`, 1, false},
+		{"mixed hooks", `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "notify-send done"}, {"type": "command", "command": "/opt/bin/spcstr hook stop --cwd=x"}]}], "PreToolUse": [{"matcher": "*", "hooks": [{"type": "command", "command": "spcstr hook pre_tool_use"}]}]}}`, 2, true},
+		{"no spcstr hooks", `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "spcstrx hook stop"}]}]}}`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := parseSettings(t, tt.settings)
+
+			changes, err := RemoveHooks(settings)
+			if err != nil {
+				t.Fatalf("RemoveHooks() error = %v", err)
+			}
+			if len(changes) != tt.removed {
+				t.Errorf("RemoveHooks() removed %d hooks, want %d", len(changes), tt.removed)
+			}
+			for _, change := range changes {
+				if change.Action != HookRemoved {
+					t.Errorf("change %+v is not a removal", change)
+				}
+			}
+			if _, ok := settings["hooks"]; ok != tt.wantHooks {
+				t.Errorf("hooks section present = %v, want %v", ok, tt.wantHooks)
+			}
+			if tt.wantHooks && tt.removed > 0 {
+				if got := eventCommands(settings, "Stop"); len(got) != 1 || got[0] != "|notify-send done" {
+					t.Errorf("Stop commands = %q, want only notify-send", got)
+				}
+			}
+		})
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        UninstallOptions
+		wantHooks   bool
+		wantData    bool
+		wantArchive bool
+	}{
+		{"delete", UninstallOptions{Force: true}, false, false, false},
+		{"keep data", UninstallOptions{Force: true, KeepData: true}, false, true, false},
+		{"archive", UninstallOptions{Force: true, Archive: true}, false, false, true},
+		{"dry run", UninstallOptions{Force: true, DryRun: true}, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			defer os.Chdir(oldWd)
+			os.Chdir(tempDir)
+
+			if err := InitializeProject(true); err != nil {
+				t.Fatalf("InitializeProject() error = %v", err)
+			}
+			os.WriteFile(filepath.Join(tempDir, ".spcstr", "sessions", "marker"), []byte("data"), 0644)
+
+			if err := Uninstall(tt.opts); err != nil {
+				t.Fatalf("Uninstall() error = %v", err)
+			}
+
+			settings, err := readSettings(filepath.Join(tempDir, ".claude", "settings.json"))
+			if err != nil {
+				t.Fatalf("readSettings() error = %v", err)
+			}
+			if _, ok := settings["hooks"]; ok != tt.wantHooks {
+				t.Errorf("hooks present = %v, want %v", ok, tt.wantHooks)
+			}
+			if got := dirExists(filepath.Join(tempDir, ".spcstr")); got != tt.wantData {
+				t.Errorf(".spcstr exists = %v, want %v", got, tt.wantData)
+			}
+
+			archives, _ := filepath.Glob(filepath.Join(tempDir, "spcstr-archive-*.tar.gz"))
+			if (len(archives) == 1) != tt.wantArchive {
+				t.Fatalf("archives = %v, want archive %v", archives, tt.wantArchive)
+			}
+			if tt.wantArchive {
+				names := archiveNames(t, archives[0])
+				if !names[".spcstr/sessions/marker"] || !names[".spcstr/logs/"] {
+					t.Errorf("archive entries = %v, want .spcstr contents", names)
+				}
+			}
+		})
+	}
+}
+
+func TestUninstallRefusesRunningDaemon(t *testing.T) {
+	tempDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tempDir)
+
+	if err := InitializeProject(true); err != nil {
+		t.Fatalf("InitializeProject() error = %v", err)
+	}
+	listener, err := net.Listen("unix", daemon.SocketPath(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	if err := Uninstall(UninstallOptions{Force: true}); err == nil || !strings.Contains(err.Error(), "daemon is running") {
+		t.Fatalf("Uninstall() error = %v, want the running daemon reported", err)
+	}
+	if !dirExists(filepath.Join(tempDir, ".spcstr")) {
+		t.Error(".spcstr was deleted under a running daemon")
+	}
+
+	// Keeping the data leaves the daemon's directory alone
+	if err := Uninstall(UninstallOptions{Force: true, KeepData: true}); err != nil {
+		t.Errorf("Uninstall(KeepData) error = %v", err)
+	}
+}
+
+// archiveNames lists the entries of a tar.gz
+func archiveNames(t *testing.T, path string) map[string]bool {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+
+	names := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive entry: %v", err)
+		}
+		names[header.Name] = true
+	}
+}
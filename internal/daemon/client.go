@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+)
+
+// ErrNotRunning is returned by Call when no daemon accepted the request, so
+// the caller should run the hook itself
+var ErrNotRunning = errors.New("spcstr daemon is not running")
+
+// Running reports whether a daemon is accepting requests for the project
+func Running(projectDir string) bool {
+	conn, err := net.DialTimeout("unix", SocketPath(projectDir), dialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Call forwards a hook event to the project's daemon and returns the hook
+// output. Errors wrapping ErrNotRunning mean the hook was not executed; a
+// lost response is reported as a non-blocking error.
+func Call(projectDir, hookName string, input []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(projectDir), dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(callTimeout))
+
+	if err := json.NewEncoder(conn).Encode(Request{Hook: hookName, Input: string(input)}); err != nil {
+		return nil, fmt.Errorf("%w: failed to send request: %v", ErrNotRunning, err)
+	}
+
+	// The daemon may already have run the hook, so it is not retried here,
+	// but a daemon that died or stalled must not block Claude Code
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, &events.NonBlockingError{Err: fmt.Errorf("failed to read daemon response: %w", err)}
+	}
+	if resp.Error != "" {
+		if resp.NonBlocking {
+			return nil, &events.NonBlockingError{Err: errors.New(resp.Error)}
+		}
+		return nil, errors.New(resp.Error)
+	}
+	if resp.Output == "" {
+		return nil, nil
+	}
+	return []byte(resp.Output), nil
+}
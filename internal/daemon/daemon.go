@@ -0,0 +1,193 @@
+// Package daemon serves hook events for one project over a Unix socket, so
+// busy sessions don't pay process start-up and state decoding on every event
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+)
+
+// SocketFileName is the daemon socket inside the .spcstr directory
+const SocketFileName = "daemon.sock"
+
+const (
+	// dialTimeout bounds how long a client waits to reach the daemon before
+	// falling back to running the hook itself
+	dialTimeout = 200 * time.Millisecond
+	// callTimeout bounds a whole request, staying inside Claude Code's
+	// 60 second hook timeout
+	callTimeout = 55 * time.Second
+	// ioTimeout bounds how long the server waits for a client to send its
+	// request or to take the response
+	ioTimeout = 5 * time.Second
+)
+
+// SocketPath returns the daemon socket for a project root
+func SocketPath(projectDir string) string {
+	return filepath.Join(projectDir, ".spcstr", SocketFileName)
+}
+
+// Request is a hook event forwarded by a client
+type Request struct {
+	Hook  string `json:"hook"`
+	Input string `json:"input"`
+}
+
+// Response carries the hook's output for Claude Code, or its error
+type Response struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// NonBlocking marks an error that must not block Claude Code
+	NonBlocking bool `json:"non_blocking,omitempty"`
+}
+
+// HookRunner executes a hook in the daemon's working directory
+type HookRunner func(hookName string, input []byte) ([]byte, error)
+
+// Server accepts hook requests on the project's socket. Requests for the
+// same session run one at a time in arrival order; different sessions run
+// concurrently.
+type Server struct {
+	socketPath string
+	run        HookRunner
+
+	mu       sync.Mutex
+	sessions map[string]*sync.Mutex
+	conns    sync.WaitGroup
+}
+
+// NewServer creates a Server for the project rooted at projectDir
+func NewServer(projectDir string, run HookRunner) *Server {
+	return &Server{
+		socketPath: SocketPath(projectDir),
+		run:        run,
+		sessions:   make(map[string]*sync.Mutex),
+	}
+}
+
+// SocketPath returns the socket the server listens on
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
+// Serve listens on the socket until ctx is cancelled, then waits for
+// in-flight requests and removes the socket
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(s.socketPath)
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				s.conns.Wait()
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// listen binds the socket, replacing a stale one left by a daemon that died
+func (s *Server) listen() (net.Listener, error) {
+	if _, err := os.Stat(s.socketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", s.socketPath, dialTimeout); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("daemon already running on %s", s.socketPath)
+		}
+		if err := os.Remove(s.socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+	// Hook events carry prompts and file contents; keep them to this user
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// handle serves a single request on conn
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(ioTimeout))
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		writeResponse(conn, Response{Error: fmt.Sprintf("failed to decode request: %v", err)})
+		return
+	}
+
+	input := []byte(req.Input)
+	lock := s.sessionLock(sessionID(input))
+	lock.Lock()
+	output, err := s.run(req.Hook, input)
+	lock.Unlock()
+
+	resp := Response{Output: string(output)}
+	if err != nil {
+		resp.Error = err.Error()
+		resp.NonBlocking = !events.IsBlocking(err)
+	}
+	writeResponse(conn, resp)
+}
+
+// sessionLock returns the mutex serializing requests for a session
+func (s *Server) sessionLock(id string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.sessions[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.sessions[id] = lock
+	}
+	return lock
+}
+
+// sessionID extracts the session_id of a hook input, or "" when it has none
+func sessionID(input []byte) string {
+	var params struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return ""
+	}
+	return params.SessionID
+}
+
+// writeResponse sends resp, ignoring clients that have gone away
+func writeResponse(conn net.Conn, resp Response) {
+	conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+	if err := json.NewEncoder(conn).Encode(resp); err != nil && !errors.Is(err, net.ErrClosed) {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write daemon response: %v\n", err)
+	}
+}
@@ -0,0 +1,189 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+)
+
+// startServer runs a Server for a temp project and returns the project dir
+func startServer(t *testing.T, run HookRunner) string {
+	t.Helper()
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, ".spcstr"), 0755); err != nil {
+		t.Fatalf("Failed to create .spcstr: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewServer(projectDir, run).Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error: %v", err)
+		}
+		if _, err := os.Stat(SocketPath(projectDir)); !os.IsNotExist(err) {
+			t.Error("socket not removed on shutdown")
+		}
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(SocketPath(projectDir)); err == nil {
+			return projectDir
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("daemon socket never appeared")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCall(t *testing.T) {
+	projectDir := startServer(t, func(hookName string, input []byte) ([]byte, error) {
+		switch hookName {
+		case "fail":
+			return nil, errors.New("handler failed")
+		case "bug":
+			return nil, &events.NonBlockingError{Err: errors.New("spcstr bug")}
+		case "silent":
+			return nil, nil
+		}
+		return []byte(hookName + ":" + string(input)), nil
+	})
+
+	tests := []struct {
+		name       string
+		hook       string
+		wantOutput string
+		wantErr    string
+		wantBlocks bool
+	}{
+		{name: "output is returned", hook: "echo", wantOutput: `echo:{"session_id":"s"}`},
+		{name: "no output", hook: "silent"},
+		{name: "handler error", hook: "fail", wantErr: "handler failed", wantBlocks: true},
+		{name: "non-blocking error", hook: "bug", wantErr: "spcstr bug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := Call(projectDir, tt.hook, []byte(`{"session_id":"s"}`))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr || errors.Is(err, ErrNotRunning) {
+					t.Errorf("Call() error = %v, want %q", err, tt.wantErr)
+				}
+				if events.IsBlocking(err) != tt.wantBlocks {
+					t.Errorf("Call() error = %#v, want blocking = %v", err, tt.wantBlocks)
+				}
+				return
+			}
+			if err != nil || string(output) != tt.wantOutput {
+				t.Errorf("Call() = %q, %v; want %q", output, err, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestCallNotRunning(t *testing.T) {
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, ".spcstr"), 0755)
+
+	// Neither a missing socket nor a stale one counts as a running daemon
+	if _, err := Call(projectDir, "stop", nil); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("missing socket error = %v, want ErrNotRunning", err)
+	}
+	os.WriteFile(SocketPath(projectDir), nil, 0600)
+	if _, err := Call(projectDir, "stop", nil); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("stale socket error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestCallLostResponse(t *testing.T) {
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, ".spcstr"), 0755)
+
+	// A daemon that dies after taking the request may have run the hook
+	listener, err := net.Listen("unix", SocketPath(projectDir))
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			json.NewDecoder(conn).Decode(&Request{})
+			conn.Close()
+		}
+	}()
+
+	_, err = Call(projectDir, "stop", []byte(`{"session_id":"s"}`))
+	if errors.Is(err, ErrNotRunning) || events.IsBlocking(err) {
+		t.Errorf("Call() error = %#v, want a non-blocking error", err)
+	}
+}
+
+func TestServerSerializesSessions(t *testing.T) {
+	var mu sync.Mutex
+	active := make(map[string]int)
+	var overlaps, crossSession atomic.Int32
+
+	projectDir := startServer(t, func(hookName string, input []byte) ([]byte, error) {
+		id := sessionID(input)
+		mu.Lock()
+		active[id]++
+		if active[id] > 1 {
+			overlaps.Add(1)
+		}
+		if len(active) > 1 {
+			crossSession.Add(1)
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		if active[id]--; active[id] == 0 {
+			delete(active, id)
+		}
+		mu.Unlock()
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := fmt.Sprintf(`{"session_id":"session-%d"}`, i%2)
+			if _, err := Call(projectDir, "pre_tool_use", []byte(input)); err != nil {
+				t.Errorf("Call() error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if overlaps.Load() != 0 {
+		t.Errorf("requests for one session overlapped %d times", overlaps.Load())
+	}
+	if crossSession.Load() == 0 {
+		t.Error("requests for different sessions never ran concurrently")
+	}
+}
+
+func TestServeRefusesSecondDaemon(t *testing.T) {
+	projectDir := startServer(t, func(string, []byte) ([]byte, error) { return nil, nil })
+
+	err := NewServer(projectDir, nil).Serve(context.Background())
+	if err == nil {
+		t.Fatal("second daemon started on a live socket")
+	}
+}
@@ -11,20 +11,27 @@ import (
 type Scanner struct {
 	rootPath string
 	config   *config.CoreConfig
+	docs     config.DocsSettings
 }
 
 func NewScanner(rootPath string) *Scanner {
 	cfg, _ := config.LoadCoreConfig(rootPath)
+	// Invalid settings fall back to the default docs locations
+	settings, err := config.LoadSettings(rootPath)
+	if err != nil {
+		settings = config.DefaultSettings()
+	}
 	return &Scanner{
 		rootPath: rootPath,
 		config:   cfg,
+		docs:     settings.Docs,
 	}
 }
 
 func (s *Scanner) ScanForMarkdownFiles() ([]string, error) {
 	var markdownFiles []string
 	
-	docsPath := filepath.Join(s.rootPath, "docs")
+	docsPath := filepath.Join(s.rootPath, s.docs.Root)
 	
 	if _, err := os.Stat(docsPath); os.IsNotExist(err) {
 		return markdownFiles, nil
@@ -50,7 +57,7 @@ func (s *Scanner) ScanForMarkdownFiles() ([]string, error) {
 		}
 		
 		s.scanDirectory(filepath.Join(s.rootPath, s.config.DevStoryLocation), &markdownFiles)
-		s.scanDirectory(filepath.Join(s.rootPath, "docs/epics"), &markdownFiles)
+		s.scanDirectory(filepath.Join(s.rootPath, s.docs.Epics), &markdownFiles)
 	}
 	
 	err := filepath.Walk(docsPath, func(path string, info os.FileInfo, err error) error {
@@ -81,6 +88,22 @@ func (s *Scanner) ScanForMarkdownFiles() ([]string, error) {
 	return markdownFiles, nil
 }
 
+// ScanForArchitectureFiles lists only the architecture file and the sharded
+// architecture directory named by core-config.yaml
+func (s *Scanner) ScanForArchitectureFiles() []string {
+	var files []string
+	if s.config == nil {
+		return files
+	}
+
+	archPath := filepath.Join(s.rootPath, s.config.Architecture.ArchitectureFile)
+	if info, err := os.Stat(archPath); err == nil && !info.IsDir() {
+		files = append(files, archPath)
+	}
+	s.scanDirectory(filepath.Join(s.rootPath, s.config.Architecture.ArchitectureShardedLocation), &files)
+	return files
+}
+
 func (s *Scanner) scanDirectory(dir string, files *[]string) {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		return
@@ -42,6 +42,12 @@ func (e *Engine) ScanAndIndex() ([]DocumentIndex, error) {
 	return e.indexer.IndexDocuments(files, e.scanner)
 }
 
+// IndexArchitecture indexes the architecture documents without walking the
+// rest of the docs tree
+func (e *Engine) IndexArchitecture() ([]DocumentIndex, error) {
+	return e.indexer.IndexDocuments(e.scanner.ScanForArchitectureFiles(), e.scanner)
+}
+
 func (e *Engine) RenderDocument(path string) (string, error) {
 	return e.Renderer.RenderMarkdown(path)
 }
\ No newline at end of file
@@ -0,0 +1,463 @@
+// Package doctor diagnoses why spcstr might not be tracking a project
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// Check outcomes, from best to worst
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// staleTempAge is how old a temp file must be before it counts as stray
+// rather than part of a write in progress
+const staleTempAge = time.Minute
+
+// tempFilePattern matches the temp files of state.AtomicWriter, Journal
+// rewrites and settings.json writes
+var tempFilePattern = regexp.MustCompile(`(\.tmp\.\d+|\.jsonl\.tmp|^\.settings-.*\.tmp)$`)
+
+// Check is the result of one diagnostic
+type Check struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+	// Hint tells the user how to resolve a warning or failure
+	Hint string `json:"hint,omitempty"`
+	// Fixed is set when --fix repaired the problem
+	Fixed bool `json:"fixed,omitempty"`
+}
+
+// Report collects the checks run for a project
+type Report struct {
+	ProjectRoot string  `json:"project_root"`
+	Checks      []Check `json:"checks"`
+}
+
+// Count returns the number of checks with the given status
+func (r Report) Count(status string) int {
+	n := 0
+	for _, check := range r.Checks {
+		if check.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Run diagnoses the spcstr installation of the project at projectRoot. With
+// fix, problems that can be repaired without losing data are repaired.
+func Run(ctx context.Context, projectRoot string, fix bool) Report {
+	basePath := filepath.Join(projectRoot, ".spcstr")
+	global := usesGlobalHooks(projectRoot)
+	return Report{
+		ProjectRoot: projectRoot,
+		Checks: []Check{
+			checkBinary(),
+			checkHooks(projectRoot, global, fix),
+			checkCapture(projectRoot),
+			checkDirectories(basePath, global, fix),
+			checkState(ctx, basePath, fix),
+			checkTempFiles(projectRoot, fix),
+		},
+	}
+}
+
+// checkBinary verifies Claude Code can find spcstr to run hooks
+func checkBinary() Check {
+	check := Check{Name: "spcstr on PATH"}
+
+	path, err := exec.LookPath("spcstr")
+	if err != nil {
+		check.Status = StatusFail
+		check.Message = "spcstr was not found on PATH"
+		check.Hint = "Install spcstr into a directory on PATH; Claude Code runs hooks as `spcstr hook ...`"
+		return check
+	}
+
+	check.Status = StatusPass
+	check.Message = path
+
+	// A different binary on PATH means hooks run another spcstr version
+	if self, err := os.Executable(); err == nil && !sameFile(self, path) {
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("hooks run %s, not this binary (%s)", path, self)
+		check.Hint = "Reinstall spcstr so the binary on PATH is the current version"
+	}
+	return check
+}
+
+// sameFile reports whether two paths resolve to the same file
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// usesGlobalHooks reports whether the project relies on hooks installed by
+// init --global rather than its own
+func usesGlobalHooks(projectRoot string) bool {
+	local, err := config.InstalledHooks(projectRoot)
+	if err != nil || len(local) > 0 {
+		return false
+	}
+	global, err := config.InstalledGlobalHooks()
+	return err == nil && len(global) > 0
+}
+
+// checkHooks verifies .claude/settings.json, or the user's Claude Code
+// settings for a project tracked by global hooks, has every current spcstr
+// hook
+func checkHooks(projectRoot string, global, fix bool) Check {
+	if global {
+		return checkGlobalHooks(projectRoot, fix)
+	}
+	return checkHookChanges(func(dryRun bool) ([]config.HookChange, error) {
+		return config.ConfigureClaudeHooks(projectRoot, dryRun)
+	}, "Fix or remove .claude/settings.json, then run spcstr init", "spcstr init", fix)
+}
+
+// checkGlobalHooks verifies the hooks installed by init --global are
+// current and track the project
+func checkGlobalHooks(projectRoot string, fix bool) Check {
+	settings, err := config.LoadSettings(projectRoot)
+	if err != nil {
+		return Check{Name: "Claude Code hooks", Status: StatusFail, Message: err.Error(), Hint: "Fix the settings file named above"}
+	}
+	if !settings.ProjectAllowed(projectRoot) {
+		return Check{
+			Name:    "Claude Code hooks",
+			Status:  StatusWarn,
+			Message: "global hooks are installed but projects.allow or projects.deny excludes this project",
+			Hint:    "Change projects.allow or projects.deny with spcstr config set --global, or run spcstr init here",
+		}
+	}
+
+	check := checkHookChanges(config.ConfigureGlobalHooks,
+		"Fix or remove the user's Claude Code settings.json, then run spcstr init --global", "spcstr init --global", fix)
+	if check.Status == StatusPass && !check.Fixed {
+		check.Message = "global spcstr hooks are installed and current"
+	}
+	return check
+}
+
+// checkHookChanges reports the changes configure would make to a Claude
+// Code settings file, applying them on fix
+func checkHookChanges(configure func(dryRun bool) ([]config.HookChange, error), brokenHint, initCommand string, fix bool) Check {
+	check := Check{Name: "Claude Code hooks"}
+
+	changes, err := configure(true)
+	if err != nil {
+		check.Status = StatusFail
+		check.Message = err.Error()
+		check.Hint = brokenHint
+		return check
+	}
+	if len(changes) == 0 {
+		check.Status = StatusPass
+		check.Message = "all spcstr hooks are installed and current"
+		return check
+	}
+
+	check.Details = strings.Split(strings.TrimSuffix(config.FormatHookChanges(changes), "\n"), "\n")
+	if fix {
+		if _, err := configure(false); err != nil {
+			check.Status = StatusFail
+			check.Message = fmt.Sprintf("failed to update hooks: %v", err)
+			return check
+		}
+		check.Status = StatusPass
+		check.Message = fmt.Sprintf("updated %s", plural(len(changes), "hook entry", "hook entries"))
+		check.Fixed = true
+		return check
+	}
+
+	check.Status = StatusFail
+	check.Message = fmt.Sprintf("%s missing or stale", plural(len(changes), "hook entry", "hook entries"))
+	check.Hint = "Run spcstr doctor --fix or " + initCommand + " to merge the current hooks"
+	return check
+}
+
+// checkCapture reports which events the installed hooks capture, so a
+// deliberately reduced hook set is visible rather than silent
+func checkCapture(projectRoot string) Check {
+	check := Check{Name: "captured events"}
+
+	installed, err := config.CapturedHooks(projectRoot)
+	if err != nil {
+		check.Status = StatusFail
+		check.Message = err.Error()
+		return check
+	}
+
+	captured := make(map[string]bool, len(installed))
+	matchers := make(map[string]string, len(installed))
+	for _, entry := range installed {
+		captured[entry.Hook] = true
+		matchers[entry.Hook] = entry.Matcher
+		var notes []string
+		if !entry.CapturesAll() {
+			notes = append(notes, "only "+entry.Matcher)
+		}
+		if entry.Global {
+			notes = append(notes, "global")
+		}
+		detail := entry.Hook
+		if len(notes) > 0 {
+			detail += " (" + strings.Join(notes, ", ") + ")"
+		}
+		check.Details = append(check.Details, detail)
+	}
+	for _, entry := range config.DefaultHookEntries() {
+		if !captured[entry.Hook] {
+			check.Details = append(check.Details, entry.Hook+" (not captured)")
+		}
+	}
+
+	total := len(config.DefaultHookEntries())
+	check.Status = StatusPass
+	check.Message = fmt.Sprintf("capturing %d of %d events", len(installed), total)
+	if len(installed) == total {
+		check.Message = fmt.Sprintf("capturing all %d events", total)
+	}
+	switch {
+	case len(installed) == 0:
+		check.Status = StatusFail
+		check.Message = "no events are captured"
+		check.Hint = "Run spcstr init to install the hooks"
+	case captured["pre_tool_use"] != captured["post_tool_use"]:
+		// Tool calls are opened by pre_tool_use and closed by post_tool_use
+		check.Status = StatusWarn
+		check.Hint = "Capture both pre_tool_use and post_tool_use, or neither, so tool calls are complete"
+	case matchers["pre_tool_use"] != matchers["post_tool_use"]:
+		check.Status = StatusWarn
+		check.Hint = "Give pre_tool_use and post_tool_use the same matcher so tool calls are complete"
+	}
+	return check
+}
+
+// checkDirectories verifies the hooks can write sessions and logs. Global
+// hooks create missing directories on the first event.
+func checkDirectories(basePath string, global, fix bool) Check {
+	check := Check{Name: ".spcstr directories", Status: StatusPass}
+
+	var created, pending []string
+	for _, name := range []string{"sessions", "logs"} {
+		dir := filepath.Join(basePath, name)
+		info, err := os.Stat(dir)
+		switch {
+		case os.IsNotExist(err) && fix:
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				check.Details = append(check.Details, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			created = append(created, name)
+			continue
+		case os.IsNotExist(err) && global:
+			pending = append(pending, name)
+			continue
+		case os.IsNotExist(err):
+			check.Details = append(check.Details, fmt.Sprintf(".spcstr/%s does not exist", name))
+			check.Hint = "Run spcstr doctor --fix or spcstr init to create it"
+			continue
+		case err != nil:
+			check.Details = append(check.Details, fmt.Sprintf("%s: %v", name, err))
+			continue
+		case !info.IsDir():
+			check.Details = append(check.Details, fmt.Sprintf(".spcstr/%s is not a directory", name))
+			continue
+		}
+
+		if err := probeWritable(dir); err != nil {
+			check.Details = append(check.Details, fmt.Sprintf(".spcstr/%s is not writable: %v", name, err))
+			check.Hint = "Make .spcstr and its directories writable by the user running Claude Code"
+		}
+	}
+
+	switch {
+	case len(check.Details) > 0:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("%s with .spcstr/sessions and .spcstr/logs", plural(len(check.Details), "problem", "problems"))
+	case len(created) > 0:
+		check.Message = "created .spcstr/" + strings.Join(created, " and .spcstr/")
+		check.Fixed = true
+	case len(pending) > 0:
+		check.Message = "the global hooks create .spcstr/" + strings.Join(pending, " and .spcstr/") + " on the first event"
+	default:
+		check.Message = ".spcstr/sessions and .spcstr/logs are writable"
+	}
+	return check
+}
+
+// probeWritable creates and removes a file in dir
+func probeWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkState loads every session's state and journal. A corrupt state.json
+// is rebuilt from an intact journal on fix; a corrupt journal needs a human.
+func checkState(ctx context.Context, basePath string, fix bool) Check {
+	check := Check{Name: "session state"}
+
+	entries, err := os.ReadDir(filepath.Join(basePath, "sessions"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			check.Status = StatusPass
+			check.Message = "no sessions recorded yet"
+			return check
+		}
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("failed to list sessions: %v", err)
+		return check
+	}
+
+	sm := state.NewStateManager(basePath)
+	sessions, rebuilt := 0, 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		sessionID := entry.Name()
+		sessionDir := filepath.Join(basePath, "sessions", sessionID)
+		hasState := fileExists(filepath.Join(sessionDir, state.StateFileName))
+		hasJournal := fileExists(filepath.Join(sessionDir, state.JournalFileName))
+		if !hasState && !hasJournal {
+			continue
+		}
+		sessions++
+
+		var journalErr error
+		if hasJournal {
+			_, journalErr = sm.ReadJournal(ctx, sessionID)
+		}
+		var stateErr error
+		if hasState {
+			_, stateErr = sm.LoadState(ctx, sessionID)
+		}
+
+		if stateErr != nil && hasJournal && journalErr == nil && fix {
+			if _, err := sm.RebuildState(ctx, sessionID); err == nil {
+				rebuilt++
+				continue
+			}
+		}
+		if stateErr != nil {
+			check.Details = append(check.Details, fmt.Sprintf("%s: corrupt state.json: %v", sessionID, stateErr))
+		}
+		if journalErr != nil {
+			check.Details = append(check.Details, fmt.Sprintf("%s: corrupt events.jsonl: %v", sessionID, journalErr))
+		}
+	}
+
+	switch {
+	case len(check.Details) > 0:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("%s in %s", plural(len(check.Details), "problem", "problems"), plural(sessions, "session", "sessions"))
+		check.Hint = "spcstr doctor --fix rebuilds state.json from an intact journal; " +
+			"a corrupt events.jsonl must be repaired by hand or the session deleted"
+	case rebuilt > 0:
+		check.Status = StatusPass
+		check.Message = fmt.Sprintf("rebuilt state.json of %d of %s", rebuilt, plural(sessions, "session", "sessions"))
+		check.Fixed = true
+	default:
+		check.Status = StatusPass
+		check.Message = fmt.Sprintf("%s loaded cleanly", plural(sessions, "session", "sessions"))
+	}
+	return check
+}
+
+// checkTempFiles finds temp files left behind by interrupted atomic writes
+func checkTempFiles(projectRoot string, fix bool) Check {
+	check := Check{Name: "stray temp files"}
+
+	var stray []string
+	cutoff := time.Now().Add(-staleTempAge)
+	for _, dir := range []string{".spcstr", ".claude"} {
+		err := filepath.WalkDir(filepath.Join(projectRoot, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || !tempFilePattern.MatchString(d.Name()) {
+				return nil
+			}
+			if info, err := d.Info(); err != nil || info.ModTime().After(cutoff) {
+				return nil
+			}
+			stray = append(stray, path)
+			return nil
+		})
+		if err != nil {
+			check.Status = StatusWarn
+			check.Message = fmt.Sprintf("failed to scan %s: %v", dir, err)
+			return check
+		}
+	}
+
+	if len(stray) == 0 {
+		check.Status = StatusPass
+		check.Message = "none found"
+		return check
+	}
+
+	for _, path := range stray {
+		rel, _ := filepath.Rel(projectRoot, path)
+		check.Details = append(check.Details, rel)
+	}
+	if fix {
+		removed := 0
+		for _, path := range stray {
+			if err := os.Remove(path); err == nil || os.IsNotExist(err) {
+				removed++
+			}
+		}
+		if removed == len(stray) {
+			check.Status = StatusPass
+			check.Message = fmt.Sprintf("removed %s", plural(removed, "temp file", "temp files"))
+			check.Fixed = true
+			return check
+		}
+	}
+
+	check.Status = StatusWarn
+	check.Message = fmt.Sprintf("%s left by interrupted writes", plural(len(stray), "temp file", "temp files"))
+	check.Hint = "Run spcstr doctor --fix to delete them"
+	return check
+}
+
+// plural formats a count with the singular or plural noun
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+// fileExists checks if a regular file exists
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
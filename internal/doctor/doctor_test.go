@@ -0,0 +1,300 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// newProject creates an initialized project with one recorded session
+func newProject(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+	projectRoot := t.TempDir()
+	for _, dir := range []string{"sessions", "logs"} {
+		os.MkdirAll(filepath.Join(projectRoot, ".spcstr", dir), 0755)
+	}
+	if _, err := config.ConfigureClaudeHooks(projectRoot, false); err != nil {
+		t.Fatalf("ConfigureClaudeHooks() error = %v", err)
+	}
+	sm := state.NewStateManager(filepath.Join(projectRoot, ".spcstr"))
+	if _, err := sm.InitializeState(context.Background(), "session-1"); err != nil {
+		t.Fatalf("InitializeState() error = %v", err)
+	}
+	return projectRoot
+}
+
+// checkNamed returns the check with the given name from a report
+func checkNamed(t *testing.T, report Report, name string) Check {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("report has no %q check", name)
+	return Check{}
+}
+
+func TestChecks(t *testing.T) {
+	sessionDir := func(root string) string {
+		return filepath.Join(root, ".spcstr", "sessions", "session-1")
+	}
+
+	tests := []struct {
+		name      string
+		check     string
+		breakIt   func(t *testing.T, root string)
+		want      string
+		wantFixed bool
+		verify    func(t *testing.T, root string)
+	}{
+		{
+			name:  "healthy hooks",
+			check: "Claude Code hooks",
+			want:  StatusPass,
+		},
+		{
+			name:  "missing hooks",
+			check: "Claude Code hooks",
+			breakIt: func(t *testing.T, root string) {
+				os.WriteFile(filepath.Join(root, ".claude", "settings.json"), []byte(`{"model": "opus"}`), 0644)
+			},
+			want:      StatusFail,
+			wantFixed: true,
+			verify: func(t *testing.T, root string) {
+				if changes, _ := config.ConfigureClaudeHooks(root, true); len(changes) != 0 {
+					t.Errorf("hooks still need %d changes after fix", len(changes))
+				}
+			},
+		},
+		{
+			name:  "unparseable settings",
+			check: "Claude Code hooks",
+			breakIt: func(t *testing.T, root string) {
+				os.WriteFile(filepath.Join(root, ".claude", "settings.json"), []byte(`{`), 0644)
+			},
+			want: StatusFail,
+		},
+		{
+			name:  "missing logs directory",
+			check: ".spcstr directories",
+			breakIt: func(t *testing.T, root string) {
+				os.RemoveAll(filepath.Join(root, ".spcstr", "logs"))
+			},
+			want:      StatusFail,
+			wantFixed: true,
+			verify: func(t *testing.T, root string) {
+				if info, err := os.Stat(filepath.Join(root, ".spcstr", "logs")); err != nil || !info.IsDir() {
+					t.Error("fix did not create .spcstr/logs")
+				}
+			},
+		},
+		{
+			name:  "healthy state",
+			check: "session state",
+			want:  StatusPass,
+		},
+		{
+			name:  "corrupt state.json",
+			check: "session state",
+			breakIt: func(t *testing.T, root string) {
+				os.WriteFile(filepath.Join(sessionDir(root), state.StateFileName), []byte("not json"), 0644)
+			},
+			want:      StatusFail,
+			wantFixed: true,
+			verify: func(t *testing.T, root string) {
+				sm := state.NewStateManager(filepath.Join(root, ".spcstr"))
+				if _, err := sm.LoadState(context.Background(), "session-1"); err != nil {
+					t.Errorf("LoadState() after fix error = %v", err)
+				}
+			},
+		},
+		{
+			name:  "corrupt journal",
+			check: "session state",
+			breakIt: func(t *testing.T, root string) {
+				path := filepath.Join(sessionDir(root), state.JournalFileName)
+				os.WriteFile(path, []byte("not json\n{}\n"), 0644)
+			},
+			want: StatusFail,
+		},
+		{
+			name:  "recent temp file",
+			check: "stray temp files",
+			breakIt: func(t *testing.T, root string) {
+				os.WriteFile(filepath.Join(sessionDir(root), "state.json.tmp.1"), nil, 0644)
+			},
+			want: StatusPass,
+		},
+		{
+			name:  "stale temp files",
+			check: "stray temp files",
+			breakIt: func(t *testing.T, root string) {
+				old := time.Now().Add(-time.Hour)
+				for _, path := range []string{
+					filepath.Join(sessionDir(root), "state.json.tmp.1"),
+					filepath.Join(sessionDir(root), "events.jsonl.tmp"),
+					filepath.Join(root, ".claude", ".settings-42.tmp"),
+				} {
+					os.WriteFile(path, nil, 0644)
+					os.Chtimes(path, old, old)
+				}
+			},
+			want:      StatusWarn,
+			wantFixed: true,
+			verify: func(t *testing.T, root string) {
+				if _, err := os.Stat(filepath.Join(root, ".claude", ".settings-42.tmp")); !os.IsNotExist(err) {
+					t.Error("fix left .claude/.settings-42.tmp behind")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newProject(t)
+			if tt.breakIt != nil {
+				tt.breakIt(t, root)
+			}
+
+			check := checkNamed(t, Run(context.Background(), root, false), tt.check)
+			if check.Status != tt.want {
+				t.Fatalf("status = %s (%s), want %s", check.Status, check.Message, tt.want)
+			}
+			if check.Status != StatusPass && check.Hint == "" {
+				t.Error("problem reported without a hint")
+			}
+
+			fixed := checkNamed(t, Run(context.Background(), root, true), tt.check)
+			if tt.wantFixed {
+				if !fixed.Fixed || fixed.Status != StatusPass {
+					t.Errorf("--fix gave %s (%s) fixed=%v, want a fixed pass", fixed.Status, fixed.Message, fixed.Fixed)
+				}
+				if tt.verify != nil {
+					tt.verify(t, root)
+				}
+			} else if fixed.Fixed {
+				t.Errorf("--fix claimed to repair %q", tt.check)
+			}
+		})
+	}
+}
+
+func TestCheckBinary(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if check := checkBinary(); check.Status != StatusFail {
+		t.Errorf("status without spcstr on PATH = %s, want %s", check.Status, StatusFail)
+	}
+
+	binDir := t.TempDir()
+	os.WriteFile(filepath.Join(binDir, "spcstr"), []byte("#!/bin/sh\n"), 0755)
+	t.Setenv("PATH", binDir)
+	// The test binary is not the spcstr on PATH
+	if check := checkBinary(); check.Status != StatusWarn {
+		t.Errorf("status with another spcstr on PATH = %s, want %s", check.Status, StatusWarn)
+	}
+}
+
+func TestCheckCapture(t *testing.T) {
+	tests := []struct {
+		name        string
+		install     string
+		matchers    map[string]string
+		want        string
+		wantMessage string
+	}{
+		{
+			name:        "all hooks",
+			want:        StatusPass,
+			wantMessage: "capturing all 9 events",
+		},
+		{
+			name:        "tool matchers",
+			matchers:    map[string]string{"pre_tool_use": "Write|Edit", "post_tool_use": "Write|Edit"},
+			want:        StatusPass,
+			wantMessage: "capturing all 9 events",
+		},
+		{
+			name:        "mismatched tool matchers",
+			matchers:    map[string]string{"post_tool_use": "Write|Edit|Bash"},
+			want:        StatusWarn,
+			wantMessage: "capturing all 9 events",
+		},
+		{
+			name:        "selected hooks",
+			install:     "session_start,pre_tool_use,post_tool_use,stop",
+			want:        StatusPass,
+			wantMessage: "capturing 4 of 9 events",
+		},
+		{
+			name:        "pre_tool_use without post_tool_use",
+			install:     "session_start,pre_tool_use",
+			want:        StatusWarn,
+			wantMessage: "capturing 2 of 9 events",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newProject(t)
+			settingsPath := config.ProjectSettingsPath(root)
+			if tt.install != "" {
+				if err := config.SetSetting(settingsPath, "hooks.install", tt.install); err != nil {
+					t.Fatalf("SetSetting() error = %v", err)
+				}
+			}
+			for hook, matcher := range tt.matchers {
+				if err := config.SetSetting(settingsPath, "hooks.matchers."+hook, matcher); err != nil {
+					t.Fatalf("SetSetting() error = %v", err)
+				}
+			}
+			if _, err := config.ConfigureClaudeHooks(root, false); err != nil {
+				t.Fatalf("ConfigureClaudeHooks() error = %v", err)
+			}
+
+			check := checkCapture(root)
+			if check.Status != tt.want {
+				t.Errorf("status = %s (%s), want %s", check.Status, check.Message, tt.want)
+			}
+			if !strings.HasPrefix(check.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want prefix %q", check.Message, tt.wantMessage)
+			}
+			if len(check.Details) != 9 {
+				t.Errorf("details list %d events, want 9: %v", len(check.Details), check.Details)
+			}
+		})
+	}
+}
+
+func TestChecksWithGlobalHooks(t *testing.T) {
+	root := newProject(t)
+	os.RemoveAll(filepath.Join(root, ".claude"))
+	os.RemoveAll(filepath.Join(root, ".spcstr"))
+	if _, err := config.ConfigureGlobalHooks(false); err != nil {
+		t.Fatalf("ConfigureGlobalHooks() error = %v", err)
+	}
+
+	report := Run(context.Background(), root, false)
+	for _, name := range []string{"Claude Code hooks", "captured events", ".spcstr directories"} {
+		if check := checkNamed(t, report, name); check.Status != StatusPass {
+			t.Errorf("%s: status = %s (%s), want %s", name, check.Status, check.Message, StatusPass)
+		}
+	}
+
+	t.Setenv(config.SettingEnvVar("projects.deny"), root)
+	report = Run(context.Background(), root, false)
+	if check := checkNamed(t, report, "Claude Code hooks"); check.Status != StatusWarn {
+		t.Errorf("denied project: status = %s (%s), want %s", check.Status, check.Message, StatusWarn)
+	}
+	if check := checkNamed(t, report, "captured events"); check.Status != StatusFail {
+		t.Errorf("denied project: captured events status = %s, want %s", check.Status, StatusFail)
+	}
+}
@@ -0,0 +1,220 @@
+// Package gate implements the opt-in "definition of done" check that runs
+// when Claude Code tries to stop
+package gate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dylan/spcstr/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the stop gate settings file in the .spcstr directory
+const FileName = "gate.yaml"
+
+// DefaultTimeout bounds a check that sets no timeout. It stays below the
+// 60 second limit Claude Code puts on a hook by default.
+const DefaultTimeout = 45 * time.Second
+
+// maxCheckOutput caps the output kept for a failing check
+const maxCheckOutput = 2000
+
+// maxListedTodos caps the open todos named in a block reason
+const maxListedTodos = 10
+
+// Config controls the stop gate
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Todos blocks stopping while pending or in-progress todos remain
+	Todos  bool    `yaml:"todos"`
+	Checks []Check `yaml:"checks"`
+	// MaxIterations is how many times in a row the gate may block before
+	// it lets Claude stop anyway
+	MaxIterations int `yaml:"max_iterations"`
+}
+
+// Check is a local command that must exit 0 before Claude may stop
+type Check struct {
+	Name    string        `yaml:"name"`
+	Command string        `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// DefaultConfig returns the settings used when no gate.yaml exists: the
+// gate is off
+func DefaultConfig() Config {
+	return Config{
+		Enabled:       false,
+		Todos:         true,
+		MaxIterations: 3,
+	}
+}
+
+// LoadConfig reads gate.yaml over the defaults. A missing file yields the
+// defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read gate config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse gate config %s: %w", path, err)
+	}
+	for i, check := range cfg.Checks {
+		if strings.TrimSpace(check.Command) == "" {
+			return cfg, fmt.Errorf("gate check %d has no command", i+1)
+		}
+		if check.Name == "" {
+			cfg.Checks[i].Name = check.Command
+		}
+		if check.Timeout <= 0 {
+			cfg.Checks[i].Timeout = DefaultTimeout
+		}
+	}
+	if cfg.MaxIterations <= 0 {
+		cfg.MaxIterations = DefaultConfig().MaxIterations
+	}
+
+	return cfg, nil
+}
+
+// Evaluate checks the session's todos and runs the configured checks in
+// dir. blocks is the number of times the gate has already blocked in a row;
+// once it reaches MaxIterations the checks are skipped and the result is
+// GateCapped.
+func Evaluate(ctx context.Context, dir string, cfg Config, todos state.TodoState, blocks int) state.GateResult {
+	result := state.GateResult{
+		Iteration: blocks + 1,
+		OpenTodos: todos.Pending + todos.InProgress,
+	}
+
+	if blocks >= cfg.MaxIterations {
+		result.Outcome = state.GateCapped
+		result.Reason = fmt.Sprintf("stop gate blocked %d times in a row; allowing stop", blocks)
+		return result
+	}
+
+	var problems []string
+	if cfg.Todos && result.OpenTodos > 0 {
+		problems = append(problems, openTodosProblem(todos, result.OpenTodos))
+	}
+
+	for _, check := range cfg.Checks {
+		checkResult := Run(ctx, dir, check)
+		result.Checks = append(result.Checks, checkResult)
+		if !checkResult.Passed {
+			problems = append(problems, checkProblem(checkResult))
+		}
+	}
+
+	if len(problems) == 0 {
+		result.Outcome = state.GatePassed
+		return result
+	}
+
+	result.Outcome = state.GateBlocked
+	result.Reason = fmt.Sprintf("Definition of done not met (attempt %d of %d):\n\n%s\n\nResolve these before stopping.",
+		result.Iteration, cfg.MaxIterations, strings.Join(problems, "\n\n"))
+	return result
+}
+
+// Run executes a single check through the shell in dir
+func Run(ctx context.Context, dir string, check Check) state.GateCheck {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", check.Command)
+	cmd.Dir = dir
+	// Children of the shell may outlive it and hold the output pipe open
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	result := state.GateCheck{
+		Name:       check.Name,
+		Command:    check.Command,
+		DurationMs: time.Since(start).Milliseconds(),
+		Passed:     err == nil,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		result.ExitCode = -1
+		result.TimedOut = true
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		// The shell could not be started at all
+		result.ExitCode = -1
+		output = append(output, []byte(err.Error())...)
+	}
+
+	if !result.Passed {
+		result.Output = tail(strings.TrimSpace(string(output)), maxCheckOutput)
+	}
+	return result
+}
+
+// openTodosProblem lists the todos that are not completed yet
+func openTodosProblem(todos state.TodoState, open int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d todos are still open:", open)
+	listed := 0
+	for _, item := range todos.Recent {
+		if item.Status == "completed" {
+			continue
+		}
+		if listed == maxListedTodos {
+			b.WriteString("\n- ...")
+			break
+		}
+		fmt.Fprintf(&b, "\n- [%s] %s", item.Status, item.Content)
+		listed++
+	}
+	return b.String()
+}
+
+// checkProblem describes a failing check and the end of its output
+func checkProblem(check state.GateCheck) string {
+	status := fmt.Sprintf("exit %d", check.ExitCode)
+	if check.TimedOut {
+		status = "timed out"
+	}
+	problem := fmt.Sprintf("Check %q failed (%s): %s", check.Name, status, check.Command)
+	if check.Output != "" {
+		problem += "\n" + check.Output
+	}
+	return problem
+}
+
+// tail keeps the last max bytes of s, where failures usually are
+func tail(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	start := len(s) - max + 3
+	// Avoid splitting a multi-byte rune
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return "..." + s[start:]
+}
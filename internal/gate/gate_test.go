@@ -0,0 +1,122 @@
+package gate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := LoadConfig(filepath.Join(dir, FileName))
+	if err != nil || cfg.Enabled || cfg.MaxIterations != 3 {
+		t.Errorf("missing file = %+v, %v; want disabled defaults", cfg, err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	content := "enabled: true\nchecks:\n  - command: go vet ./...\n  - name: test\n    command: go test ./...\n    timeout: 30s\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if !cfg.Enabled || !cfg.Todos || len(cfg.Checks) != 2 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Checks[0].Name != "go vet ./..." || cfg.Checks[0].Timeout != DefaultTimeout {
+		t.Errorf("first check defaults not applied: %+v", cfg.Checks[0])
+	}
+	if cfg.Checks[1].Name != "test" || cfg.Checks[1].Timeout != 30*time.Second {
+		t.Errorf("unexpected second check: %+v", cfg.Checks[1])
+	}
+
+	os.WriteFile(path, []byte("checks:\n  - name: empty\n"), 0644)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() accepted a check without a command")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	openTodos := state.TodoState{
+		Total:   2,
+		Pending: 1,
+		Recent: []state.TodoItem{
+			{Content: "Write tests", Status: "pending"},
+			{Content: "Read code", Status: "completed"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		todos       state.TodoState
+		blocks      int
+		wantOutcome string
+		wantReason  []string
+	}{
+		{
+			name:        "nothing open",
+			cfg:         Config{Todos: true, MaxIterations: 3, Checks: []Check{{Name: "ok", Command: "true"}}},
+			wantOutcome: state.GatePassed,
+		},
+		{
+			name:        "open todos block",
+			cfg:         Config{Todos: true, MaxIterations: 3},
+			todos:       openTodos,
+			wantOutcome: state.GateBlocked,
+			wantReason:  []string{"attempt 1 of 3", "- [pending] Write tests"},
+		},
+		{
+			name:        "todos ignored when disabled",
+			cfg:         Config{Todos: false, MaxIterations: 3},
+			todos:       openTodos,
+			wantOutcome: state.GatePassed,
+		},
+		{
+			name:        "failing check blocks",
+			cfg:         Config{MaxIterations: 3, Checks: []Check{{Name: "lint", Command: "echo bad style; exit 3"}}},
+			blocks:      1,
+			wantOutcome: state.GateBlocked,
+			wantReason:  []string{"attempt 2 of 3", `Check "lint" failed (exit 3)`, "bad style"},
+		},
+		{
+			name:        "cap lets the stop through",
+			cfg:         Config{Todos: true, MaxIterations: 3, Checks: []Check{{Name: "fail", Command: "false"}}},
+			todos:       openTodos,
+			blocks:      3,
+			wantOutcome: state.GateCapped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(context.Background(), t.TempDir(), tt.cfg, tt.todos, tt.blocks)
+			if result.Outcome != tt.wantOutcome {
+				t.Fatalf("Outcome = %q, want %q (reason %q)", result.Outcome, tt.wantOutcome, result.Reason)
+			}
+			for _, want := range tt.wantReason {
+				if !strings.Contains(result.Reason, want) {
+					t.Errorf("reason missing %q:\n%s", want, result.Reason)
+				}
+			}
+			if result.Outcome == state.GateCapped && len(result.Checks) != 0 {
+				t.Error("capped gate should not run checks")
+			}
+		})
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	result := Run(context.Background(), t.TempDir(), Check{Name: "slow", Command: "sleep 5", Timeout: 50 * time.Millisecond})
+	if result.Passed || !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
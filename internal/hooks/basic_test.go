@@ -99,8 +99,8 @@ func TestBasicHookExecution(t *testing.T) {
 	// Test log files were created
 	t.Run("verify_log_files", func(t *testing.T) {
 		expectedLogs := []string{
-			"session_start.json",
-			"session_end.json",
+			"session_start.jsonl",
+			"session_end.jsonl",
 		}
 
 		for _, logFile := range expectedLogs {
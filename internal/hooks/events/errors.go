@@ -0,0 +1,24 @@
+package events
+
+import "errors"
+
+// NonBlockingError marks a hook failure that must not block Claude Code,
+// such as a bug in spcstr itself. The hook command exits 1 instead of 2.
+type NonBlockingError struct {
+	Err error
+}
+
+func (e *NonBlockingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NonBlockingError) Unwrap() error {
+	return e.Err
+}
+
+// IsBlocking reports whether a hook error should block the Claude Code
+// action. Errors are blocking unless they wrap a NonBlockingError.
+func IsBlocking(err error) bool {
+	var nonBlocking *NonBlockingError
+	return err != nil && !errors.As(err, &nonBlocking)
+}
@@ -0,0 +1,17 @@
+package events
+
+// HookOutput is the JSON reply a hook writes to stdout for Claude Code
+type HookOutput struct {
+	// Decision "block" keeps Claude working from Stop and SubagentStop
+	Decision           string              `json:"decision,omitempty"`
+	Reason             string              `json:"reason,omitempty"`
+	HookSpecificOutput *HookSpecificOutput `json:"hookSpecificOutput,omitempty"`
+}
+
+// HookSpecificOutput carries event-specific fields of a hook reply
+type HookSpecificOutput struct {
+	HookEventName            string `json:"hookEventName"`
+	PermissionDecision       string `json:"permissionDecision,omitempty"`
+	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
+	AdditionalContext        string `json:"additionalContext,omitempty"`
+}
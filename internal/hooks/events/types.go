@@ -7,6 +7,7 @@ type ClaudeEvent struct {
 	SessionID       string          `json:"session_id"`
 	HookEventName   string          `json:"hook_event_name"`
 	ToolName        string          `json:"tool_name"`
+	ToolUseID       string          `json:"tool_use_id"`
 	ToolInput       json.RawMessage `json:"tool_input"`
 	ToolResponse    json.RawMessage `json:"tool_response"`
 	PermissionMode  string          `json:"permission_mode"`
@@ -64,6 +65,13 @@ type BashInput struct {
 	Timeout         int    `json:"timeout"`
 }
 
+// BashResponse for Bash tool responses
+type BashResponse struct {
+	Stdout      string `json:"stdout"`
+	Stderr      string `json:"stderr"`
+	Interrupted bool   `json:"interrupted"`
+}
+
 // NotificationParams for notification events
 type NotificationParams struct {
 	SessionID string `json:"session_id"`
@@ -96,7 +104,8 @@ type StopParams struct {
 
 // SubagentStopParams for subagent stop events
 type SubagentStopParams struct {
-	SessionID string `json:"session_id"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
 	// Note: agent_name field doesn't exist in actual events
 }
 
@@ -1,34 +1,105 @@
 package hooks
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/metrics"
+	"github.com/dylan/spcstr/internal/redact"
+	"github.com/dylan/spcstr/internal/state"
 )
 
 // ExecuteHook executes a hook in the context of a project directory
 func ExecuteHook(hookName string, projectDir string, input []byte) error {
+	_, err := ExecuteHookWithOutput(hookName, projectDir, input)
+	return err
+}
+
+// ExecuteHookWithOutput executes a hook in the context of a project directory
+// and returns the JSON output the hook produced for Claude Code, if any
+func ExecuteHookWithOutput(hookName string, projectDir string, input []byte) ([]byte, error) {
 	// 1. Validate project directory
 	if !isValidSpcstrProject(projectDir) {
-		return fmt.Errorf("invalid spcstr project directory: %s", projectDir)
+		return nil, fmt.Errorf("invalid spcstr project directory: %s", projectDir)
 	}
 
 	// 2. Change to project directory
 	oldDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get current working directory: %w", err)
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
 	err = os.Chdir(projectDir)
 	if err != nil {
-		return fmt.Errorf("failed to change to project directory '%s': %w", projectDir, err)
+		return nil, fmt.Errorf("failed to change to project directory '%s': %w", projectDir, err)
 	}
 	defer func() {
 		os.Chdir(oldDir)
 	}()
 
-	// 3. Parse input to get session ID for logging
+	return RunHook(hookName, input)
+}
+
+// RunHook executes and logs a hook in the current working directory, which
+// must be the project root. The daemon calls it directly after changing into
+// the project once at start-up.
+func RunHook(hookName string, input []byte) (output []byte, err error) {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	basePath := filepath.Join(projectDir, ".spcstr")
+
+	// Time each phase and record a sample however the hook ends. Measuring
+	// spcstr's own overhead must never fail the hook either.
+	var sample metrics.Sample
+	phaseStart := time.Now()
+	lap := func() int64 {
+		now := time.Now()
+		elapsed := now.Sub(phaseStart).Microseconds()
+		phaseStart = now
+		return elapsed
+	}
+	defer func() {
+		if metricsErr := metrics.Record(basePath, hookName, sample, err); metricsErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to record hook metrics: %v\n", metricsErr)
+		}
+	}()
+
+	// A broken settings file must not stop tracking, so fall back to the
+	// defaults and say why
+	settings, err := config.LoadSettings(projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using default settings\n", err)
+		settings = config.DefaultSettings()
+	}
+	state.SetDefaultTimeout(settings.State.Timeout)
+	DefaultLogger.SetOptions(logOptions(settings.Logs))
+
+	// A broken redaction.yaml still gets the built-in detectors
+	redactor, err := redact.Load(filepath.Join(basePath, redact.FileName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the built-in redaction detectors\n", err)
+		redactor = redact.Default()
+	}
+
+	// Like settings, a broken middleware.yaml falls back to the defaults
+	middleware, err := LoadMiddleware(basePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the default middleware\n", err)
+		middleware = DefaultMiddleware(basePath)
+	}
+	sample.SetupUs = lap()
+
+	// Scrub secrets before the handler or the logger sees the input
+	input, counts := redactInput(redactor, input)
+
+	// Parse input to get session ID for logging
 	var inputData map[string]interface{}
 	sessionID := ""
 	if err := json.Unmarshal(input, &inputData); err == nil {
@@ -36,18 +107,58 @@ func ExecuteHook(hookName string, projectDir string, input []byte) error {
 			sessionID = id
 		}
 	}
+	sample.ParseUs = lap()
+
+	// Execute hook in project context, then any configured plugins, inside
+	// the middleware from middleware.yaml
+	run := Chain(func(hookName string, input []byte) ([]byte, error) {
+		output, err := DefaultRegistry.ExecuteWithOutput(hookName, input)
+		if err != nil {
+			return output, err
+		}
+		return runPlugins(hookName, sessionID, input, output)
+	}, middleware...)
+	output, err = run(hookName, input)
 
-	// 4. Execute hook in project context
-	err = DefaultRegistry.Execute(hookName, input)
+	// Count the input redactions once the handler has created the session;
+	// like the handler's own updates this is a session state write
+	if counts.Total() > 0 && sessionID != "" {
+		recordInputRedactions(basePath, sessionID, counts)
+	}
+	sample.HandlerUs = lap()
 
-	// 5. Log the event
+	// Log the event
 	success := err == nil
 	if logErr := DefaultLogger.LogEvent(sessionID, hookName, inputData, success); logErr != nil {
 		// Don't fail the hook execution due to logging issues, but print a warning
 		fmt.Fprintf(os.Stderr, "Warning: Failed to log hook event: %v\n", logErr)
 	}
+	sample.LogUs = lap()
 
-	return err
+	return output, err
+}
+
+// recordInputRedactions adds redactions made to hook input to the session's
+// counts. Events for sessions spcstr has not seen are only redacted.
+func recordInputRedactions(basePath, sessionID string, counts redact.Counts) {
+	if !dirExists(filepath.Join(basePath, "sessions", sessionID)) {
+		return
+	}
+	sm := state.NewStateManager(basePath)
+	if err := sm.RecordRedactions(context.Background(), sessionID, counts); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to record redactions: %v\n", err)
+	}
+}
+
+// redactInput replaces secrets in raw hook input. Input that is not JSON is
+// redacted as plain text so nothing slips through unparsed.
+func redactInput(redactor *redact.Redactor, input []byte) ([]byte, redact.Counts) {
+	redacted, counts, err := redactor.JSON(input)
+	if err != nil {
+		text, counts := redactor.String(string(input))
+		return []byte(text), counts
+	}
+	return redacted, counts
 }
 
 // isValidSpcstrProject checks if the directory contains valid .spcstr structure
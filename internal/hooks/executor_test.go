@@ -1,10 +1,16 @@
 package hooks
 
 import (
-	"encoding/json"
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/metrics"
+	"github.com/dylan/spcstr/internal/redact"
+	"github.com/dylan/spcstr/internal/state"
 )
 
 func TestExecuteHook(t *testing.T) {
@@ -207,22 +213,20 @@ func TestExecuteHookWithLogging(t *testing.T) {
 	}
 
 	// Check if log file was created
-	logPath := filepath.Join(logsDir, "logging_test_hook.json")
+	logPath := filepath.Join(logsDir, "logging_test_hook.jsonl")
 	if _, err := os.Stat(logPath); os.IsNotExist(err) {
 		t.Error("Log file was not created")
 		return
 	}
 
 	// Check log file contents
-	logData, err := os.ReadFile(logPath)
-	if err != nil {
-		t.Fatalf("Failed to read log file: %v", err)
-	}
-
 	var events []HookEvent
-	err = json.Unmarshal(logData, &events)
+	err = ReadEvents(logsDir, "logging_test_hook", func(event HookEvent) error {
+		events = append(events, event)
+		return nil
+	})
 	if err != nil {
-		t.Fatalf("Failed to parse log file: %v", err)
+		t.Fatalf("Failed to read log file: %v", err)
 	}
 
 	if len(events) != 1 {
@@ -241,3 +245,163 @@ func TestExecuteHookWithLogging(t *testing.T) {
 		t.Error("Expected success to be true")
 	}
 }
+
+// inputRecorder keeps the input its hook was executed with
+type inputRecorder struct {
+	name  string
+	input []byte
+}
+
+func (r *inputRecorder) Name() string {
+	return r.name
+}
+
+func (r *inputRecorder) Execute(input []byte) error {
+	r.input = input
+	return nil
+}
+
+func TestExecuteHookRedactsInput(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "sessions"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "logs"), 0755)
+
+	sm := state.NewStateManager(filepath.Join(tempDir, ".spcstr"))
+	if _, err := sm.InitializeState(context.Background(), "redact_session"); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+
+	recorder := &inputRecorder{name: "redact_test_hook"}
+	testRegistry := NewRegistry()
+	testRegistry.Register(recorder)
+	oldRegistry := DefaultRegistry
+	DefaultRegistry = testRegistry
+	defer func() {
+		DefaultRegistry = oldRegistry
+	}()
+
+	secret := "ghp_" + strings.Repeat("x", 36)
+	input := []byte(`{"session_id": "redact_session", "prompt": "push with ` + secret + `"}`)
+	if err := ExecuteHook("redact_test_hook", tempDir, input); err != nil {
+		t.Fatalf("ExecuteHook() error: %v", err)
+	}
+
+	if strings.Contains(string(recorder.input), secret) {
+		t.Errorf("handler received the secret: %s", recorder.input)
+	}
+	if !strings.Contains(string(recorder.input), "[REDACTED:github_token]") {
+		t.Errorf("handler input lacks the redaction marker: %s", recorder.input)
+	}
+
+	logData, err := os.ReadFile(filepath.Join(tempDir, ".spcstr", "logs", "redact_test_hook"+LogFileExt))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if strings.Contains(string(logData), secret) {
+		t.Error("hook log contains the secret")
+	}
+
+	sessionState, err := sm.LoadState(context.Background(), "redact_session")
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if sessionState.Redactions["github_token"] != 1 {
+		t.Errorf("Redactions = %v, want github_token=1", sessionState.Redactions)
+	}
+}
+
+func TestExecuteHookBrokenRedactionConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "sessions"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "logs"), 0755)
+	os.WriteFile(filepath.Join(tempDir, ".spcstr", redact.FileName), []byte("patterns: ["), 0644)
+
+	recorder := &inputRecorder{name: "broken_redaction_hook"}
+	testRegistry := NewRegistry()
+	testRegistry.Register(recorder)
+	oldRegistry := DefaultRegistry
+	DefaultRegistry = testRegistry
+	defer func() {
+		DefaultRegistry = oldRegistry
+	}()
+
+	// A malformed redaction.yaml falls back to the built-in detectors
+	secret := "ghp_" + strings.Repeat("x", 36)
+	input := []byte(`{"session_id": "s", "prompt": "push with ` + secret + `"}`)
+	if err := ExecuteHook("broken_redaction_hook", tempDir, input); err != nil {
+		t.Fatalf("ExecuteHook() error = %v, want the broken config ignored", err)
+	}
+	if !strings.Contains(string(recorder.input), "[REDACTED:github_token]") {
+		t.Errorf("handler input not redacted by the built-in detectors: %s", recorder.input)
+	}
+}
+
+func TestExecuteHookBrokenMiddlewareConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "sessions"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "logs"), 0755)
+	os.WriteFile(filepath.Join(tempDir, ".spcstr", MiddlewareFileName), []byte("middleware: [metrics]\n"), 0644)
+
+	testRegistry := NewRegistry()
+	testRegistry.Register(panicHandler{})
+	oldRegistry := DefaultRegistry
+	DefaultRegistry = testRegistry
+	defer func() {
+		DefaultRegistry = oldRegistry
+	}()
+
+	// The default chain still runs, so the panic is recovered
+	err := ExecuteHook("panic_hook", tempDir, []byte(`{"session_id": "s"}`))
+	if err == nil || events.IsBlocking(err) {
+		t.Errorf("ExecuteHook() error = %v, want a non-blocking recovered panic", err)
+	}
+
+	// The failed run is still measured
+	file, err := metrics.Load(filepath.Join(tempDir, ".spcstr"))
+	if err != nil {
+		t.Fatalf("metrics.Load() error: %v", err)
+	}
+	if recorded := file.Hooks["panic_hook"]; recorded == nil || recorded.Failures != 1 {
+		t.Errorf("panic_hook metrics = %+v, want 1 failed run", recorded)
+	}
+}
+
+func TestExecuteHookRecordsMetrics(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "sessions"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, ".spcstr", "logs"), 0755)
+
+	testRegistry := NewRegistry()
+	testRegistry.Register(&inputRecorder{name: "metrics_test_hook"})
+	oldRegistry := DefaultRegistry
+	DefaultRegistry = testRegistry
+	defer func() {
+		DefaultRegistry = oldRegistry
+	}()
+
+	input := []byte(`{"session_id": "metrics_session"}`)
+	for i := 0; i < 2; i++ {
+		if err := ExecuteHook("metrics_test_hook", tempDir, input); err != nil {
+			t.Fatalf("ExecuteHook() error: %v", err)
+		}
+	}
+	if err := ExecuteHook("unregistered_hook", tempDir, input); err == nil {
+		t.Fatal("ExecuteHook() of an unknown hook succeeded")
+	}
+
+	file, err := metrics.Load(filepath.Join(tempDir, ".spcstr"))
+	if err != nil {
+		t.Fatalf("metrics.Load() error: %v", err)
+	}
+	recorded := file.Hooks["metrics_test_hook"]
+	if recorded == nil || recorded.Count != 2 || recorded.Failures != 0 {
+		t.Fatalf("metrics_test_hook metrics = %+v, want 2 runs without failures", recorded)
+	}
+	if len(recorded.Window) != 2 || !recorded.Window[0].Success {
+		t.Errorf("Window = %+v, want 2 successful samples", recorded.Window)
+	}
+	failed := file.Hooks["unregistered_hook"]
+	if failed == nil || failed.Failures != 1 || failed.LastError == "" {
+		t.Errorf("unregistered_hook metrics = %+v, want 1 failure with its error", failed)
+	}
+}
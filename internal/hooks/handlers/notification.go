@@ -73,19 +73,13 @@ func (h *NotificationHandler) Execute(input []byte) error {
 
 	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
 
-	// Add notification to session state using UpdateState
+	// Record notification in the session journal and state
 	ctx := context.Background()
-	err = stateManager.UpdateState(ctx, params.SessionID, func(sessionState *state.SessionState) error {
-		// Create new notification entry
-		newNotification := state.NotificationEntry{
-			Timestamp: notificationTime,
-			Type:      "hook",
-			Message:   params.Message,
-			Level:     level,
-		}
-
-		sessionState.Notifications = append(sessionState.Notifications, newNotification)
-		return nil
+	err = stateManager.AddNotification(ctx, params.SessionID, state.NotificationEntry{
+		Timestamp: notificationTime,
+		Type:      "hook",
+		Message:   params.Message,
+		Level:     level,
 	})
 
 	if err != nil {
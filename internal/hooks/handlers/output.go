@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/injection"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// permissionOutput renders a PreToolUse permission decision for Claude Code
+func permissionOutput(decision *state.PolicyDecision) ([]byte, error) {
+	output, err := json.Marshal(events.HookOutput{
+		HookSpecificOutput: &events.HookSpecificOutput{
+			HookEventName:            "PreToolUse",
+			PermissionDecision:       decision.Decision,
+			PermissionDecisionReason: decision.Reason,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode permission decision: %w", err)
+	}
+	return output, nil
+}
+
+// loadInjectionConfig reads context.yaml. Injection is opt-in, so a broken
+// file turns it off with a warning rather than failing the hook.
+func loadInjectionConfig(basePath string) injection.Config {
+	cfg, err := injection.LoadConfig(filepath.Join(basePath, injection.ConfigFileName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; context injection is off\n", err)
+		return injection.DefaultConfig()
+	}
+	return cfg
+}
+
+// contextOutput renders additional context for SessionStart or
+// UserPromptSubmit. Empty context produces no output.
+func contextOutput(hookEventName, context string) ([]byte, error) {
+	if context == "" {
+		return nil, nil
+	}
+	output, err := json.Marshal(events.HookOutput{
+		HookSpecificOutput: &events.HookSpecificOutput{
+			HookEventName:     hookEventName,
+			AdditionalContext: context,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode additional context: %w", err)
+	}
+	return output, nil
+}
+
+// blockOutput renders a Stop decision that keeps Claude working, with the
+// reason shown to Claude as its next instruction
+func blockOutput(reason string) ([]byte, error) {
+	output, err := json.Marshal(events.HookOutput{
+		Decision: "block",
+		Reason:   reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode block decision: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/policy"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// pathInputKeys are the tool_input fields that name the file a tool touches
+var pathInputKeys = []string{"file_path", "notebook_path", "path"}
+
+// evaluatePolicy checks a tool call against the project policy. It returns
+// nil when there is no policy file or no rule matched. A policy that cannot
+// be loaded fails closed: the call is denied with the error as the reason.
+func evaluatePolicy(ctx context.Context, stateManager *state.StateManager, basePath, projectDir string, event events.ClaudeEvent) (*state.PolicyDecision, error) {
+	pol, err := policy.Load(filepath.Join(basePath, policy.FileName))
+	if err != nil {
+		return &state.PolicyDecision{
+			ToolUseID: event.ToolUseID,
+			ToolName:  event.ToolName,
+			Input:     summarizeToolInput(event.ToolName, event.ToolInput),
+			Rule:      policy.FileName,
+			Decision:  string(policy.Deny),
+			Reason:    fmt.Sprintf("spcstr denies tool calls until .spcstr/%s is fixed: %v", policy.FileName, err),
+		}, nil
+	}
+	if pol == nil {
+		return nil, nil
+	}
+
+	var fields map[string]interface{}
+	json.Unmarshal(event.ToolInput, &fields)
+
+	call := policy.Call{
+		ToolName: event.ToolName,
+		FilePath: projectRelativePath(projectDir, firstString(fields, pathInputKeys...)),
+		Command:  firstString(fields, "command"),
+	}
+	if sessionState, err := stateManager.GetSessionState(ctx, event.SessionID); err == nil {
+		call.Agent = sessionState.CurrentAgent()
+	}
+
+	result, matched := pol.Evaluate(call)
+	if !matched {
+		return nil, nil
+	}
+
+	return &state.PolicyDecision{
+		ToolUseID: event.ToolUseID,
+		ToolName:  event.ToolName,
+		Input:     summarizeToolInput(event.ToolName, event.ToolInput),
+		Agent:     call.Agent,
+		Rule:      result.Rule,
+		Decision:  string(result.Decision),
+		Reason:    result.Reason,
+	}, nil
+}
+
+// projectRelativePath makes paths inside the project relative to its root so
+// policy globs can be written without absolute prefixes
+func projectRelativePath(projectDir, path string) string {
+	if path == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(projectDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
@@ -8,12 +8,13 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/dylan/spcstr/internal/config"
 	"github.com/dylan/spcstr/internal/hooks/events"
 	"github.com/dylan/spcstr/internal/state"
 )
 
 // PostToolUseHandler handles the post_tool_use hook
-// Reference .spcstr/logs/post_tool_use.json for event structure
+// Reference .spcstr/logs/post_tool_use.jsonl for event structure
 // Updates .spcstr/sessions/{session-id}/state.json with extracted data
 type PostToolUseHandler struct{}
 
@@ -43,9 +44,28 @@ func (h *PostToolUseHandler) Execute(input []byte) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
 	ctx := context.Background()
 
+	// Close the matching call on the tool timeline
+	failure := detectToolFailure(event.ToolName, event.ToolResponse)
+	if err := stateManager.FinishToolCall(ctx, event.SessionID, state.ToolCallFinishedData{
+		ID:       event.ToolUseID,
+		ToolName: event.ToolName,
+		Input:    summarizeToolInput(event.ToolName, event.ToolInput),
+		Success:  failure == nil || failure.Severity != "error",
+	}); err != nil {
+		return fmt.Errorf("failed to finish tool call: %w", err)
+	}
+
+	// Record failed tool calls so sessions that fought the tools stand out
+	if failure != nil {
+		if err := stateManager.RecordErrorEntry(ctx, event.SessionID, newToolErrorEntry(event, failure)); err != nil {
+			return fmt.Errorf("failed to record tool error: %w", err)
+		}
+	}
+
 	switch event.ToolName {
 	case "TodoWrite":
 		var todoInput events.TodoWriteInput
@@ -59,6 +79,7 @@ func (h *PostToolUseHandler) Execute(input []byte) error {
 				LastUpdated: time.Now().Format(time.RFC3339),
 			}
 
+			recentLimit := recentTodoLimit(cwd)
 			for i, todo := range todoInput.Todos {
 				switch todo.Status {
 				case "pending":
@@ -69,7 +90,7 @@ func (h *PostToolUseHandler) Execute(input []byte) error {
 					todoState.Completed++
 				}
 
-				if i < 5 {
+				if i < recentLimit {
 					todoState.Recent = append(todoState.Recent, state.TodoItem{
 						Content:    todo.Content,
 						Status:     todo.Status,
@@ -111,6 +132,26 @@ func (h *PostToolUseHandler) Execute(input []byte) error {
 			}
 		}
 
+	case "Bash":
+		var bashInput events.BashInput
+		var bashResponse events.BashResponse
+		if err := json.Unmarshal(event.ToolInput, &bashInput); err == nil && bashInput.Command != "" {
+			json.Unmarshal(event.ToolResponse, &bashResponse)
+			if err := stateManager.RecordCommand(ctx, event.SessionID, state.CommandEntry{
+				ToolUseID:   event.ToolUseID,
+				Command:     bashInput.Command,
+				Description: bashInput.Description,
+				Background:  bashInput.RunInBackground,
+				TimeoutMs:   bashInput.Timeout,
+				ExitCode:    bashExitCode(event.ToolResponse),
+				Interrupted: bashResponse.Interrupted,
+				Stdout:      truncateOutput(bashResponse.Stdout),
+				Stderr:      truncateOutput(bashResponse.Stderr),
+			}); err != nil {
+				return fmt.Errorf("failed to record command: %w", err)
+			}
+		}
+
 	case "Read":
 		var fileInput events.FileOperationInput
 		if err := json.Unmarshal(event.ToolInput, &fileInput); err == nil && fileInput.FilePath != "" {
@@ -118,13 +159,32 @@ func (h *PostToolUseHandler) Execute(input []byte) error {
 		}
 
 	case "Task":
-		// Move agent from active to history when Task completes
-		sessionState, err := stateManager.GetSessionState(ctx, event.SessionID)
-		if err == nil && len(sessionState.Agents) > 0 {
-			// Complete the first active agent (most recently added)
-			stateManager.CompleteAgent(ctx, event.SessionID, sessionState.Agents[0])
+		// Close the execution this Task call started in pre_tool_use
+		var taskInput events.TaskInput
+		json.Unmarshal(event.ToolInput, &taskInput)
+		if err := stateManager.FinishAgent(ctx, event.SessionID, state.AgentFinishedData{
+			ID:          event.ToolUseID,
+			Name:        taskInput.SubagentType,
+			Description: taskInput.Description,
+			Result:      summarizeTaskResult(event.ToolResponse),
+		}); err != nil {
+			return fmt.Errorf("failed to finish agent: %w", err)
 		}
 	}
 
+	// Keep token usage current mid-turn; usage is best effort and
+	// must never fail the tool call
+	recordTranscriptUsage(ctx, stateManager, basePath, event.SessionID, event.TranscriptPath)
+
 	return nil
-}
\ No newline at end of file
+}
+
+// recentTodoLimit returns the configured todos.recent_limit. Hook execution
+// already warned about unreadable settings, so errors fall back quietly.
+func recentTodoLimit(projectRoot string) int {
+	settings, err := config.LoadSettings(projectRoot)
+	if err != nil {
+		return config.DefaultSettings().Todos.RecentLimit
+	}
+	return settings.Todos.RecentLimit
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// setupToolSession creates an initialized session in a temp project and
+// changes into it, returning the state manager and a cleanup func
+func setupToolSession(t *testing.T, sessionID string) (*state.StateManager, func()) {
+	t.Helper()
+	tempDir := t.TempDir()
+	oldDir, _ := os.Getwd()
+	os.Chdir(tempDir)
+
+	manager := state.NewStateManager(filepath.Join(tempDir, ".spcstr"))
+	if _, err := manager.InitializeState(context.Background(), sessionID); err != nil {
+		os.Chdir(oldDir)
+		t.Fatalf("Failed to initialize state: %v", err)
+	}
+	return manager, func() { os.Chdir(oldDir) }
+}
+
+func TestPostToolUseHandlerRecordsErrors(t *testing.T) {
+	sessionID := "tool_failure_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	handler := NewPostToolUseHandler()
+	inputs := []string{
+		`{"session_id": "` + sessionID + `", "tool_name": "Bash", "tool_input": {"command": "go test ./..."}, "tool_response": {"stdout": "", "stderr": "FAIL", "exit_code": 2}}`,
+		`{"session_id": "` + sessionID + `", "tool_name": "Bash", "tool_input": {"command": "ls"}, "tool_response": {"stdout": "a.go", "stderr": ""}}`,
+	}
+	for _, input := range inputs {
+		if err := handler.Execute([]byte(input)); err != nil {
+			t.Fatalf("Execute() error: %v", err)
+		}
+	}
+
+	sessionState, err := manager.LoadState(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	if len(sessionState.Errors) != 1 {
+		t.Fatalf("recorded %d errors, want 1", len(sessionState.Errors))
+	}
+	entry := sessionState.Errors[0]
+	if entry.Source != "Bash" || entry.Type != FailureExitCode || entry.Severity != "error" || entry.Input != "go test ./..." {
+		t.Errorf("unexpected error entry: %+v", entry)
+	}
+
+	if len(sessionState.ToolCalls) != 2 || sessionState.ToolCalls[0].Status != state.ToolCallFailed ||
+		sessionState.ToolCalls[1].Status != state.ToolCallSucceeded {
+		t.Errorf("unexpected tool call statuses: %+v", sessionState.ToolCalls)
+	}
+}
+
+func TestPostToolUseHandlerRecordsBashCommands(t *testing.T) {
+	sessionID := "bash_history_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	pre := NewPreToolUseHandler()
+	post := NewPostToolUseHandler()
+
+	longOutput := strings.Repeat("line\n", 2000)
+	steps := []struct {
+		handler interface{ Execute([]byte) error }
+		input   string
+	}{
+		{pre, `{"session_id": "` + sessionID + `", "tool_use_id": "toolu_1", "tool_name": "Bash", "tool_input": {"command": "go test ./...", "description": "Run tests", "timeout": 60000}}`},
+		{post, `{"session_id": "` + sessionID + `", "tool_use_id": "toolu_1", "tool_name": "Bash", "tool_input": {"command": "go test ./...", "description": "Run tests", "timeout": 60000}, "tool_response": {"stdout": "` + strings.ReplaceAll(longOutput, "\n", "\\n") + `", "stderr": "FAIL", "exit_code": 1}}`},
+		{post, `{"session_id": "` + sessionID + `", "tool_name": "Bash", "tool_input": {"command": "npm run dev", "run_in_background": true}, "tool_response": {"stdout": "started", "stderr": ""}}`},
+	}
+	for i, step := range steps {
+		if err := step.handler.Execute([]byte(step.input)); err != nil {
+			t.Fatalf("step %d Execute() error: %v", i, err)
+		}
+	}
+
+	sessionState, err := manager.LoadState(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	if len(sessionState.Commands) != 2 {
+		t.Fatalf("recorded %d commands, want 2", len(sessionState.Commands))
+	}
+
+	first := sessionState.Commands[0]
+	if first.Command != "go test ./..." || first.Description != "Run tests" || first.TimeoutMs != 60000 || first.ToolUseID != "toolu_1" {
+		t.Errorf("unexpected first command: %+v", first)
+	}
+	if first.ExitCode == nil || *first.ExitCode != 1 {
+		t.Errorf("first command exit code = %v, want 1", first.ExitCode)
+	}
+	if first.DurationMs != sessionState.ToolCalls[0].DurationMs {
+		t.Errorf("first command duration = %d, want tool call duration %d", first.DurationMs, sessionState.ToolCalls[0].DurationMs)
+	}
+	if len(first.Stdout) > maxCommandOutput || !strings.HasPrefix(first.Stdout, "...") {
+		t.Errorf("stdout not truncated: %d bytes", len(first.Stdout))
+	}
+
+	second := sessionState.Commands[1]
+	if !second.Background || second.ExitCode != nil || second.Stdout != "started" {
+		t.Errorf("unexpected second command: %+v", second)
+	}
+}
+
+func TestPostToolUseHandlerRecentTodoLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     int
+	}{
+		{"default", "", 5},
+		{"configured", `{"todos": {"recent_limit": 2}}`, 2},
+		{"disabled", `{"todos": {"recent_limit": 0}}`, 0},
+	}
+
+	todos := make([]string, 7)
+	for i := range todos {
+		todos[i] = `{"content": "task", "status": "pending", "activeForm": "Working"}`
+	}
+	input := `{"session_id": "todo_limit_session", "tool_name": "TodoWrite", "tool_input": {"todos": [` + strings.Join(todos, ",") + `]}}`
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			manager, cleanup := setupToolSession(t, "todo_limit_session")
+			defer cleanup()
+			if tt.settings != "" {
+				os.WriteFile(filepath.Join(".spcstr", "settings.json"), []byte(tt.settings), 0644)
+			}
+
+			if err := NewPostToolUseHandler().Execute([]byte(input)); err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			sessionState, err := manager.LoadState(context.Background(), "todo_limit_session")
+			if err != nil {
+				t.Fatalf("Failed to load state: %v", err)
+			}
+			if sessionState.Todos.Total != 7 || len(sessionState.Todos.Recent) != tt.want {
+				t.Errorf("todos total %d with %d recent, want 7 with %d", sessionState.Todos.Total, len(sessionState.Todos.Recent), tt.want)
+			}
+		})
+	}
+}
@@ -8,11 +8,12 @@ import (
 	"path/filepath"
 
 	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/policy"
 	"github.com/dylan/spcstr/internal/state"
 )
 
 // PreToolUseHandler handles the pre_tool_use hook
-// Reference .spcstr/logs/pre_tool_use.json for event structure
+// Reference .spcstr/logs/pre_tool_use.jsonl for event structure
 // Updates .spcstr/sessions/{session-id}/state.json with tool usage and agent info
 type PreToolUseHandler struct{}
 
@@ -28,38 +29,80 @@ func (h *PreToolUseHandler) Name() string {
 
 // Execute processes the pre_tool_use hook
 func (h *PreToolUseHandler) Execute(input []byte) error {
+	_, err := h.ExecuteWithOutput(input)
+	return err
+}
+
+// ExecuteWithOutput processes the pre_tool_use hook and returns the
+// permission decision for Claude Code when a policy rule matched
+func (h *PreToolUseHandler) ExecuteWithOutput(input []byte) ([]byte, error) {
 	var event events.ClaudeEvent
 	if err := json.Unmarshal(input, &event); err != nil {
-		return fmt.Errorf("failed to parse event: %w", err)
+		return nil, fmt.Errorf("failed to parse event: %w", err)
 	}
 
 	if event.SessionID == "" || event.ToolName == "" {
-		return fmt.Errorf("missing required fields")
+		return nil, fmt.Errorf("missing required fields")
 	}
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
 	ctx := context.Background()
 
+	// Check the call against .spcstr/policy.yaml before recording it
+	decision, err := evaluatePolicy(ctx, stateManager, basePath, cwd, event)
+	if err != nil {
+		return nil, err
+	}
+	var output []byte
+	if decision != nil {
+		if err := stateManager.RecordPolicyDecision(ctx, event.SessionID, *decision); err != nil {
+			return nil, fmt.Errorf("failed to record policy decision: %w", err)
+		}
+		if output, err = permissionOutput(decision); err != nil {
+			return nil, err
+		}
+		// Denied calls never run, so they stay off the usage counts and timeline
+		if decision.Decision == string(policy.Deny) {
+			return output, nil
+		}
+	}
+
 	// Always increment tool usage
 	if err := stateManager.IncrementToolUsage(ctx, event.SessionID, event.ToolName); err != nil {
-		return fmt.Errorf("failed to increment tool usage: %w", err)
+		return nil, fmt.Errorf("failed to increment tool usage: %w", err)
+	}
+
+	// Open a call on the tool timeline, closed by post_tool_use
+	if err := stateManager.StartToolCall(ctx, event.SessionID, state.ToolCallStartedData{
+		ID:       event.ToolUseID,
+		ToolName: event.ToolName,
+		Input:    summarizeToolInput(event.ToolName, event.ToolInput),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to start tool call: %w", err)
 	}
 
 	// Handle Task tool to extract agent info
 	if event.ToolName == "Task" {
 		var taskInput events.TaskInput
 		if err := json.Unmarshal(event.ToolInput, &taskInput); err == nil && taskInput.SubagentType != "" {
-			if err := stateManager.AddAgent(ctx, event.SessionID, taskInput.SubagentType); err != nil {
-				return fmt.Errorf("failed to add agent: %w", err)
+			// Hook input names no parent agent, so the agent joins the main thread
+			if err := stateManager.StartAgent(ctx, event.SessionID, state.AgentSpawnedData{
+				ID:          event.ToolUseID,
+				Name:        taskInput.SubagentType,
+				Description: taskInput.Description,
+				Prompt:      taskInput.Prompt,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to add agent: %w", err)
 			}
 		}
 	}
 
-	// Never block operations - let Claude handle permissions
-	return nil
-}
\ No newline at end of file
+	// Without a matching policy rule, let Claude handle permissions
+	return output, nil
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/policy"
+)
+
+func TestPreToolUseHandlerPolicy(t *testing.T) {
+	sessionID := "policy_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	cwd, _ := os.Getwd()
+	rules := `
+rules:
+  - name: no-rm
+    decision: deny
+    reason: Recursive deletes are blocked
+    tools: [Bash]
+    command: 'rm\s+-rf'
+  - name: env
+    decision: ask
+    paths: ["**/.env"]
+`
+	if err := os.WriteFile(filepath.Join(cwd, ".spcstr", policy.FileName), []byte(rules), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		wantDecision string
+		wantReason   string
+	}{
+		{
+			name:         "deny",
+			input:        `{"session_id": "` + sessionID + `", "tool_use_id": "t1", "tool_name": "Bash", "tool_input": {"command": "rm -rf build"}}`,
+			wantDecision: "deny",
+			wantReason:   "Recursive deletes are blocked",
+		},
+		{
+			name:         "ask with absolute path inside project",
+			input:        `{"session_id": "` + sessionID + `", "tool_use_id": "t2", "tool_name": "Read", "tool_input": {"file_path": "` + filepath.Join(cwd, "app", ".env") + `"}}`,
+			wantDecision: "ask",
+			wantReason:   `spcstr policy rule "env"`,
+		},
+		{
+			name:  "no matching rule",
+			input: `{"session_id": "` + sessionID + `", "tool_use_id": "t3", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+		},
+	}
+
+	handler := NewPreToolUseHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := handler.ExecuteWithOutput([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ExecuteWithOutput() error: %v", err)
+			}
+
+			if tt.wantDecision == "" {
+				if output != nil {
+					t.Errorf("expected no output, got %s", output)
+				}
+				return
+			}
+
+			var reply events.HookOutput
+			if err := json.Unmarshal(output, &reply); err != nil || reply.HookSpecificOutput == nil {
+				t.Fatalf("invalid hook output %s: %v", output, err)
+			}
+			got := reply.HookSpecificOutput
+			if got.HookEventName != "PreToolUse" || got.PermissionDecision != tt.wantDecision || got.PermissionDecisionReason != tt.wantReason {
+				t.Errorf("unexpected decision: %+v", got)
+			}
+		})
+	}
+
+	sessionState, err := manager.LoadState(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	if len(sessionState.PolicyDecisions) != 2 {
+		t.Fatalf("recorded %d decisions, want 2", len(sessionState.PolicyDecisions))
+	}
+	if d := sessionState.PolicyDecisions[0]; d.Rule != "no-rm" || d.Decision != "deny" || d.Input != "rm -rf build" {
+		t.Errorf("unexpected decision record: %+v", d)
+	}
+	// The denied call never runs, so only the ask and unmatched calls are on the timeline
+	if len(sessionState.ToolCalls) != 2 || sessionState.ToolsUsed["Bash"] != 1 {
+		t.Errorf("denied call was recorded: calls=%+v tools=%v", sessionState.ToolCalls, sessionState.ToolsUsed)
+	}
+}
+
+func TestPreToolUseHandlerInvalidPolicy(t *testing.T) {
+	sessionID := "bad_policy_session"
+	_, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	cwd, _ := os.Getwd()
+	if err := os.WriteFile(filepath.Join(cwd, ".spcstr", policy.FileName), []byte("rules:\n  - decision: maybe\n"), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+
+	// A broken policy fails closed with a deny that says why
+	input := `{"session_id": "` + sessionID + `", "tool_name": "Bash", "tool_input": {"command": "ls"}}`
+	output, err := NewPreToolUseHandler().ExecuteWithOutput([]byte(input))
+	if err != nil {
+		t.Fatalf("ExecuteWithOutput() error = %v, want a deny decision", err)
+	}
+	var reply events.HookOutput
+	if err := json.Unmarshal(output, &reply); err != nil || reply.HookSpecificOutput == nil {
+		t.Fatalf("invalid hook output %s: %v", output, err)
+	}
+	got := reply.HookSpecificOutput
+	if got.PermissionDecision != "deny" || !strings.Contains(got.PermissionDecisionReason, "policy.yaml") || !strings.Contains(got.PermissionDecisionReason, "maybe") {
+		t.Errorf("unexpected decision: %+v", got)
+	}
+}
@@ -12,7 +12,8 @@ import (
 
 // SessionEndParams defines the expected input for session_end hook
 type SessionEndParams struct {
-	SessionID string `json:"session_id"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
 }
 
 // SessionEndHandler handles the session_end hook
@@ -46,10 +47,14 @@ func (h *SessionEndHandler) Execute(input []byte) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
+	ctx := context.Background()
+
+	// Final usage sweep before the session closes
+	recordTranscriptUsage(ctx, stateManager, basePath, params.SessionID, params.TranscriptPath)
 
 	// Set session as inactive
-	ctx := context.Background()
 	if err := stateManager.SetSessionActive(ctx, params.SessionID, false); err != nil {
 		return fmt.Errorf("failed to set session inactive: %w", err)
 	}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/dylan/spcstr/internal/injection"
 	"github.com/dylan/spcstr/internal/state"
 )
 
@@ -31,32 +32,47 @@ func (h *SessionStartHandler) Name() string {
 
 // Execute processes the session_start hook
 func (h *SessionStartHandler) Execute(input []byte) error {
+	_, err := h.ExecuteWithOutput(input)
+	return err
+}
+
+// ExecuteWithOutput processes the session_start hook and returns story and
+// architecture context for Claude Code
+func (h *SessionStartHandler) ExecuteWithOutput(input []byte) ([]byte, error) {
 	var params SessionStartParams
 	if err := json.Unmarshal(input, &params); err != nil {
-		return fmt.Errorf("failed to parse session_start parameters: %w", err)
+		return nil, fmt.Errorf("failed to parse session_start parameters: %w", err)
 	}
 
 	// Validate required fields
 	if params.SessionID == "" {
-		return fmt.Errorf("session_id is required")
+		return nil, fmt.Errorf("session_id is required")
 	}
 	if params.Source == "" {
-		return fmt.Errorf("source is required")
+		return nil, fmt.Errorf("source is required")
 	}
 
 	// Create StateManager using current working directory (after --cwd change)
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
 
 	// Initialize state using StateManager
 	ctx := context.Background()
 	if _, err := stateManager.InitializeState(ctx, params.SessionID); err != nil {
-		return fmt.Errorf("failed to initialize session state: %w", err)
+		return nil, fmt.Errorf("failed to initialize session state: %w", err)
+	}
+
+	// Brief Claude on the story in progress and where the architecture lives
+	cfg := loadInjectionConfig(basePath)
+	additionalContext, err := injection.SessionStartContext(cwd, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build session context: %w", err)
 	}
 
-	return nil
+	return contextOutput("SessionStart", additionalContext)
 }
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/injection"
 	"github.com/dylan/spcstr/internal/state"
 )
 
@@ -124,3 +128,45 @@ func TestNewSessionStartHandler(t *testing.T) {
 		t.Fatalf("Expected handler name 'session_start', got '%s'", handler.Name())
 	}
 }
+
+func TestSessionStartHandlerInjectsContext(t *testing.T) {
+	tempDir := t.TempDir()
+	oldDir, _ := os.Getwd()
+	os.Chdir(tempDir)
+	defer os.Chdir(oldDir)
+
+	os.MkdirAll(".spcstr/sessions", 0755)
+	os.MkdirAll("docs/stories", 0755)
+	story := "# Story 1.2: Hooks\n\n## Status\nIn Progress\n\n## Acceptance Criteria\n\n1. Hooks record events\n"
+	if err := os.WriteFile("docs/stories/1.2.story.md", []byte(story), 0644); err != nil {
+		t.Fatalf("Failed to write story: %v", err)
+	}
+
+	// Without context.yaml nothing is injected
+	output, err := NewSessionStartHandler().ExecuteWithOutput([]byte(`{"session_id": "ctx_session_0", "source": "startup"}`))
+	if err != nil || output != nil {
+		t.Fatalf("default injection = %s, %v; want no output", output, err)
+	}
+
+	os.WriteFile(filepath.Join(".spcstr", injection.ConfigFileName), []byte("session_start:\n  enabled: true\n"), 0644)
+	output, err = NewSessionStartHandler().ExecuteWithOutput([]byte(`{"session_id": "ctx_session", "source": "startup"}`))
+	if err != nil {
+		t.Fatalf("ExecuteWithOutput() error: %v", err)
+	}
+
+	var reply events.HookOutput
+	if err := json.Unmarshal(output, &reply); err != nil || reply.HookSpecificOutput == nil {
+		t.Fatalf("invalid hook output %s: %v", output, err)
+	}
+	got := reply.HookSpecificOutput
+	if got.HookEventName != "SessionStart" || !strings.Contains(got.AdditionalContext, "1. Hooks record events") {
+		t.Errorf("unexpected output: %+v", got)
+	}
+
+	// Disabling injection leaves stdout empty
+	os.WriteFile(filepath.Join(".spcstr", injection.ConfigFileName), []byte("session_start:\n  enabled: false\n"), 0644)
+	output, err = NewSessionStartHandler().ExecuteWithOutput([]byte(`{"session_id": "ctx_session_2", "source": "startup"}`))
+	if err != nil || output != nil {
+		t.Errorf("disabled injection = %s, %v; want no output", output, err)
+	}
+}
@@ -1,13 +1,24 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dylan/spcstr/internal/gate"
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/state"
+	"github.com/dylan/spcstr/internal/transcript"
 )
 
 // StopParams defines the expected input for stop hook
 type StopParams struct {
-	SessionID string `json:"session_id"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
 }
 
 // StopHandler handles the stop hook
@@ -25,20 +36,101 @@ func (h *StopHandler) Name() string {
 
 // Execute processes the stop hook
 func (h *StopHandler) Execute(input []byte) error {
+	_, err := h.ExecuteWithOutput(input)
+	return err
+}
+
+// ExecuteWithOutput processes the stop hook and, when the stop gate in
+// gate.yaml is not satisfied, returns a block decision for Claude Code.
+// Only that decision blocks: a failing stop hook would keep Claude working,
+// so every error is reported without blocking.
+func (h *StopHandler) ExecuteWithOutput(input []byte) ([]byte, error) {
+	output, err := h.stop(input)
+	if err != nil {
+		return nil, &events.NonBlockingError{Err: err}
+	}
+	return output, nil
+}
+
+// stop records the end of the turn and evaluates the stop gate
+func (h *StopHandler) stop(input []byte) ([]byte, error) {
 	var params StopParams
 	if err := json.Unmarshal(input, &params); err != nil {
-		return fmt.Errorf("failed to parse stop parameters: %w", err)
+		return nil, fmt.Errorf("failed to parse stop parameters: %w", err)
 	}
 
 	// Validate required fields
 	if params.SessionID == "" {
-		return fmt.Errorf("session_id is required")
+		return nil, fmt.Errorf("session_id is required")
 	}
 
 	// Stop hook is called when Claude finishes a response turn
 	// The session remains active - it only becomes inactive on session_end
-	// For now, we don't need to do anything here, but we keep the handler
-	// to acknowledge the hook was received successfully
-	
-	return nil
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
+	ctx := context.Background()
+
+	// Session might not exist yet, that's ok
+	sessionState, err := stateManager.GetSessionState(ctx, params.SessionID)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Pick up token usage for the turn that just finished
+	recordTranscriptUsage(ctx, stateManager, basePath, params.SessionID, params.TranscriptPath)
+
+	// Close out the prompt this turn answered. The response is a nicety, so
+	// an unreadable transcript completes the prompt without one.
+	if len(sessionState.Prompts) > 0 {
+		var exchange transcript.Exchange
+		if params.TranscriptPath != "" {
+			exchange, err = transcript.LastExchange(ctx, params.TranscriptPath)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to read transcript: %v\n", err)
+			}
+		}
+
+		if err := stateManager.CompletePrompt(ctx, params.SessionID, state.PromptCompletedData{
+			Response: truncateResponse(exchange.Response),
+			Turns:    exchange.Turns,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to complete prompt: %w", err)
+		}
+	}
+
+	return evaluateGate(ctx, stateManager, basePath, cwd, params.SessionID)
+}
+
+// evaluateGate runs the stop gate, records its result and returns a block
+// decision when Claude should keep working
+func evaluateGate(ctx context.Context, stateManager *state.StateManager, basePath, projectDir, sessionID string) ([]byte, error) {
+	cfg, err := gate.LoadConfig(filepath.Join(basePath, gate.FileName))
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	// Reload so the todos and prompts reflect everything recorded so far
+	sessionState, err := stateManager.GetSessionState(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session state: %w", err)
+	}
+
+	result := gate.Evaluate(ctx, projectDir, cfg, sessionState.Todos, sessionState.GateBlocks())
+	result.Timestamp = time.Now()
+	if err := stateManager.RecordGateResult(ctx, sessionID, result); err != nil {
+		return nil, fmt.Errorf("failed to record gate result: %w", err)
+	}
+
+	if result.Outcome != state.GateBlocked {
+		return nil, nil
+	}
+	return blockOutput(result.Reason)
 }
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/gate"
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+func TestStopHandlerCompletesPrompt(t *testing.T) {
+	sessionID := "stop_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := manager.AddPrompt(ctx, sessionID, state.PromptEntry{Timestamp: time.Now().Add(-time.Second), Prompt: "list files"}); err != nil {
+		t.Fatalf("AddPrompt() error: %v", err)
+	}
+	if err := manager.StartToolCall(ctx, sessionID, state.ToolCallStartedData{ToolName: "Bash", Input: "ls"}); err != nil {
+		t.Fatalf("StartToolCall() error: %v", err)
+	}
+
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	content := `{"type":"user","message":{"role":"user","content":"list files"}}` + "\n" +
+		`{"type":"assistant","message":{"id":"a1","content":[{"type":"tool_use","name":"Bash"}]}}` + "\n" +
+		`{"type":"assistant","message":{"id":"a2","content":[{"type":"text","text":"There are two files."}]}}` + "\n"
+	if err := os.WriteFile(transcriptPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	input := `{"session_id": "` + sessionID + `", "transcript_path": "` + transcriptPath + `"}`
+	if err := NewStopHandler().Execute([]byte(input)); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	sessionState, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	prompt := sessionState.Prompts[0]
+	if prompt.Response != "There are two files." || prompt.Turns != 2 || prompt.CompletedAt == nil {
+		t.Errorf("prompt not closed out: %+v", prompt)
+	}
+	if len(prompt.ToolsUsed) != 1 || prompt.ToolsUsed[0] != "Bash" {
+		t.Errorf("ToolsUsed = %v, want [Bash]", prompt.ToolsUsed)
+	}
+}
+
+func TestStopHandlerUnreadableTranscript(t *testing.T) {
+	sessionID := "stop_unreadable_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := manager.AddPrompt(ctx, sessionID, state.PromptEntry{Timestamp: time.Now(), Prompt: "list files"}); err != nil {
+		t.Fatalf("AddPrompt() error: %v", err)
+	}
+
+	// A directory opens but cannot be read as a transcript
+	input := `{"session_id": "` + sessionID + `", "transcript_path": "` + t.TempDir() + `"}`
+	if err := NewStopHandler().Execute([]byte(input)); err != nil {
+		t.Fatalf("Execute() error = %v, want transcript failures ignored", err)
+	}
+
+	sessionState, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if prompt := sessionState.Prompts[0]; prompt.CompletedAt == nil || prompt.Response != "" {
+		t.Errorf("prompt = %+v, want it completed with an empty response", prompt)
+	}
+}
+
+func TestStopHandlerGate(t *testing.T) {
+	sessionID := "gate_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := manager.AddPrompt(ctx, sessionID, state.PromptEntry{Timestamp: time.Now().Add(-time.Second), Prompt: "finish the story"}); err != nil {
+		t.Fatalf("AddPrompt() error: %v", err)
+	}
+	todos := state.TodoState{Total: 1, InProgress: 1, Recent: []state.TodoItem{{Content: "Wire up hooks", Status: "in_progress"}}}
+	if err := manager.UpdateTodos(ctx, sessionID, todos); err != nil {
+		t.Fatalf("UpdateTodos() error: %v", err)
+	}
+
+	// Without gate.yaml the gate stays out of the way
+	handler := NewStopHandler()
+	input := []byte(`{"session_id": "` + sessionID + `"}`)
+	if output, err := handler.ExecuteWithOutput(input); err != nil || output != nil {
+		t.Fatalf("gate disabled = %s, %v; want no output", output, err)
+	}
+
+	config := "enabled: true\nmax_iterations: 2\nchecks:\n  - name: tests\n    command: exit 1\n"
+	if err := os.WriteFile(filepath.Join(".spcstr", gate.FileName), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write gate config: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		output, err := handler.ExecuteWithOutput(input)
+		if err != nil {
+			t.Fatalf("attempt %d error: %v", i, err)
+		}
+		var reply events.HookOutput
+		if err := json.Unmarshal(output, &reply); err != nil {
+			t.Fatalf("attempt %d invalid output %s: %v", i, output, err)
+		}
+		if reply.Decision != "block" || !strings.Contains(reply.Reason, "Wire up hooks") || !strings.Contains(reply.Reason, `Check "tests" failed`) {
+			t.Errorf("attempt %d unexpected reply: %+v", i, reply)
+		}
+	}
+
+	// The third stop in a row hits the cap and is let through
+	if output, err := handler.ExecuteWithOutput(input); err != nil || output != nil {
+		t.Fatalf("capped gate = %s, %v; want no output", output, err)
+	}
+
+	sessionState, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	var outcomes []string
+	for _, result := range sessionState.GateResults {
+		outcomes = append(outcomes, result.Outcome)
+	}
+	want := []string{state.GateBlocked, state.GateBlocked, state.GateCapped}
+	if strings.Join(outcomes, ",") != strings.Join(want, ",") {
+		t.Errorf("recorded outcomes %v, want %v", outcomes, want)
+	}
+	if checks := sessionState.GateResults[0].Checks; len(checks) != 1 || checks[0].ExitCode != 1 {
+		t.Errorf("unexpected recorded checks: %+v", checks)
+	}
+}
+
+func TestStopHandlerBrokenGateConfig(t *testing.T) {
+	sessionID := "broken_gate_session"
+	_, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	if err := os.WriteFile(filepath.Join(".spcstr", gate.FileName), []byte("enabled: [true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write gate config: %v", err)
+	}
+
+	output, err := NewStopHandler().ExecuteWithOutput([]byte(`{"session_id": "` + sessionID + `"}`))
+	if err == nil || output != nil {
+		t.Fatalf("broken gate.yaml = %s, %v; want an error and no block", output, err)
+	}
+	if events.IsBlocking(err) {
+		t.Errorf("error %v is blocking, want it reported without blocking", err)
+	}
+}
+
+func TestStopHandlerStateFailureDoesNotBlock(t *testing.T) {
+	sessionID := "stop_locked_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := manager.AddPrompt(ctx, sessionID, state.PromptEntry{Timestamp: time.Now(), Prompt: "list files"}); err != nil {
+		t.Fatalf("AddPrompt() error: %v", err)
+	}
+
+	// Another process holds the session past the state timeout
+	lock, err := state.AcquireFileLock(ctx, filepath.Join(".spcstr", "sessions", sessionID, state.LockFileName))
+	if err != nil {
+		t.Fatalf("AcquireFileLock() error: %v", err)
+	}
+	defer lock.Release()
+	state.SetDefaultTimeout(50 * time.Millisecond)
+	defer state.SetDefaultTimeout(0)
+
+	output, err := NewStopHandler().ExecuteWithOutput([]byte(`{"session_id": "` + sessionID + `"}`))
+	if err == nil || output != nil {
+		t.Fatalf("locked session = %s, %v; want an error and no block", output, err)
+	}
+	if events.IsBlocking(err) {
+		t.Errorf("error %v is blocking, want it reported without blocking", err)
+	}
+}
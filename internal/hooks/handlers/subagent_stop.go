@@ -12,7 +12,7 @@ import (
 )
 
 // SubagentStopHandler handles the subagent_stop hook
-// Reference .spcstr/logs/subagent_stop.json for event structure
+// Reference .spcstr/logs/subagent_stop.jsonl for event structure
 // Note: agent_name field doesn't exist in actual events
 type SubagentStopHandler struct{}
 
@@ -44,24 +44,26 @@ func (h *SubagentStopHandler) Execute(input []byte) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
 	ctx := context.Background()
 
-	// Get current state to find active agent
-	sessionState, err := stateManager.GetSessionState(ctx, params.SessionID)
-	if err != nil {
-		// Session might not exist yet, that's ok
+	// Session might not exist yet, that's ok
+	if _, err := stateManager.GetSessionState(ctx, params.SessionID); err != nil {
 		return nil
 	}
 
-	// Complete the most recently added agent if any
-	if len(sessionState.Agents) > 0 {
-		if err := stateManager.CompleteAgent(ctx, params.SessionID, sessionState.Agents[0]); err != nil {
-			// Don't fail if we can't complete the agent
-			// This is an observability tool, not a blocker
-			return nil
-		}
+	// Ingest first so the agent's usage lands while it is still running
+	recordTranscriptUsage(ctx, stateManager, basePath, params.SessionID, params.TranscriptPath)
+
+	// SubagentStop does not say which agent stopped; the state only closes
+	// an execution when exactly one is running. PostToolUse for the Task
+	// call completes it otherwise.
+	if err := stateManager.StopSubagent(ctx, params.SessionID); err != nil {
+		// Don't fail if we can't complete the agent
+		// This is an observability tool, not a blocker
+		return nil
 	}
 
 	return nil
-}
\ No newline at end of file
+}
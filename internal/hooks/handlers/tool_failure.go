@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// Tool failure types recorded on ErrorEntry.Type
+const (
+	FailureToolError    = "tool_error"
+	FailureExitCode     = "exit_code"
+	FailureInterrupted  = "interrupted"
+	FailureStderr       = "stderr"
+	FailureEditMismatch = "edit_mismatch"
+)
+
+// maxFailureMessage bounds the error message stored per failure
+const maxFailureMessage = 300
+
+// toolFailure describes a failure detected in a tool_response
+type toolFailure struct {
+	Type     string
+	Message  string
+	Severity string
+}
+
+// editMismatchMarkers are substrings of Edit/MultiEdit "string not found" errors
+var editMismatchMarkers = []string{
+	"string to replace not found",
+	"old_string not found",
+	"not found in file",
+}
+
+// exitCodeKeys are the tool_response fields that may carry a Bash exit code
+var exitCodeKeys = []string{"exit_code", "exitCode", "returnCode"}
+
+// detectToolFailure inspects a tool_response for known failure shapes.
+// It returns nil when the response looks successful.
+func detectToolFailure(toolName string, response json.RawMessage) *toolFailure {
+	if len(response) == 0 {
+		return nil
+	}
+
+	// Some tools report failures as a bare string response
+	var text string
+	if err := json.Unmarshal(response, &text); err == nil {
+		return detectTextFailure(toolName, text)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(response, &fields); err != nil {
+		return nil
+	}
+
+	if isError, ok := fields["is_error"].(bool); ok && isError {
+		message := firstString(fields, "error", "content", "message")
+		if failure := detectTextFailure(toolName, message); failure != nil {
+			return failure
+		}
+		return &toolFailure{Type: FailureToolError, Message: truncateMessage(message), Severity: "error"}
+	}
+
+	if toolName == "Bash" {
+		stderr := firstString(fields, "stderr")
+		if code, ok := firstNumber(fields, exitCodeKeys...); ok && code != 0 {
+			message := fmt.Sprintf("exit code %d", code)
+			if line := firstLine(stderr); line != "" {
+				message += ": " + line
+			}
+			return &toolFailure{Type: FailureExitCode, Message: truncateMessage(message), Severity: "error"}
+		}
+		if interrupted, ok := fields["interrupted"].(bool); ok && interrupted {
+			return &toolFailure{Type: FailureInterrupted, Message: "command interrupted", Severity: "warning"}
+		}
+		if strings.TrimSpace(stderr) != "" {
+			return &toolFailure{Type: FailureStderr, Message: truncateMessage(firstLine(stderr)), Severity: "warning"}
+		}
+	}
+
+	if message := firstString(fields, "error"); message != "" {
+		if failure := detectTextFailure(toolName, message); failure != nil {
+			return failure
+		}
+		return &toolFailure{Type: FailureToolError, Message: truncateMessage(message), Severity: "error"}
+	}
+
+	if success, ok := fields["success"].(bool); ok && !success {
+		return &toolFailure{Type: FailureToolError, Message: "tool reported success: false", Severity: "error"}
+	}
+
+	return nil
+}
+
+// detectTextFailure classifies a plain-text tool response
+func detectTextFailure(toolName, text string) *toolFailure {
+	lower := strings.ToLower(text)
+
+	if toolName == "Edit" || toolName == "MultiEdit" {
+		for _, marker := range editMismatchMarkers {
+			if strings.Contains(lower, marker) {
+				return &toolFailure{Type: FailureEditMismatch, Message: truncateMessage(firstLine(text)), Severity: "error"}
+			}
+		}
+	}
+
+	if strings.HasPrefix(lower, "error") || strings.Contains(lower, "<tool_use_error>") {
+		return &toolFailure{Type: FailureToolError, Message: truncateMessage(firstLine(text)), Severity: "error"}
+	}
+
+	return nil
+}
+
+// bashExitCode extracts the exit code from a Bash tool_response, if reported
+func bashExitCode(response json.RawMessage) *int {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(response, &fields); err != nil {
+		return nil
+	}
+	if code, ok := firstNumber(fields, exitCodeKeys...); ok {
+		return &code
+	}
+	return nil
+}
+
+// newToolErrorEntry builds the ErrorEntry recorded for a tool failure
+func newToolErrorEntry(event events.ClaudeEvent, failure *toolFailure) state.ErrorEntry {
+	return state.ErrorEntry{
+		Message:  failure.Message,
+		Source:   event.ToolName,
+		Severity: failure.Severity,
+		Type:     failure.Type,
+		Input:    summarizeToolInput(event.ToolName, event.ToolInput),
+	}
+}
+
+// firstString returns the first non-empty string field among keys
+func firstString(fields map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// firstNumber returns the first numeric field among keys
+func firstNumber(fields map[string]interface{}, keys ...string) (int, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key].(float64); ok {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
+
+// firstLine returns the first non-blank line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// truncateMessage shortens an error message for storage
+func truncateMessage(s string) string {
+	if len([]rune(s)) > maxFailureMessage {
+		return string([]rune(s)[:maxFailureMessage-3]) + "..."
+	}
+	return s
+}
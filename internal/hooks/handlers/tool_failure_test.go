@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDetectToolFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		toolName     string
+		response     string
+		wantType     string
+		wantSeverity string
+		wantMessage  string
+	}{
+		{
+			name:     "successful bash",
+			toolName: "Bash",
+			response: `{"stdout": "ok", "stderr": "", "interrupted": false}`,
+		},
+		{
+			name:     "successful write",
+			toolName: "Write",
+			response: `{"filePath": "/a.go", "type": "create"}`,
+		},
+		{
+			name:     "empty response",
+			toolName: "Read",
+			response: ``,
+		},
+		{
+			name:         "bash non-zero exit code",
+			toolName:     "Bash",
+			response:     `{"stdout": "", "stderr": "\nFAIL: TestFoo\nmore", "exit_code": 1}`,
+			wantType:     FailureExitCode,
+			wantSeverity: "error",
+			wantMessage:  "exit code 1: FAIL: TestFoo",
+		},
+		{
+			name:         "bash stderr only",
+			toolName:     "Bash",
+			response:     `{"stdout": "", "stderr": "warning: deprecated"}`,
+			wantType:     FailureStderr,
+			wantSeverity: "warning",
+			wantMessage:  "warning: deprecated",
+		},
+		{
+			name:         "bash interrupted",
+			toolName:     "Bash",
+			response:     `{"stdout": "", "stderr": "", "interrupted": true}`,
+			wantType:     FailureInterrupted,
+			wantSeverity: "warning",
+			wantMessage:  "command interrupted",
+		},
+		{
+			name:         "is_error flag",
+			toolName:     "WebFetch",
+			response:     `{"is_error": true, "content": "404 Not Found"}`,
+			wantType:     FailureToolError,
+			wantSeverity: "error",
+			wantMessage:  "404 Not Found",
+		},
+		{
+			name:         "edit string not found",
+			toolName:     "Edit",
+			response:     `"Error: String to replace not found in file.\nString: foo"`,
+			wantType:     FailureEditMismatch,
+			wantSeverity: "error",
+			wantMessage:  "Error: String to replace not found in file.",
+		},
+		{
+			name:         "multiedit is_error not found",
+			toolName:     "MultiEdit",
+			response:     `{"is_error": true, "error": "old_string not found in file"}`,
+			wantType:     FailureEditMismatch,
+			wantSeverity: "error",
+			wantMessage:  "old_string not found in file",
+		},
+		{
+			name:         "generic string error",
+			toolName:     "Read",
+			response:     `"<tool_use_error>File does not exist.</tool_use_error>"`,
+			wantType:     FailureToolError,
+			wantSeverity: "error",
+			wantMessage:  "<tool_use_error>File does not exist.</tool_use_error>",
+		},
+		{
+			name:         "success false",
+			toolName:     "Write",
+			response:     `{"success": false}`,
+			wantType:     FailureToolError,
+			wantSeverity: "error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := detectToolFailure(tt.toolName, json.RawMessage(tt.response))
+
+			if tt.wantType == "" {
+				if failure != nil {
+					t.Errorf("detectToolFailure() = %+v, want nil", failure)
+				}
+				return
+			}
+
+			if failure == nil {
+				t.Fatalf("detectToolFailure() = nil, want %s", tt.wantType)
+			}
+			if failure.Type != tt.wantType || failure.Severity != tt.wantSeverity {
+				t.Errorf("detectToolFailure() = %+v, want type %s severity %s", failure, tt.wantType, tt.wantSeverity)
+			}
+			if tt.wantMessage != "" && failure.Message != tt.wantMessage {
+				t.Errorf("detectToolFailure() message = %q, want %q", failure.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxToolInputSummary bounds the summarized tool input stored per call
+	maxToolInputSummary = 120
+	// maxCommandOutput bounds the stdout/stderr kept per Bash command
+	maxCommandOutput = 4000
+	// maxAgentResult bounds the result summary kept per Task agent
+	maxAgentResult = 300
+	// maxPromptResponse bounds the assistant response kept per prompt
+	maxPromptResponse = 2000
+)
+
+// summarizeToolInput reduces a tool_input payload to a short, human
+// readable description for the tool call timeline
+func summarizeToolInput(toolName string, input json.RawMessage) string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return ""
+	}
+
+	// Pick the field that best identifies the call for each known tool
+	var keys []string
+	switch toolName {
+	case "Bash":
+		keys = []string{"command"}
+	case "Read", "Write", "Edit", "MultiEdit", "NotebookEdit":
+		keys = []string{"file_path", "notebook_path"}
+	case "Glob", "Grep":
+		keys = []string{"pattern"}
+	case "Task":
+		keys = []string{"description", "subagent_type"}
+	case "WebFetch":
+		keys = []string{"url"}
+	case "WebSearch":
+		keys = []string{"query"}
+	}
+
+	for _, key := range keys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return truncateSummary(value)
+		}
+	}
+
+	compact, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return truncateSummary(string(compact))
+}
+
+// truncateSummary collapses whitespace and shortens a summary string
+func truncateSummary(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) > maxToolInputSummary {
+		return string([]rune(s)[:maxToolInputSummary-3]) + "..."
+	}
+	return s
+}
+
+// truncateResponse keeps the head of an assistant response, where the
+// answer is usually stated
+func truncateResponse(s string) string {
+	s = strings.TrimSpace(s)
+	if len([]rune(s)) > maxPromptResponse {
+		return string([]rune(s)[:maxPromptResponse-3]) + "..."
+	}
+	return s
+}
+
+// truncateOutput keeps the tail of command output, where failures and
+// summaries usually appear
+func truncateOutput(s string) string {
+	if len(s) <= maxCommandOutput {
+		return s
+	}
+	tail := s[len(s)-maxCommandOutput+len("...\n"):]
+	// Avoid starting in the middle of a multi-byte rune
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	return "...\n" + tail
+}
+
+// summarizeTaskResult extracts the agent's final text from a Task
+// tool_response, which is either a plain string or a content block list
+func summarizeTaskResult(response json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(response, &text); err != nil {
+		var structured struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		}
+		if err := json.Unmarshal(response, &structured); err != nil {
+			return ""
+		}
+		var parts []string
+		for _, block := range structured.Content {
+			if block.Type == "text" && block.Text != "" {
+				parts = append(parts, block.Text)
+			}
+		}
+		text = strings.Join(parts, " ")
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if len([]rune(text)) > maxAgentResult {
+		return string([]rune(text)[:maxAgentResult-3]) + "..."
+	}
+	return text
+}
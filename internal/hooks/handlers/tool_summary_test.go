@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeToolInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolName string
+		input    string
+		want     string
+	}{
+		{
+			name:     "bash uses command",
+			toolName: "Bash",
+			input:    `{"command": "go test ./...", "description": "Run tests"}`,
+			want:     "go test ./...",
+		},
+		{
+			name:     "edit uses file path",
+			toolName: "Edit",
+			input:    `{"file_path": "/src/main.go", "old_string": "a", "new_string": "b"}`,
+			want:     "/src/main.go",
+		},
+		{
+			name:     "task uses description",
+			toolName: "Task",
+			input:    `{"description": "Review code", "prompt": "...", "subagent_type": "qa"}`,
+			want:     "Review code",
+		},
+		{
+			name:     "multiline command is collapsed",
+			toolName: "Bash",
+			input:    `{"command": "echo a\n  echo b"}`,
+			want:     "echo a echo b",
+		},
+		{
+			name:     "unknown tool falls back to compact json",
+			toolName: "mcp__custom",
+			input:    `{"key": "value"}`,
+			want:     `{"key":"value"}`,
+		},
+		{
+			name:     "invalid input",
+			toolName: "Bash",
+			input:    `not json`,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeToolInput(tt.toolName, json.RawMessage(tt.input))
+			if got != tt.want {
+				t.Errorf("summarizeToolInput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeToolInputTruncates(t *testing.T) {
+	long := strings.Repeat("x", 500)
+	got := summarizeToolInput("Bash", json.RawMessage(`{"command": "`+long+`"}`))
+	if len(got) != maxToolInputSummary || !strings.HasSuffix(got, "...") {
+		t.Errorf("summarizeToolInput() length = %d, want %d with ellipsis", len(got), maxToolInputSummary)
+	}
+}
+
+func TestSummarizeTaskResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "content blocks",
+			response: `{"content": [{"type": "text", "text": "Story drafted."}, {"type": "text", "text": "Ready for review"}], "totalDurationMs": 1200}`,
+			want:     "Story drafted. Ready for review",
+		},
+		{
+			name:     "plain string",
+			response: `"All tests pass\n"`,
+			want:     "All tests pass",
+		},
+		{
+			name:     "empty response",
+			response: `{}`,
+			want:     "",
+		},
+		{
+			name:     "long result is truncated",
+			response: `"` + strings.Repeat("a", maxAgentResult+50) + `"`,
+			want:     strings.Repeat("a", maxAgentResult-3) + "...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeTaskResult(json.RawMessage(tt.response))
+			if got != tt.want {
+				t.Errorf("summarizeTaskResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
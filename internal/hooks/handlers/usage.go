@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dylan/spcstr/internal/state"
+	"github.com/dylan/spcstr/internal/transcript"
+)
+
+// recordTranscriptUsage ingests the transcript as a best effort: token usage
+// is informational, so a failure is reported on stderr and never fails or
+// blocks the hook
+func recordTranscriptUsage(ctx context.Context, stateManager *state.StateManager, basePath, sessionID, transcriptPath string) {
+	if err := ingestTranscript(ctx, stateManager, basePath, sessionID, transcriptPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to ingest transcript: %v\n", err)
+	}
+}
+
+// ingestTranscript records token usage for transcript messages written
+// since the last ingestion. A missing transcript is not an error.
+func ingestTranscript(ctx context.Context, stateManager *state.StateManager, basePath, sessionID, transcriptPath string) error {
+	if transcriptPath == "" {
+		return nil
+	}
+
+	sessionState, err := stateManager.GetSessionState(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	var cursor transcript.Cursor
+	if sessionState.Usage.TranscriptPath == transcriptPath {
+		cursor = transcript.Cursor{
+			Offset:        sessionState.Usage.Offset,
+			LastMessageID: sessionState.Usage.LastMessageID,
+			LastUsage: transcript.Usage{
+				InputTokens:         sessionState.Usage.LastUsage.InputTokens,
+				OutputTokens:        sessionState.Usage.LastUsage.OutputTokens,
+				CacheCreationTokens: sessionState.Usage.LastUsage.CacheCreationTokens,
+				CacheReadTokens:     sessionState.Usage.LastUsage.CacheReadTokens,
+			},
+		}
+	}
+
+	records, next, err := transcript.Tail(ctx, transcriptPath, cursor)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if next == cursor {
+		return nil
+	}
+
+	prices, err := transcript.LoadPrices(filepath.Join(basePath, transcript.PriceFileName))
+	if err != nil {
+		return err
+	}
+
+	data := state.UsageRecordedData{
+		TranscriptPath: transcriptPath,
+		FromOffset:     cursor.Offset,
+		ToOffset:       next.Offset,
+		LastMessageID:  next.LastMessageID,
+		LastUsage:      tokenUsage(next.LastUsage),
+		Records:        make([]state.UsageRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		usage := tokenUsage(record.Usage)
+		usage.CostUSD = prices.Cost(record.Model, record.Usage)
+		// A continued message was already counted once
+		if !record.Continued {
+			usage.Messages = 1
+		}
+		data.Records = append(data.Records, state.UsageRecord{
+			Timestamp: record.Timestamp,
+			Model:     record.Model,
+			Sidechain: record.Sidechain,
+			Usage:     usage,
+		})
+	}
+
+	return stateManager.RecordUsage(ctx, sessionID, data)
+}
+
+// tokenUsage converts transcript token counts to session usage
+func tokenUsage(u transcript.Usage) state.TokenUsage {
+	return state.TokenUsage{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		CacheCreationTokens: u.CacheCreationTokens,
+		CacheReadTokens:     u.CacheReadTokens,
+	}
+}
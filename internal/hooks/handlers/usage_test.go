@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/transcript"
+)
+
+func TestStopHandlerIngestsTranscript(t *testing.T) {
+	sessionID := "usage_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	line := `{"type":"assistant","timestamp":"2025-01-01T12:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":1000000,"output_tokens":0}}}` + "\n"
+	if err := os.WriteFile(transcriptPath, []byte(line), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	input := `{"session_id": "` + sessionID + `", "transcript_path": "` + transcriptPath + `"}`
+	handler := NewStopHandler()
+	// A second stop with no new transcript lines must not double count
+	for i := 0; i < 2; i++ {
+		if err := handler.Execute([]byte(input)); err != nil {
+			t.Fatalf("Execute() error: %v", err)
+		}
+	}
+
+	sessionState, err := manager.LoadState(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	usage := sessionState.Usage
+	if usage.Total.Messages != 1 || usage.Total.InputTokens != 1000000 {
+		t.Errorf("Total = %+v, want one message with 1M input tokens", usage.Total)
+	}
+	if usage.Total.CostUSD != 3 {
+		t.Errorf("CostUSD = %v, want 3 from the default price table", usage.Total.CostUSD)
+	}
+	if usage.Offset != int64(len(line)) {
+		t.Errorf("Offset = %d, want %d", usage.Offset, len(line))
+	}
+}
+
+func TestStopHandlerIngestsStreamedMessage(t *testing.T) {
+	sessionID := "usage_streamed_session"
+	manager, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	streamed := func(outputTokens string) string {
+		return `{"type":"assistant","timestamp":"2025-01-01T12:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":1000,"output_tokens":` + outputTokens + `}}}` + "\n"
+	}
+	input := `{"session_id": "` + sessionID + `", "transcript_path": "` + transcriptPath + `"}`
+
+	// The message's final line is written after the first stop ingested it
+	transcript := ""
+	for _, outputTokens := range []string{"1", "300"} {
+		transcript += streamed(outputTokens)
+		if err := os.WriteFile(transcriptPath, []byte(transcript), 0644); err != nil {
+			t.Fatalf("Failed to write transcript: %v", err)
+		}
+		if err := NewStopHandler().Execute([]byte(input)); err != nil {
+			t.Fatalf("Execute() error: %v", err)
+		}
+	}
+
+	sessionState, err := manager.LoadState(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	total := sessionState.Usage.Total
+	if total.Messages != 1 || total.InputTokens != 1000 || total.OutputTokens != 300 {
+		t.Errorf("Total = %+v, want one message with 1000 input and 300 output tokens", total)
+	}
+}
+
+func TestStopHandlerSurvivesIngestFailure(t *testing.T) {
+	sessionID := "usage_failure_session"
+	_, cleanup := setupToolSession(t, sessionID)
+	defer cleanup()
+
+	// A broken price table must not block the stop
+	if err := os.WriteFile(filepath.Join(".spcstr", transcript.PriceFileName), []byte("models: ["), 0644); err != nil {
+		t.Fatalf("Failed to write price table: %v", err)
+	}
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	line := `{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":5}}}` + "\n"
+	if err := os.WriteFile(transcriptPath, []byte(line), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	input := `{"session_id": "` + sessionID + `", "transcript_path": "` + transcriptPath + `"}`
+	if err := NewStopHandler().Execute([]byte(input)); err != nil {
+		t.Errorf("Execute() error = %v, want ingestion failures ignored", err)
+	}
+	if err := NewSessionEndHandler().Execute([]byte(input)); err != nil {
+		t.Errorf("session_end Execute() error = %v, want ingestion failures ignored", err)
+	}
+}
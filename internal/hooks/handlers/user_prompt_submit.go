@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/dylan/spcstr/internal/injection"
 	"github.com/dylan/spcstr/internal/state"
 )
 
@@ -33,17 +34,24 @@ func (h *UserPromptSubmitHandler) Name() string {
 
 // Execute processes the user_prompt_submit hook
 func (h *UserPromptSubmitHandler) Execute(input []byte) error {
+	_, err := h.ExecuteWithOutput(input)
+	return err
+}
+
+// ExecuteWithOutput processes the user_prompt_submit hook and, when enabled
+// in context.yaml, returns a summary of the previous session for Claude Code
+func (h *UserPromptSubmitHandler) ExecuteWithOutput(input []byte) ([]byte, error) {
 	var params UserPromptSubmitParams
 	if err := json.Unmarshal(input, &params); err != nil {
-		return fmt.Errorf("failed to parse user_prompt_submit parameters: %w", err)
+		return nil, fmt.Errorf("failed to parse user_prompt_submit parameters: %w", err)
 	}
 
 	// Validate required fields
 	if params.SessionID == "" {
-		return fmt.Errorf("session_id is required")
+		return nil, fmt.Errorf("session_id is required")
 	}
 	if params.Prompt == "" {
-		return fmt.Errorf("prompt is required")
+		return nil, fmt.Errorf("prompt is required")
 	}
 
 	// Parse timestamp or use current time
@@ -61,29 +69,64 @@ func (h *UserPromptSubmitHandler) Execute(input []byte) error {
 	// Create StateManager using current working directory (after --cwd change)
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	stateManager := state.NewStateManager(filepath.Join(cwd, ".spcstr"))
-
-	// Add prompt to session state using UpdateState
+	basePath := filepath.Join(cwd, ".spcstr")
+	stateManager := state.NewStateManager(basePath)
 	ctx := context.Background()
-	err = stateManager.UpdateState(ctx, params.SessionID, func(sessionState *state.SessionState) error {
-		// Create new prompt entry
-		newPrompt := state.PromptEntry{
-			Timestamp: promptTime,
-			Prompt:    params.Prompt,
-			Response:  "", // Will be filled by response hooks if needed
-			ToolsUsed: []string{},
-		}
 
-		sessionState.Prompts = append(sessionState.Prompts, newPrompt)
-		return nil
+	// Build context before recording the prompt so the first prompt is detectable
+	cfg := loadInjectionConfig(basePath)
+	additionalContext := ""
+	if cfg.PromptSubmit.Enabled {
+		additionalContext = previousSessionContext(ctx, stateManager, cwd, params.SessionID, cfg)
+	}
+
+	// Record prompt in the session journal and state
+	err = stateManager.AddPrompt(ctx, params.SessionID, state.PromptEntry{
+		Timestamp: promptTime,
+		Prompt:    params.Prompt,
+		Response:  "", // Filled in by the stop hook
+		ToolsUsed: []string{},
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to add prompt to session: %w", err)
+		return nil, fmt.Errorf("failed to add prompt to session: %w", err)
+	}
+
+	return contextOutput("UserPromptSubmit", additionalContext)
+}
+
+// previousSessionContext summarizes the session that ran before sessionID.
+// Context is best effort, so unreadable sessions are skipped.
+func previousSessionContext(ctx context.Context, stateManager *state.StateManager, rootPath, sessionID string, cfg injection.Config) string {
+	current, err := stateManager.GetSessionState(ctx, sessionID)
+	if err != nil {
+		return ""
+	}
+	if cfg.PromptSubmit.FirstPromptOnly && len(current.Prompts) > 0 {
+		return ""
+	}
+
+	sessionIDs, err := stateManager.ListSessions(ctx)
+	if err != nil {
+		return ""
+	}
+
+	var previous *state.SessionState
+	for _, id := range sessionIDs {
+		if id == sessionID {
+			continue
+		}
+		candidate, err := stateManager.LoadState(ctx, id)
+		if err != nil || !candidate.CreatedAt.Before(current.CreatedAt) {
+			continue
+		}
+		if previous == nil || candidate.CreatedAt.After(previous.CreatedAt) {
+			previous = candidate
+		}
 	}
 
-	return nil
+	return injection.PromptContext(rootPath, previous, cfg)
 }
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/injection"
 	"github.com/dylan/spcstr/internal/state"
 )
 
@@ -165,3 +169,88 @@ func TestUserPromptSubmitHandlerTimestampParsing(t *testing.T) {
 		t.Errorf("Expected timestamp %v, got %v", expectedTime, actualTime)
 	}
 }
+
+func TestUserPromptSubmitHandlerInjectsPreviousSession(t *testing.T) {
+	tempDir := t.TempDir()
+	oldDir, _ := os.Getwd()
+	os.Chdir(tempDir)
+	defer os.Chdir(oldDir)
+
+	basePath := filepath.Join(tempDir, ".spcstr")
+	os.MkdirAll(filepath.Join(basePath, "sessions"), 0755)
+	os.WriteFile(filepath.Join(basePath, injection.ConfigFileName), []byte("prompt_submit:\n  enabled: true\n"), 0644)
+
+	ctx := context.Background()
+	manager := state.NewStateManager(basePath)
+	if _, err := manager.InitializeState(ctx, "previous_session"); err != nil {
+		t.Fatalf("Failed to initialize previous session: %v", err)
+	}
+	err := manager.UpdateTodos(ctx, "previous_session", state.TodoState{
+		Total:   1,
+		Pending: 1,
+		Recent:  []state.TodoItem{{Content: "Finish migration", Status: "pending"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update todos: %v", err)
+	}
+	if _, err := manager.InitializeState(ctx, "current_session"); err != nil {
+		t.Fatalf("Failed to initialize current session: %v", err)
+	}
+
+	handler := NewUserPromptSubmitHandler()
+	output, err := handler.ExecuteWithOutput([]byte(`{"session_id": "current_session", "prompt": "Continue"}`))
+	if err != nil {
+		t.Fatalf("ExecuteWithOutput() error: %v", err)
+	}
+
+	var reply events.HookOutput
+	if err := json.Unmarshal(output, &reply); err != nil || reply.HookSpecificOutput == nil {
+		t.Fatalf("invalid hook output %s: %v", output, err)
+	}
+	got := reply.HookSpecificOutput
+	if got.HookEventName != "UserPromptSubmit" || !strings.Contains(got.AdditionalContext, "- [pending] Finish migration") {
+		t.Errorf("unexpected output: %+v", got)
+	}
+
+	// Only the first prompt of a session receives the summary
+	output, err = handler.ExecuteWithOutput([]byte(`{"session_id": "current_session", "prompt": "And then?"}`))
+	if err != nil || output != nil {
+		t.Errorf("second prompt = %s, %v; want no output", output, err)
+	}
+}
+
+func TestUserPromptSubmitHandlerBrokenContextConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	oldDir, _ := os.Getwd()
+	os.Chdir(tempDir)
+	defer os.Chdir(oldDir)
+
+	basePath := filepath.Join(tempDir, ".spcstr")
+	os.MkdirAll(filepath.Join(basePath, "sessions"), 0755)
+	os.WriteFile(filepath.Join(basePath, injection.ConfigFileName), []byte("prompt_submit: [enabled\n"), 0644)
+
+	ctx := context.Background()
+	manager := state.NewStateManager(basePath)
+	if _, err := manager.InitializeState(ctx, "broken_context_session"); err != nil {
+		t.Fatalf("Failed to initialize session: %v", err)
+	}
+
+	// A broken context.yaml turns injection off but still records the prompt
+	output, err := NewUserPromptSubmitHandler().ExecuteWithOutput([]byte(`{"session_id": "broken_context_session", "prompt": "Continue"}`))
+	if err != nil || output != nil {
+		t.Fatalf("broken context.yaml = %s, %v; want no output and no error", output, err)
+	}
+	sessionState, err := manager.LoadState(ctx, "broken_context_session")
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if len(sessionState.Prompts) != 1 || sessionState.Prompts[0].Prompt != "Continue" {
+		t.Errorf("Prompts = %+v, want the prompt recorded", sessionState.Prompts)
+	}
+
+	// Session start falls back the same way
+	output, err = NewSessionStartHandler().ExecuteWithOutput([]byte(`{"session_id": "broken_context_start", "source": "startup"}`))
+	if err != nil || output != nil {
+		t.Errorf("session_start with broken context.yaml = %s, %v; want no output and no error", output, err)
+	}
+}
@@ -237,12 +237,12 @@ func TestHookWorkflowIntegration(t *testing.T) {
 	// Verify all log files were created
 	t.Run("verify_log_files", func(t *testing.T) {
 		expectedLogs := []string{
-			"session_start.json",
-			"user_prompt_submit.json",
-			"pre_tool_use.json",
-			"post_tool_use.json",
-			"notification.json",
-			"session_end.json",
+			"session_start.jsonl",
+			"user_prompt_submit.jsonl",
+			"pre_tool_use.jsonl",
+			"post_tool_use.jsonl",
+			"notification.jsonl",
+			"session_end.jsonl",
 		}
 
 		for _, logFile := range expectedLogs {
@@ -1,13 +1,31 @@
 package hooks
 
 import (
+	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+const (
+	// LogFileExt is the extension of JSON Lines hook logs
+	LogFileExt = ".jsonl"
+	// segmentTimeFormat stamps rotated segments so names sort chronologically
+	segmentTimeFormat = "20060102T150405.000000000"
+	// rotateLockName serializes rotation across hook processes
+	rotateLockName = "rotate.lock"
 )
 
 // HookEvent represents a logged hook event
@@ -19,14 +37,61 @@ type HookEvent struct {
 	Success   bool        `json:"success"`
 }
 
-// HookLogger handles logging of hook events
+// LogOptions controls rotation of hook log files
+type LogOptions struct {
+	// MaxSize rotates the active log once it reaches this many bytes
+	MaxSize int64
+	// MaxAge rotates the active log once its first event is this old
+	MaxAge time.Duration
+	// MaxSegments is how many rotated segments to keep per hook; 0 keeps all
+	MaxSegments int
+	// Compress gzips rotated segments
+	Compress bool
+}
+
+// DefaultLogOptions returns the rotation settings of DefaultLogger. Every
+// segment is kept, since replay reads the whole history.
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		MaxSize:     10 << 20,
+		MaxAge:      7 * 24 * time.Hour,
+		MaxSegments: 0,
+		Compress:    true,
+	}
+}
+
+// logOptions converts the logs settings into rotation options
+func logOptions(settings config.LogSettings) LogOptions {
+	return LogOptions{
+		MaxSize:     int64(settings.MaxSizeMB) << 20,
+		MaxAge:      settings.MaxAge,
+		MaxSegments: settings.MaxSegments,
+		Compress:    settings.Compress,
+	}
+}
+
+// HookLogger appends hook events to .spcstr/logs/<hook>.jsonl, one JSON
+// object per line, and rotates the file into timestamped segments
 type HookLogger struct {
-	mu sync.Mutex
+	mu   sync.Mutex
+	opts LogOptions
 }
 
 // NewHookLogger creates a new HookLogger instance
 func NewHookLogger() *HookLogger {
-	return &HookLogger{}
+	return NewHookLoggerWithOptions(DefaultLogOptions())
+}
+
+// NewHookLoggerWithOptions creates a HookLogger with custom rotation settings
+func NewHookLoggerWithOptions(opts LogOptions) *HookLogger {
+	return &HookLogger{opts: opts}
+}
+
+// SetOptions replaces the rotation settings
+func (l *HookLogger) SetOptions(opts LogOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.opts = opts
 }
 
 // LogEvent logs a hook event to the appropriate log file
@@ -42,64 +107,212 @@ func (l *HookLogger) LogEvent(sessionID, hookName string, inputData interface{},
 		Success:   success,
 	}
 
-	logPath := filepath.Join(".spcstr", "logs", fmt.Sprintf("%s.json", hookName))
+	logsDir := filepath.Join(".spcstr", "logs")
 
 	// Create logs directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create logs directory: %w", err)
 	}
 
-	return l.appendToLogFile(logPath, event)
+	if err := l.rotateIfNeeded(logsDir, hookName, event.Timestamp); err != nil {
+		return err
+	}
+
+	return appendLogLine(filepath.Join(logsDir, hookName+LogFileExt), event)
 }
 
-// appendToLogFile appends an event to the log file in a thread-safe manner
-func (l *HookLogger) appendToLogFile(logPath string, event HookEvent) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// appendLogLine appends event as a single line. One write per event keeps
+// lines from concurrent hook processes from interleaving.
+func appendLogLine(logPath string, event HookEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log event: %w", err)
+	}
+	line = append(line, '\n')
+
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append log event: %w", err)
+	}
+	return file.Close()
+}
+
+// rotateIfNeeded moves the active log into a segment once it is too large
+// or too old. Rotation runs under a cross-process lock and re-checks the
+// log once held, so concurrent hooks rotate it only once.
+func (l *HookLogger) rotateIfNeeded(logsDir, hookName string, now time.Time) error {
+	logPath := filepath.Join(logsDir, hookName+LogFileExt)
+	if due, err := l.rotationDue(logPath, now); err != nil || !due {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), state.DefaultTimeout)
 	defer cancel()
+	lock, err := state.AcquireFileLock(ctx, filepath.Join(logsDir, rotateLockName))
+	if err != nil {
+		return fmt.Errorf("failed to lock log rotation: %w", err)
+	}
+	defer lock.Release()
 
-	// Create temporary file for atomic write
-	tempFile := logPath + ".tmp"
+	// Another process may have rotated while we waited
+	if due, err := l.rotationDue(logPath, now); err != nil || !due {
+		return err
+	}
 
-	// Read existing events
-	var existingEvents []HookEvent
-	if data, err := os.ReadFile(logPath); err == nil {
-		if err := json.Unmarshal(data, &existingEvents); err != nil {
-			return fmt.Errorf("failed to parse existing log file: %w", err)
+	segmentPath := segmentName(logsDir, hookName, now)
+	if err := os.Rename(logPath, segmentPath); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if l.opts.Compress {
+		if err := compressFile(segmentPath); err != nil {
+			return err
 		}
 	}
 
-	// Append new event
-	existingEvents = append(existingEvents, event)
+	return pruneSegments(logsDir, hookName, l.opts.MaxSegments)
+}
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(existingEvents, "", "  ")
+// rotationDue reports whether the active log is too large or too old
+func (l *HookLogger) rotationDue(logPath string, now time.Time) (bool, error) {
+	info, err := os.Stat(logPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal log events: %w", err)
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat log file: %w", err)
 	}
 
-	// Write to temp file with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- os.WriteFile(tempFile, data, 0644)
-	}()
-
-	select {
-	case err := <-done:
-		if err != nil {
-			return fmt.Errorf("failed to write temp log file: %w", err)
+	if l.opts.MaxSize > 0 && info.Size() >= l.opts.MaxSize {
+		return true, nil
+	}
+	if l.opts.MaxAge > 0 {
+		if first, ok := firstEventTime(logPath); ok && now.Sub(first) >= l.opts.MaxAge {
+			return true, nil
 		}
-	case <-ctx.Done():
-		return fmt.Errorf("log file write timeout")
+	}
+	return false, nil
+}
+
+// segmentName returns the path of a rotated segment stamped with t
+func segmentName(logsDir, hookName string, t time.Time) string {
+	return filepath.Join(logsDir, fmt.Sprintf("%s.%s%s", hookName, t.UTC().Format(segmentTimeFormat), LogFileExt))
+}
+
+// firstEventTime reads the timestamp of the first event in a log file
+func firstEventTime(logPath string) (time.Time, bool) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return time.Time{}, false
+	}
+	var event struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil || event.Timestamp.IsZero() {
+		return time.Time{}, false
+	}
+	return event.Timestamp, true
+}
+
+// compressFile gzips path into path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log segment: %w", err)
+	}
+	defer src.Close()
+
+	tempPath := path + ".gz.tmp"
+	dst, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed segment: %w", err)
 	}
 
-	// Atomic rename
-	if err := os.Rename(tempFile, logPath); err != nil {
-		os.Remove(tempFile) // Cleanup temp file on error
-		return fmt.Errorf("failed to rename temp log file: %w", err)
+	zw := gzip.NewWriter(dst)
+	_, copyErr := io.Copy(zw, src)
+	closeErr := zw.Close()
+	if err := errors.Join(copyErr, closeErr, dst.Close()); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to compress log segment: %w", err)
 	}
 
+	if err := os.Rename(tempPath, path+".gz"); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename compressed segment: %w", err)
+	}
+	return os.Remove(path)
+}
+
+// pruneSegments deletes the oldest rotated segments beyond keep
+func pruneSegments(logsDir, hookName string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	segments, err := rotatedSegments(logsDir, hookName)
+	if err != nil {
+		return err
+	}
+	for len(segments) > keep {
+		if err := os.Remove(segments[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old log segment: %w", err)
+		}
+		segments = segments[1:]
+	}
 	return nil
 }
 
+// rotatedSegments lists a hook's rotated segments, oldest first
+func rotatedSegments(logsDir, hookName string) ([]string, error) {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+
+	var segments []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if hook, stamp, ok := parseSegmentName(entry.Name()); ok && hook == hookName && stamp != "" {
+			segments = append(segments, filepath.Join(logsDir, entry.Name()))
+		}
+	}
+	// The fixed-width stamp makes name order chronological
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// parseSegmentName splits a log file name into hook name and rotation stamp.
+// The active log has an empty stamp.
+func parseSegmentName(name string) (hook, stamp string, ok bool) {
+	name = strings.TrimSuffix(name, ".gz")
+	if !strings.HasSuffix(name, LogFileExt) {
+		return "", "", false
+	}
+	name = strings.TrimSuffix(name, LogFileExt)
+
+	hook, stamp, found := strings.Cut(name, ".")
+	if !found {
+		return hook, "", hook != ""
+	}
+	if _, err := time.Parse(segmentTimeFormat, stamp); err != nil {
+		return "", "", false
+	}
+	return hook, stamp, hook != ""
+}
+
 // DefaultLogger is the global hook logger instance
 var DefaultLogger = NewHookLogger()
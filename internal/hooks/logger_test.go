@@ -0,0 +1,226 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// chdirTemp changes into a fresh temp directory for the rest of the test
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(oldDir) })
+	return filepath.Join(dir, ".spcstr", "logs")
+}
+
+// readSessions collects the session IDs of a hook's logged events in order
+func readSessions(t *testing.T, logsDir, hookName string) []string {
+	t.Helper()
+	var sessions []string
+	err := ReadEvents(logsDir, hookName, func(event HookEvent) error {
+		sessions = append(sessions, event.SessionID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadEvents() error: %v", err)
+	}
+	return sessions
+}
+
+func TestHookLoggerRotation(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         LogOptions
+		wantSegments int
+		wantExt      string
+	}{
+		{name: "plain segments", opts: LogOptions{MaxSize: 200}, wantSegments: 9, wantExt: LogFileExt},
+		{name: "compressed segments", opts: LogOptions{MaxSize: 200, Compress: true}, wantSegments: 9, wantExt: ".gz"},
+		{name: "pruned segments", opts: LogOptions{MaxSize: 200, MaxSegments: 2}, wantSegments: 2, wantExt: LogFileExt},
+		{name: "no rotation", opts: LogOptions{}, wantSegments: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsDir := chdirTemp(t)
+			logger := NewHookLoggerWithOptions(tt.opts)
+
+			const events = 10
+			var want []string
+			for i := 0; i < events; i++ {
+				sessionID := fmt.Sprintf("session-%02d", i)
+				want = append(want, sessionID)
+				if err := logger.LogEvent(sessionID, "stop", map[string]interface{}{"padding": strings.Repeat("x", 150)}, true); err != nil {
+					t.Fatalf("LogEvent() error: %v", err)
+				}
+			}
+
+			segments, err := rotatedSegments(logsDir, "stop")
+			if err != nil {
+				t.Fatalf("rotatedSegments() error: %v", err)
+			}
+			if len(segments) != tt.wantSegments {
+				t.Fatalf("got %d segments, want %d: %v", len(segments), tt.wantSegments, segments)
+			}
+			for _, segment := range segments {
+				if !strings.HasSuffix(segment, tt.wantExt) {
+					t.Errorf("segment %s does not end in %s", segment, tt.wantExt)
+				}
+			}
+
+			// Pruning drops the oldest events; the rest read back in order
+			got := readSessions(t, logsDir, "stop")
+			want = want[len(want)-len(got):]
+			if tt.opts.MaxSegments == 0 && len(got) != events {
+				t.Fatalf("read %d events, want %d", len(got), events)
+			}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("events read = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestHookLoggerRotationWaitsForLock(t *testing.T) {
+	logsDir := chdirTemp(t)
+	logPath := filepath.Join(logsDir, "stop"+LogFileExt)
+	logger := NewHookLoggerWithOptions(LogOptions{MaxSize: 400})
+	padding := map[string]interface{}{"padding": strings.Repeat("x", 150)}
+	for _, sessionID := range []string{"a", "b"} {
+		if err := logger.LogEvent(sessionID, "stop", padding, true); err != nil {
+			t.Fatalf("LogEvent() error: %v", err)
+		}
+	}
+
+	// Another process holds the rotation lock
+	lock, err := state.AcquireFileLock(context.Background(), filepath.Join(logsDir, rotateLockName))
+	if err != nil {
+		t.Fatalf("AcquireFileLock() error: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- NewHookLoggerWithOptions(LogOptions{MaxSize: 400}).LogEvent("c", "stop", padding, true)
+	}()
+
+	// ... and rotates the full log while the second logger waits
+	time.Sleep(100 * time.Millisecond)
+	if err := os.Rename(logPath, segmentName(logsDir, "stop", time.Now())); err != nil {
+		t.Fatalf("Failed to rotate log: %v", err)
+	}
+	if err := appendLogLine(logPath, HookEvent{Timestamp: time.Now(), SessionID: "other", HookName: "stop"}); err != nil {
+		t.Fatalf("appendLogLine() error: %v", err)
+	}
+	lock.Release()
+
+	if err := <-done; err != nil {
+		t.Fatalf("LogEvent() error: %v", err)
+	}
+	if segments, _ := rotatedSegments(logsDir, "stop"); len(segments) != 1 {
+		t.Errorf("got %d segments, want the fresh log left unrotated", len(segments))
+	}
+	if got := readSessions(t, logsDir, "stop"); strings.Join(got, ",") != "a,b,other,c" {
+		t.Errorf("events read = %v, want [a b other c]", got)
+	}
+}
+
+func TestHookLoggerRotatesByAge(t *testing.T) {
+	logsDir := chdirTemp(t)
+	os.MkdirAll(logsDir, 0755)
+
+	old := HookEvent{Timestamp: time.Now().Add(-2 * time.Hour), SessionID: "old", HookName: "stop"}
+	if err := appendLogLine(filepath.Join(logsDir, "stop"+LogFileExt), old); err != nil {
+		t.Fatalf("appendLogLine() error: %v", err)
+	}
+
+	logger := NewHookLoggerWithOptions(LogOptions{MaxAge: time.Hour})
+	if err := logger.LogEvent("new", "stop", nil, true); err != nil {
+		t.Fatalf("LogEvent() error: %v", err)
+	}
+
+	segments, _ := rotatedSegments(logsDir, "stop")
+	if len(segments) != 1 {
+		t.Fatalf("got %d segments, want 1", len(segments))
+	}
+	if got := readSessions(t, logsDir, "stop"); strings.Join(got, ",") != "old,new" {
+		t.Errorf("events read = %v, want [old new]", got)
+	}
+}
+
+func TestReadEventsSkipsCorruptLines(t *testing.T) {
+	logsDir := t.TempDir()
+	content := `{"session_id": "a", "hook_name": "stop"}` + "\n" +
+		`not json` + "\n" +
+		`{"session_id": "b", "hook_name": "stop"}` + "\n" +
+		`{"session_id": "c", "hook_na`
+	os.WriteFile(filepath.Join(logsDir, "stop"+LogFileExt), []byte(content), 0644)
+
+	if got := readSessions(t, logsDir, "stop"); strings.Join(got, ",") != "a,b" {
+		t.Errorf("events read = %v, want [a b]", got)
+	}
+
+	// ErrStopReading ends the scan without an error
+	count := 0
+	err := ReadEvents(logsDir, "stop", func(HookEvent) error {
+		count++
+		return ErrStopReading
+	})
+	if err != nil || count != 1 {
+		t.Errorf("early stop = %d events, %v; want 1, nil", count, err)
+	}
+}
+
+func TestConvertLegacyLog(t *testing.T) {
+	logsDir := t.TempDir()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	legacy := []HookEvent{
+		{Timestamp: start, SessionID: "legacy-1", HookName: "stop"},
+		{Timestamp: start.Add(time.Minute), SessionID: "legacy-2", HookName: "stop"},
+	}
+	data, _ := json.MarshalIndent(legacy, "", "  ")
+	os.WriteFile(filepath.Join(logsDir, "stop.json"), data, 0644)
+	os.WriteFile(filepath.Join(logsDir, "notification.json"), []byte(`[{"session_id": `), 0644)
+
+	// Events logged after the upgrade but before conversion
+	appendLogLine(filepath.Join(logsDir, "stop"+LogFileExt), HookEvent{Timestamp: time.Now(), SessionID: "current", HookName: "stop"})
+
+	hooks, err := LegacyLogs(logsDir)
+	if err != nil || strings.Join(hooks, ",") != "notification,stop" {
+		t.Fatalf("LegacyLogs() = %v, %v", hooks, err)
+	}
+
+	converted, err := ConvertLegacyLog(logsDir, "stop")
+	if err != nil || converted != 2 {
+		t.Fatalf("ConvertLegacyLog() = %d, %v; want 2", converted, err)
+	}
+	if _, err := os.Stat(filepath.Join(logsDir, "stop.json.bak")); err != nil {
+		t.Errorf("legacy log not backed up: %v", err)
+	}
+	if got := readSessions(t, logsDir, "stop"); strings.Join(got, ",") != "legacy-1,legacy-2,current" {
+		t.Errorf("events read = %v, want legacy events first", got)
+	}
+
+	// A corrupt legacy log is reported and left in place
+	if _, err := ConvertLegacyLog(logsDir, "notification"); err == nil {
+		t.Error("ConvertLegacyLog() accepted a corrupt log")
+	}
+	if _, err := os.Stat(filepath.Join(logsDir, "notification.json")); err != nil {
+		t.Errorf("corrupt legacy log was moved: %v", err)
+	}
+
+	if hooks, _ := LoggedHooks(logsDir); strings.Join(hooks, ",") != "stop" {
+		t.Errorf("LoggedHooks() = %v, want [stop]", hooks)
+	}
+}
@@ -0,0 +1,208 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// legacyLogExt is the extension of the old array-format hook logs
+const legacyLogExt = ".json"
+
+// ErrStopReading can be returned by a ReadEvents callback to end the scan
+// early without an error
+var ErrStopReading = errors.New("stop reading hook log")
+
+// LogSegments returns the files holding a hook's events, oldest first: the
+// rotated segments followed by the active log
+func LogSegments(logsDir, hookName string) ([]string, error) {
+	segments, err := rotatedSegments(logsDir, hookName)
+	if err != nil {
+		return nil, err
+	}
+	active := filepath.Join(logsDir, hookName+LogFileExt)
+	if _, err := os.Stat(active); err == nil {
+		segments = append(segments, active)
+	}
+	return segments, nil
+}
+
+// LoggedHooks returns the names of hooks with JSON Lines logs in logsDir
+func LoggedHooks(logsDir string) ([]string, error) {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var hooks []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if hook, _, ok := parseSegmentName(entry.Name()); ok && !seen[hook] {
+			seen[hook] = true
+			hooks = append(hooks, hook)
+		}
+	}
+	sort.Strings(hooks)
+	return hooks, nil
+}
+
+// ReadEvents streams a hook's logged events, oldest first, across rotated
+// and compressed segments. Lines that are not valid events, such as a write
+// cut short by a crash, are skipped. Returning ErrStopReading from fn ends
+// the scan early.
+func ReadEvents(logsDir, hookName string, fn func(HookEvent) error) error {
+	segments, err := LogSegments(logsDir, hookName)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range segments {
+		if err := readSegment(path, fn); err != nil {
+			if errors.Is(err, ErrStopReading) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// readSegment feeds every event in one segment file to fn
+func readSegment(path string, fn func(HookEvent) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Pruned or compressed by a concurrent rotation
+			return nil
+		}
+		return fmt.Errorf("failed to open log segment: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to open compressed log segment %s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	// Events embed tool input and output, so lines can be large
+	br := bufio.NewReader(r)
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var event HookEvent
+			if err := json.Unmarshal(line, &event); err == nil {
+				if err := fn(event); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read log segment %s: %w", path, readErr)
+		}
+	}
+}
+
+// LegacyLogs returns the hooks that still have an array-format
+// <hook>.json log in logsDir
+func LegacyLogs(logsDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(logsDir, "*"+legacyLogExt))
+	if err != nil {
+		return nil, err
+	}
+	var hooks []string
+	for _, match := range matches {
+		hooks = append(hooks, strings.TrimSuffix(filepath.Base(match), legacyLogExt))
+	}
+	sort.Strings(hooks)
+	return hooks, nil
+}
+
+// ConvertLegacyLog rewrites a hook's array-format log as a JSON Lines
+// segment ordered before any events logged since, and renames the original
+// to <hook>.json.bak. It returns the number of events converted.
+func ConvertLegacyLog(logsDir, hookName string) (int, error) {
+	legacyPath := filepath.Join(logsDir, hookName+legacyLogExt)
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read legacy log: %w", err)
+	}
+
+	var events []HookEvent
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &events); err != nil {
+			return 0, fmt.Errorf("failed to parse legacy log %s: %w", legacyPath, err)
+		}
+	}
+
+	if len(events) > 0 {
+		// Stamp the segment with the last legacy event so it sorts before
+		// segments rotated after the upgrade
+		stamp := events[len(events)-1].Timestamp
+		if stamp.IsZero() {
+			if info, err := os.Stat(legacyPath); err == nil {
+				stamp = info.ModTime()
+			}
+		}
+		segmentPath := segmentName(logsDir, hookName, stamp)
+		for {
+			if _, err := os.Stat(segmentPath); os.IsNotExist(err) {
+				break
+			}
+			stamp = stamp.Add(time.Nanosecond)
+			segmentPath = segmentName(logsDir, hookName, stamp)
+		}
+
+		if err := writeSegment(segmentPath, events); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := os.Rename(legacyPath, legacyPath+".bak"); err != nil {
+		return 0, fmt.Errorf("failed to back up legacy log: %w", err)
+	}
+	return len(events), nil
+}
+
+// writeSegment writes events as JSON Lines to a new segment file
+func writeSegment(path string, events []HookEvent) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode log event: %w", err)
+		}
+	}
+
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write log segment: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename log segment: %w", err)
+	}
+	return nil
+}
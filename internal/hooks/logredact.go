@@ -0,0 +1,121 @@
+package hooks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/redact"
+)
+
+// RedactLogs scrubs secrets from every hook log in logsDir in place:
+// active and rotated JSON Lines segments, compressed segments and
+// array-format logs with their backups. It returns the number of secrets
+// found; with dryRun set nothing is rewritten.
+func RedactLogs(logsDir string, redactor *redact.Redactor, dryRun bool) (redact.Counts, error) {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return redact.Counts{}, nil
+		}
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+
+	total := redact.Counts{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isLogFile(name) {
+			continue
+		}
+		counts, err := redactLogFile(filepath.Join(logsDir, name), redactor, dryRun)
+		if err != nil {
+			return total, err
+		}
+		total.Add(counts)
+	}
+	return total, nil
+}
+
+// isLogFile reports whether name is a hook log RedactLogs rewrites
+func isLogFile(name string) bool {
+	if _, _, ok := parseSegmentName(name); ok {
+		return true
+	}
+	return strings.HasSuffix(name, legacyLogExt) || strings.HasSuffix(name, legacyLogExt+".bak")
+}
+
+// redactLogFile rewrites one log file with its secrets replaced
+func redactLogFile(path string, redactor *redact.Redactor, dryRun bool) (redact.Counts, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log %s: %w", path, err)
+	}
+
+	compressed := strings.HasSuffix(path, ".gz")
+	if compressed {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open compressed log %s: %w", path, err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress log %s: %w", path, err)
+		}
+	}
+
+	var redacted []byte
+	var counts redact.Counts
+	if strings.HasSuffix(strings.TrimSuffix(path, ".gz"), LogFileExt) {
+		redacted, counts = redactLines(data, redactor)
+	} else {
+		redacted, counts = redactInput(redactor, data)
+	}
+	if counts.Total() == 0 || dryRun {
+		return counts, nil
+	}
+
+	if compressed {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, writeErr := zw.Write(redacted)
+		if err := errors.Join(writeErr, zw.Close()); err != nil {
+			return nil, fmt.Errorf("failed to compress log %s: %w", path, err)
+		}
+		redacted = buf.Bytes()
+	}
+
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, redacted, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write redacted log: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to rename redacted log: %w", err)
+	}
+	return counts, nil
+}
+
+// redactLines redacts each line of a JSON Lines log on its own, so one
+// corrupt line does not stop the rest from being scrubbed
+func redactLines(data []byte, redactor *redact.Redactor) ([]byte, redact.Counts) {
+	total := redact.Counts{}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var buf bytes.Buffer
+	for _, line := range lines {
+		content := bytes.TrimRight(line, "\n")
+		if len(bytes.TrimSpace(content)) == 0 {
+			buf.Write(line)
+			continue
+		}
+		redacted, counts := redactInput(redactor, content)
+		total.Add(counts)
+		buf.Write(redacted)
+		buf.Write(line[len(content):])
+	}
+	return buf.Bytes(), total
+}
@@ -0,0 +1,247 @@
+package hooks
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/schema"
+	"github.com/dylan/spcstr/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// MiddlewareFileName is the middleware settings file in the .spcstr directory
+const MiddlewareFileName = "middleware.yaml"
+
+// HandlerFunc executes a hook and returns any JSON output for Claude Code
+type HandlerFunc func(hookName string, input []byte) ([]byte, error)
+
+// Middleware wraps hook execution with behavior shared by every hook
+type Middleware interface {
+	Name() string
+	Wrap(next HandlerFunc) HandlerFunc
+}
+
+// Chain wraps handler in middleware. The first middleware is the outermost
+// and sees the call first.
+func Chain(handler HandlerFunc, middleware ...Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i].Wrap(handler)
+	}
+	return handler
+}
+
+// MiddlewareConfig selects the middleware applied around every hook
+type MiddlewareConfig struct {
+	// Middleware names in order, outermost first
+	Middleware []string `yaml:"middleware"`
+}
+
+// DefaultMiddlewareConfig returns the settings used when no middleware.yaml
+// exists: every built-in middleware is on
+func DefaultMiddlewareConfig() MiddlewareConfig {
+	return MiddlewareConfig{Middleware: []string{"recover", "timing", "validate"}}
+}
+
+// LoadMiddlewareConfig reads middleware.yaml over the defaults. A missing
+// file yields the defaults; an empty list turns all middleware off.
+func LoadMiddlewareConfig(path string) (MiddlewareConfig, error) {
+	cfg := DefaultMiddlewareConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read middleware config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse middleware config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// BuildMiddleware creates the named built-in middleware for the project
+// whose .spcstr directory is basePath
+func BuildMiddleware(names []string, basePath string) ([]Middleware, error) {
+	middleware := make([]Middleware, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("middleware %q is listed twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "recover":
+			middleware = append(middleware, RecoverMiddleware{})
+		case "timing":
+			middleware = append(middleware, NewTimingMiddleware(basePath))
+		case "validate":
+			middleware = append(middleware, ValidateMiddleware{})
+		default:
+			return nil, fmt.Errorf("unknown middleware %q (want recover, timing or validate)", name)
+		}
+	}
+	return middleware, nil
+}
+
+// DefaultMiddleware builds the default middleware chain, the fallback when
+// middleware.yaml cannot be used
+func DefaultMiddleware(basePath string) []Middleware {
+	middleware, _ := BuildMiddleware(DefaultMiddlewareConfig().Middleware, basePath)
+	return middleware
+}
+
+// LoadMiddleware builds the middleware configured in basePath/middleware.yaml
+func LoadMiddleware(basePath string) ([]Middleware, error) {
+	cfg, err := LoadMiddlewareConfig(filepath.Join(basePath, MiddlewareFileName))
+	if err != nil {
+		return nil, err
+	}
+	return BuildMiddleware(cfg.Middleware, basePath)
+}
+
+// RecoverMiddleware turns a panicking handler into a non-blocking error, so
+// a bug in spcstr never blocks Claude Code
+type RecoverMiddleware struct{}
+
+// Name returns the middleware name used in middleware.yaml
+func (RecoverMiddleware) Name() string {
+	return "recover"
+}
+
+// Wrap recovers panics raised by next
+func (RecoverMiddleware) Wrap(next HandlerFunc) HandlerFunc {
+	return func(hookName string, input []byte) (output []byte, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintf(os.Stderr, "spcstr bug: hook %s panicked: %v\n%s", hookName, r, debug.Stack())
+				output = nil
+				err = &events.NonBlockingError{Err: fmt.Errorf("hook %s panicked: %v", hookName, r)}
+			}
+		}()
+		return next(hookName, input)
+	}
+}
+
+// TimingMiddleware records how long each hook takes in the session's
+// hook_timings
+type TimingMiddleware struct {
+	basePath string
+}
+
+// NewTimingMiddleware creates a TimingMiddleware for the .spcstr directory
+// at basePath
+func NewTimingMiddleware(basePath string) *TimingMiddleware {
+	return &TimingMiddleware{basePath: basePath}
+}
+
+// Name returns the middleware name used in middleware.yaml
+func (m *TimingMiddleware) Name() string {
+	return "timing"
+}
+
+// Wrap measures next and records the duration once it returns
+func (m *TimingMiddleware) Wrap(next HandlerFunc) HandlerFunc {
+	return func(hookName string, input []byte) ([]byte, error) {
+		start := time.Now()
+		output, err := next(hookName, input)
+		m.record(hookName, input, time.Since(start), err == nil)
+		return output, err
+	}
+}
+
+// record stores a timing for sessions that exist; failures only warn
+func (m *TimingMiddleware) record(hookName string, input []byte, duration time.Duration, success bool) {
+	var header struct {
+		SessionID string `json:"session_id"`
+	}
+	if json.Unmarshal(input, &header) != nil || header.SessionID == "" {
+		return
+	}
+	if !dirExists(filepath.Join(m.basePath, "sessions", header.SessionID)) {
+		return
+	}
+
+	sm := state.NewStateManager(m.basePath)
+	err := sm.RecordHookTiming(context.Background(), header.SessionID, state.HookTimedData{
+		Hook:       hookName,
+		DurationMs: duration.Milliseconds(),
+		Success:    success,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to record hook timing: %v\n", err)
+	}
+}
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var (
+	schemasOnce sync.Once
+	schemas     map[string]*schema.Schema
+	schemasErr  error
+)
+
+// inputSchema returns the embedded JSON schema for a hook's input, if any
+func inputSchema(hookName string) (*schema.Schema, error) {
+	schemasOnce.Do(func() {
+		schemas = make(map[string]*schema.Schema)
+		entries, err := schemaFiles.ReadDir("schemas")
+		if err != nil {
+			schemasErr = err
+			return
+		}
+		for _, entry := range entries {
+			data, err := schemaFiles.ReadFile("schemas/" + entry.Name())
+			if err != nil {
+				schemasErr = err
+				return
+			}
+			s, err := schema.Parse(data)
+			if err != nil {
+				schemasErr = fmt.Errorf("schema %s: %w", entry.Name(), err)
+				return
+			}
+			schemas[strings.TrimSuffix(entry.Name(), ".json")] = s
+		}
+	})
+	return schemas[hookName], schemasErr
+}
+
+// ValidateMiddleware checks hook input against the hook's JSON schema and
+// skips the handler when it does not match. Invalid input is reported as a
+// non-blocking error, since it means spcstr and Claude Code disagree on the
+// event format rather than that the action should be stopped.
+type ValidateMiddleware struct{}
+
+// Name returns the middleware name used in middleware.yaml
+func (ValidateMiddleware) Name() string {
+	return "validate"
+}
+
+// Wrap validates input before calling next
+func (ValidateMiddleware) Wrap(next HandlerFunc) HandlerFunc {
+	return func(hookName string, input []byte) ([]byte, error) {
+		s, err := inputSchema(hookName)
+		if err != nil {
+			return nil, &events.NonBlockingError{Err: fmt.Errorf("failed to load input schemas: %w", err)}
+		}
+		if s != nil {
+			if err := s.Validate(input); err != nil {
+				return nil, &events.NonBlockingError{Err: fmt.Errorf("invalid %s input: %w", hookName, err)}
+			}
+		}
+		return next(hookName, input)
+	}
+}
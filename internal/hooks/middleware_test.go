@@ -0,0 +1,182 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// traceMiddleware appends its name to a shared trace around next
+type traceMiddleware struct {
+	name  string
+	trace *[]string
+}
+
+func (m traceMiddleware) Name() string {
+	return m.name
+}
+
+func (m traceMiddleware) Wrap(next HandlerFunc) HandlerFunc {
+	return func(hookName string, input []byte) ([]byte, error) {
+		*m.trace = append(*m.trace, m.name+">")
+		output, err := next(hookName, input)
+		*m.trace = append(*m.trace, "<"+m.name)
+		return output, err
+	}
+}
+
+// panicHandler panics on every call
+type panicHandler struct{}
+
+func (panicHandler) Name() string {
+	return "panic_hook"
+}
+
+func (panicHandler) Execute(input []byte) error {
+	panic("boom")
+}
+
+func TestChainOrder(t *testing.T) {
+	var trace []string
+	registry := NewRegistry()
+	registry.Register(&mockHandler{name: "test_hook"})
+	run := Chain(registry.ExecuteWithOutput, traceMiddleware{"outer", &trace}, traceMiddleware{"inner", &trace})
+
+	if _, err := run("test_hook", []byte(`{}`)); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if got := strings.Join(trace, " "); got != "outer> inner> <inner <outer" {
+		t.Errorf("trace = %q", got)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(panicHandler{})
+	run := Chain(registry.ExecuteWithOutput, RecoverMiddleware{})
+
+	_, err := run("panic_hook", []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "panicked: boom") {
+		t.Fatalf("Execute() error = %v, want recovered panic", err)
+	}
+	if events.IsBlocking(err) {
+		t.Error("recovered panic must not block Claude Code")
+	}
+	if !events.IsBlocking(errors.New("policy denied")) {
+		t.Error("plain errors should block")
+	}
+}
+
+func TestValidateMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    string
+		input   string
+		wantErr string
+	}{
+		{name: "valid input", hook: "user_prompt_submit", input: `{"session_id":"s1","prompt":"hi"}`},
+		{name: "hook without schema", hook: "custom_hook", input: `{}`},
+		{name: "missing field", hook: "user_prompt_submit", input: `{"session_id":"s1"}`, wantErr: `missing required property "prompt"`},
+		{name: "wrong type", hook: "pre_tool_use", input: `{"session_id":"s1","tool_name":"Bash","tool_input":"ls"}`, wantErr: "$.tool_input: expected object, got string"},
+		{name: "empty session", hook: "stop", input: `{"session_id":""}`, wantErr: "$.session_id: shorter than 1 characters"},
+		{name: "not JSON", hook: "stop", input: `{`, wantErr: "not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := ValidateMiddleware{}.Wrap(func(hookName string, input []byte) ([]byte, error) {
+				called = true
+				return nil, nil
+			})
+
+			_, err := handler(tt.hook, []byte(tt.input))
+			if tt.wantErr == "" {
+				if err != nil || !called {
+					t.Errorf("error = %v, called = %v; want handler to run", err, called)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if called {
+				t.Error("handler ran on invalid input")
+			}
+			if events.IsBlocking(err) {
+				t.Error("invalid input must not block Claude Code")
+			}
+		})
+	}
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	basePath := t.TempDir()
+	ctx := context.Background()
+	sm := state.NewStateManager(basePath)
+	if _, err := sm.InitializeState(ctx, "timed_session"); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+
+	timing := NewTimingMiddleware(basePath)
+	ok := timing.Wrap(func(string, []byte) ([]byte, error) { return nil, nil })
+	failing := timing.Wrap(func(string, []byte) ([]byte, error) { return nil, errors.New("failed") })
+
+	input := []byte(`{"session_id":"timed_session"}`)
+	ok("stop", input)
+	failing("stop", input)
+	ok("stop", []byte(`{"session_id":"unknown_session"}`))
+
+	sessionState, err := sm.LoadState(ctx, "timed_session")
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	got := sessionState.HookTimings["stop"]
+	if got.Count != 2 || got.Failures != 1 {
+		t.Errorf("stop timing = %+v, want 2 runs with 1 failure", got)
+	}
+	if _, err := os.Stat(filepath.Join(basePath, "sessions", "unknown_session")); !os.IsNotExist(err) {
+		t.Error("timing created a session that did not exist")
+	}
+}
+
+func TestLoadMiddleware(t *testing.T) {
+	basePath := t.TempDir()
+	path := filepath.Join(basePath, MiddlewareFileName)
+
+	names := func(middleware []Middleware) string {
+		var out []string
+		for _, m := range middleware {
+			out = append(out, m.Name())
+		}
+		return strings.Join(out, ",")
+	}
+
+	middleware, err := LoadMiddleware(basePath)
+	if err != nil || names(middleware) != "recover,timing,validate" {
+		t.Errorf("default middleware = %q, %v", names(middleware), err)
+	}
+
+	os.WriteFile(path, []byte("middleware: [validate, recover]\n"), 0644)
+	if middleware, err = LoadMiddleware(basePath); err != nil || names(middleware) != "validate,recover" {
+		t.Errorf("configured middleware = %q, %v", names(middleware), err)
+	}
+
+	os.WriteFile(path, []byte("middleware: []\n"), 0644)
+	if middleware, err = LoadMiddleware(basePath); err != nil || len(middleware) != 0 {
+		t.Errorf("empty middleware = %q, %v", names(middleware), err)
+	}
+
+	for _, bad := range []string{"middleware: [metrics]\n", "middleware: [recover, recover]\n"} {
+		os.WriteFile(path, []byte(bad), 0644)
+		if _, err := LoadMiddleware(basePath); err == nil {
+			t.Errorf("LoadMiddleware() accepted %q", bad)
+		}
+	}
+}
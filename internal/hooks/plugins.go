@@ -0,0 +1,146 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/plugin"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// hookEventNames maps hook command names to Claude Code event names
+var hookEventNames = map[string]string{
+	"session_start":      "SessionStart",
+	"user_prompt_submit": "UserPromptSubmit",
+	"pre_tool_use":       "PreToolUse",
+	"post_tool_use":      "PostToolUse",
+	"notification":       "Notification",
+	"pre_compact":        "PreCompact",
+	"session_end":        "SessionEnd",
+	"stop":               "Stop",
+	"subagent_stop":      "SubagentStop",
+}
+
+// contextHooks are the events Claude Code accepts additional context from
+var contextHooks = map[string]bool{
+	"session_start":      true,
+	"user_prompt_submit": true,
+	"post_tool_use":      true,
+}
+
+// runPlugins runs the plugins configured in .spcstr/plugins.yaml for a hook
+// after its built-in handler, in order, and folds their results into the
+// handler's output. The working directory must be the project root.
+func runPlugins(hookName, sessionID string, input, output []byte) ([]byte, error) {
+	// Like middleware.yaml, a broken plugins.yaml must not stop every hook
+	cfg, err := plugin.LoadConfig(filepath.Join(".spcstr", plugin.FileName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; running no plugins\n", err)
+		return output, nil
+	}
+	plugins := cfg.ForHook(hookName)
+	if len(plugins) == 0 {
+		return output, nil
+	}
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return output, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	var reply events.HookOutput
+	if len(output) > 0 {
+		if err := json.Unmarshal(output, &reply); err != nil {
+			return output, fmt.Errorf("failed to decode %s output: %w", hookName, err)
+		}
+	}
+
+	ctx := context.Background()
+	changed := false
+	for _, p := range plugins {
+		result, err := plugin.Run(ctx, projectDir, hookName, p, input)
+		if err == nil && len(result.State) > 0 && sessionID != "" {
+			sm := state.NewStateManager(filepath.Join(projectDir, ".spcstr"))
+			if patchErr := sm.PatchPluginState(ctx, sessionID, p.Name, result.State); patchErr != nil {
+				err = fmt.Errorf("failed to save state of plugin %s: %w", p.Name, patchErr)
+			}
+		}
+		if err != nil {
+			switch p.OnFailure {
+			case plugin.FailBlock:
+				return nil, err
+			case plugin.FailWarn:
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			continue
+		}
+		if mergePluginResult(hookName, p.Name, result, &reply) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return output, nil
+	}
+	merged, err := json.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s output: %w", hookName, err)
+	}
+	return merged, nil
+}
+
+// mergePluginResult adds a plugin's decision and context to the hook reply
+// and reports whether the reply changed. A block from any plugin wins over
+// the built-in handler's decision.
+func mergePluginResult(hookName, pluginName string, result plugin.Result, reply *events.HookOutput) bool {
+	changed := false
+
+	if result.Blocks() {
+		reason := result.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("blocked by plugin %s", pluginName)
+		}
+		if hookName == "pre_tool_use" {
+			specific := specificOutput(hookName, reply)
+			if specific.PermissionDecision == "deny" {
+				specific.PermissionDecisionReason = joinText(specific.PermissionDecisionReason, reason)
+			} else {
+				specific.PermissionDecision = "deny"
+				specific.PermissionDecisionReason = reason
+			}
+		} else {
+			reply.Decision = "block"
+			reply.Reason = joinText(reply.Reason, reason)
+		}
+		changed = true
+	}
+
+	if result.AdditionalContext != "" && contextHooks[hookName] {
+		specific := specificOutput(hookName, reply)
+		specific.AdditionalContext = joinText(specific.AdditionalContext, result.AdditionalContext)
+		changed = true
+	}
+
+	return changed
+}
+
+// specificOutput returns the reply's hook-specific section, creating it
+func specificOutput(hookName string, reply *events.HookOutput) *events.HookSpecificOutput {
+	if reply.HookSpecificOutput == nil {
+		reply.HookSpecificOutput = &events.HookSpecificOutput{HookEventName: hookEventNames[hookName]}
+	}
+	return reply.HookSpecificOutput
+}
+
+// joinText appends next to existing as a new paragraph
+func joinText(existing, next string) string {
+	if strings.TrimSpace(existing) == "" {
+		return next
+	}
+	return existing + "\n\n" + next
+}
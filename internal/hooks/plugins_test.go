@@ -0,0 +1,134 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dylan/spcstr/internal/hooks/events"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// outputHandler replies with fixed JSON output
+type outputHandler struct {
+	name   string
+	output []byte
+}
+
+func (h *outputHandler) Name() string {
+	return h.name
+}
+
+func (h *outputHandler) Execute(input []byte) error {
+	return nil
+}
+
+func (h *outputHandler) ExecuteWithOutput(input []byte) ([]byte, error) {
+	return h.output, nil
+}
+
+func TestExecuteHookRunsPlugins(t *testing.T) {
+	tempDir := t.TempDir()
+	spcstrDir := filepath.Join(tempDir, ".spcstr")
+	os.MkdirAll(filepath.Join(spcstrDir, "sessions"), 0755)
+	os.MkdirAll(filepath.Join(spcstrDir, "logs"), 0755)
+
+	ctx := context.Background()
+	sm := state.NewStateManager(spcstrDir)
+	if _, err := sm.InitializeState(ctx, "plugin_session"); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+
+	testRegistry := NewRegistry()
+	testRegistry.Register(&outputHandler{name: "pre_tool_use", output: []byte(`{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"allow"}}`)})
+	testRegistry.Register(&outputHandler{name: "user_prompt_submit"})
+	testRegistry.Register(&outputHandler{name: "stop"})
+	oldRegistry := DefaultRegistry
+	DefaultRegistry = testRegistry
+	defer func() {
+		DefaultRegistry = oldRegistry
+	}()
+
+	config := `plugins:
+  - name: tracker
+    command: echo '{"state":{"ticket":"ABC-1"},"additional_context":"Ticket ABC-1 is open"}'
+    hooks: [user_prompt_submit]
+  - name: guard
+    command: echo '{"decision":"block","reason":"frozen"}'
+    hooks: [pre_tool_use]
+  - name: flaky
+    command: exit 1
+    hooks: [user_prompt_submit]
+    on_failure: ignore
+    order: -1
+  - name: strict
+    command: exit 1
+    hooks: [stop]
+    on_failure: block
+`
+	os.WriteFile(filepath.Join(spcstrDir, "plugins.yaml"), []byte(config), 0644)
+
+	input := []byte(`{"session_id":"plugin_session"}`)
+
+	output, err := ExecuteHookWithOutput("pre_tool_use", tempDir, []byte(`{"session_id":"plugin_session","tool_name":"Bash"}`))
+	if err != nil {
+		t.Fatalf("pre_tool_use error: %v", err)
+	}
+	var reply events.HookOutput
+	json.Unmarshal(output, &reply)
+	if reply.HookSpecificOutput == nil || reply.HookSpecificOutput.PermissionDecision != "deny" || reply.HookSpecificOutput.PermissionDecisionReason != "frozen" {
+		t.Errorf("pre_tool_use output = %s, want plugin deny", output)
+	}
+
+	output, err = ExecuteHookWithOutput("user_prompt_submit", tempDir, []byte(`{"session_id":"plugin_session","prompt":"hi"}`))
+	if err != nil {
+		t.Fatalf("user_prompt_submit error: %v", err)
+	}
+	reply = events.HookOutput{}
+	json.Unmarshal(output, &reply)
+	if reply.HookSpecificOutput == nil || reply.HookSpecificOutput.HookEventName != "UserPromptSubmit" || reply.HookSpecificOutput.AdditionalContext != "Ticket ABC-1 is open" {
+		t.Errorf("user_prompt_submit output = %s, want plugin context", output)
+	}
+	sessionState, err := sm.LoadState(ctx, "plugin_session")
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if got := string(sessionState.PluginData["tracker"]["ticket"]); got != `"ABC-1"` {
+		t.Errorf("tracker state ticket = %s", got)
+	}
+
+	if _, err := ExecuteHookWithOutput("stop", tempDir, input); err == nil || !strings.Contains(err.Error(), "plugin strict") {
+		t.Errorf("stop error = %v, want blocking plugin failure", err)
+	}
+}
+
+func TestExecuteHookBrokenPluginConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	spcstrDir := filepath.Join(tempDir, ".spcstr")
+	os.MkdirAll(filepath.Join(spcstrDir, "sessions"), 0755)
+	os.MkdirAll(filepath.Join(spcstrDir, "logs"), 0755)
+
+	testRegistry := NewRegistry()
+	testRegistry.Register(&outputHandler{name: "pre_tool_use", output: []byte(`{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"allow"}}`)})
+	oldRegistry := DefaultRegistry
+	DefaultRegistry = testRegistry
+	defer func() {
+		DefaultRegistry = oldRegistry
+	}()
+
+	os.WriteFile(filepath.Join(spcstrDir, "plugins.yaml"), []byte("plugins: [name\n"), 0644)
+
+	// The handler's output goes through untouched
+	output, err := ExecuteHookWithOutput("pre_tool_use", tempDir, []byte(`{"session_id":"plugin_session","tool_name":"Bash"}`))
+	if err != nil {
+		t.Fatalf("pre_tool_use error = %v, want broken plugins.yaml ignored", err)
+	}
+	var reply events.HookOutput
+	json.Unmarshal(output, &reply)
+	if reply.HookSpecificOutput == nil || reply.HookSpecificOutput.PermissionDecision != "allow" {
+		t.Errorf("pre_tool_use output = %s, want the handler's allow", output)
+	}
+}
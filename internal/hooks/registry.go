@@ -11,6 +11,13 @@ type HookHandler interface {
 	Execute(input []byte) error
 }
 
+// OutputHandler is implemented by handlers that reply to Claude Code with
+// JSON written to stdout, such as permission decisions
+type OutputHandler interface {
+	HookHandler
+	ExecuteWithOutput(input []byte) ([]byte, error)
+}
+
 // HookRegistry manages all registered hook handlers
 type HookRegistry struct {
 	handlers map[string]HookHandler
@@ -33,15 +40,25 @@ func (r *HookRegistry) Register(handler HookHandler) {
 
 // Execute runs the specified hook with the provided input
 func (r *HookRegistry) Execute(name string, input []byte) error {
+	_, err := r.ExecuteWithOutput(name, input)
+	return err
+}
+
+// ExecuteWithOutput runs the specified hook and returns any JSON output it
+// produced for Claude Code. Handlers without output return nil.
+func (r *HookRegistry) ExecuteWithOutput(name string, input []byte) ([]byte, error) {
 	r.mu.RLock()
 	handler, exists := r.handlers[name]
 	r.mu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("hook '%s' not found", name)
+		return nil, fmt.Errorf("hook '%s' not found", name)
 	}
 
-	return handler.Execute(input)
+	if outputHandler, ok := handler.(OutputHandler); ok {
+		return outputHandler.ExecuteWithOutput(input)
+	}
+	return nil, handler.Execute(input)
 }
 
 // GetHandler retrieves a handler by name
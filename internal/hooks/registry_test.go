@@ -182,3 +182,33 @@ func TestRegistryConcurrency(t *testing.T) {
 		t.Error("Handler was not registered in concurrent test")
 	}
 }
+
+// mockOutputHandler implements OutputHandler for testing
+type mockOutputHandler struct {
+	mockHandler
+	output []byte
+}
+
+func (m *mockOutputHandler) ExecuteWithOutput(input []byte) ([]byte, error) {
+	return m.output, m.execErr
+}
+
+func TestRegistryExecuteWithOutput(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockHandler{name: "plain"})
+	registry.Register(&mockOutputHandler{mockHandler: mockHandler{name: "replying"}, output: []byte(`{"ok":true}`)})
+
+	output, err := registry.ExecuteWithOutput("plain", nil)
+	if err != nil || output != nil {
+		t.Errorf("plain handler = %s, %v; want no output", output, err)
+	}
+
+	output, err = registry.ExecuteWithOutput("replying", nil)
+	if err != nil || string(output) != `{"ok":true}` {
+		t.Errorf("output handler = %s, %v", output, err)
+	}
+
+	if _, err := registry.ExecuteWithOutput("missing", nil); err == nil {
+		t.Error("expected error for unknown hook")
+	}
+}
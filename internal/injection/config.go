@@ -0,0 +1,99 @@
+// Package injection builds the extra context spcstr hands to Claude Code
+// from the SessionStart and UserPromptSubmit hooks
+package injection
+
+import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the context injection settings file in the .spcstr directory
+const ConfigFileName = "context.yaml"
+
+// Config selects what context is injected by each hook
+type Config struct {
+	SessionStart SessionStartConfig `yaml:"session_start"`
+	PromptSubmit PromptSubmitConfig `yaml:"prompt_submit"`
+	// MaxChars caps the size of any injected context block
+	MaxChars int `yaml:"max_chars"`
+}
+
+// SessionStartConfig controls context injected when a session starts
+type SessionStartConfig struct {
+	Enabled            bool `yaml:"enabled"`
+	Story              bool `yaml:"story"`
+	AcceptanceCriteria bool `yaml:"acceptance_criteria"`
+	ArchitectureIndex  bool `yaml:"architecture_index"`
+}
+
+// PromptSubmitConfig controls context injected when a prompt is submitted
+type PromptSubmitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// FirstPromptOnly limits injection to the first prompt of a session
+	FirstPromptOnly bool `yaml:"first_prompt_only"`
+	Todos           bool `yaml:"todos"`
+	RecentFiles     bool `yaml:"recent_files"`
+	MaxFiles        int  `yaml:"max_files"`
+}
+
+// DefaultConfig returns the settings used when no context.yaml exists.
+// Injection is opt-in, so nothing is injected until a hook is enabled.
+func DefaultConfig() Config {
+	return Config{
+		SessionStart: SessionStartConfig{
+			Enabled:            false,
+			Story:              true,
+			AcceptanceCriteria: true,
+			ArchitectureIndex:  true,
+		},
+		PromptSubmit: PromptSubmitConfig{
+			Enabled:         false,
+			FirstPromptOnly: true,
+			Todos:           true,
+			RecentFiles:     true,
+			MaxFiles:        10,
+		},
+		MaxChars: 8000,
+	}
+}
+
+// LoadConfig reads context.yaml over the defaults. A missing file yields
+// the defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read context config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse context config %s: %w", path, err)
+	}
+	if cfg.MaxChars <= 0 {
+		cfg.MaxChars = DefaultConfig().MaxChars
+	}
+	if cfg.PromptSubmit.MaxFiles <= 0 {
+		cfg.PromptSubmit.MaxFiles = DefaultConfig().PromptSubmit.MaxFiles
+	}
+
+	return cfg, nil
+}
+
+// truncate shortens context to the configured limit
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	// Avoid splitting a multi-byte rune
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + "\n[truncated]"
+}
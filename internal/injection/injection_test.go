@@ -0,0 +1,160 @@
+package injection
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+const inProgressStory = `# Story 2.1: Policy Engine
+
+## Status
+InProgress
+
+## Story
+
+**As a** maintainer,
+**I want** tool calls checked against rules,
+**so that** risky commands are blocked.
+
+## Acceptance Criteria
+
+1. Deny rules block the call
+2. Decisions are recorded
+
+## Tasks / Subtasks
+
+- [ ] Write the parser
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestCurrentStory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "1.1.story.md"), "# Story 1.1: Done\n\n## Status\nDone\n")
+	writeFile(t, filepath.Join(dir, "2.1.story.md"), inProgressStory)
+
+	story, err := CurrentStory(dir)
+	if err != nil {
+		t.Fatalf("CurrentStory() error: %v", err)
+	}
+	if story == nil {
+		t.Fatal("CurrentStory() found no story")
+	}
+	if story.Title != "Story 2.1: Policy Engine" || story.Status != "InProgress" {
+		t.Errorf("unexpected story: %s (%s)", story.Title, story.Status)
+	}
+	if got := story.Section("Acceptance Criteria"); got != "1. Deny rules block the call\n2. Decisions are recorded" {
+		t.Errorf("Acceptance Criteria = %q", got)
+	}
+
+	empty := t.TempDir()
+	writeFile(t, filepath.Join(empty, "1.1.story.md"), "# Story 1.1\n\n## Status\nDraft\n")
+	if story, err := CurrentStory(empty); err != nil || story != nil {
+		t.Errorf("CurrentStory() without in-progress story = %v, %v", story, err)
+	}
+}
+
+func TestSessionStartContext(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "docs", "stories", "2.1.story.md"), inProgressStory)
+	writeFile(t, filepath.Join(root, "docs", "architecture", "tech-stack.md"), "# Tech Stack\n")
+	writeFile(t, filepath.Join(root, "docs", "guides", "architecture-notes.md"), "# Notes\n")
+
+	// Injection is opt-in
+	if got, err := SessionStartContext(root, DefaultConfig()); err != nil || got != "" {
+		t.Errorf("default SessionStartContext() = %q, %v; want nothing", got, err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.SessionStart.Enabled = true
+	got, err := SessionStartContext(root, cfg)
+	if err != nil {
+		t.Fatalf("SessionStartContext() error: %v", err)
+	}
+	for _, want := range []string{
+		"## Current story: Story 2.1: Policy Engine",
+		"File: docs/stories/2.1.story.md",
+		"**I want** tool calls checked against rules",
+		"1. Deny rules block the call",
+		"- Tech Stack (docs/architecture/tech-stack.md)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("context missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Write the parser") {
+		t.Error("context should not include tasks")
+	}
+	if strings.Contains(got, "Notes") {
+		t.Error("context should list only the configured architecture documents")
+	}
+}
+
+func TestPromptContext(t *testing.T) {
+	previous := &state.SessionState{
+		SessionID: "prev",
+		CreatedAt: time.Now().Add(-time.Hour),
+		Files: state.FileOperations{
+			New:    []string{"/proj/new.go"},
+			Edited: []string{"/proj/a.go", "/proj/b.go", "/proj/a.go"},
+		},
+		Todos: state.TodoState{
+			Total:      3,
+			Pending:    1,
+			InProgress: 1,
+			Completed:  1,
+			Recent: []state.TodoItem{
+				{Content: "Write tests", Status: "pending"},
+				{Content: "Fix bug", Status: "in_progress"},
+				{Content: "Read code", Status: "completed"},
+			},
+		},
+	}
+
+	cfg := DefaultConfig()
+	if got := PromptContext("/proj", previous, cfg); got != "" {
+		t.Errorf("PromptContext() disabled by default, got %q", got)
+	}
+
+	cfg.PromptSubmit.Enabled = true
+	cfg.PromptSubmit.MaxFiles = 2
+	got := PromptContext("/proj", previous, cfg)
+	for _, want := range []string{"(2 of 3)", "- [pending] Write tests", "- [in_progress] Fix bug", "- a.go", "- b.go"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("context missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Read code") || strings.Contains(got, "new.go") {
+		t.Errorf("context includes completed todo or exceeds max files:\n%s", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	writeFile(t, path, "prompt_submit:\n  enabled: true\nmax_chars: 20\n")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if !cfg.PromptSubmit.Enabled || !cfg.PromptSubmit.Todos || cfg.SessionStart.Enabled || !cfg.SessionStart.Story || cfg.MaxChars != 20 {
+		t.Errorf("LoadConfig() did not layer over defaults: %+v", cfg)
+	}
+
+	if got := truncate(strings.Repeat("é", 20), cfg.MaxChars); got != strings.Repeat("é", 10)+"\n[truncated]" {
+		t.Errorf("truncate() = %q", got)
+	}
+}
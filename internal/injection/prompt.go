@@ -0,0 +1,71 @@
+package injection
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// PromptContext summarizes the open todos and recently touched files of the
+// previous session. It returns "" when there is nothing worth injecting.
+func PromptContext(rootPath string, previous *state.SessionState, cfg Config) string {
+	if !cfg.PromptSubmit.Enabled || previous == nil {
+		return ""
+	}
+
+	var blocks []string
+
+	if cfg.PromptSubmit.Todos {
+		todos := previous.Todos
+		open := todos.Pending + todos.InProgress
+		if open > 0 {
+			var b strings.Builder
+			fmt.Fprintf(&b, "## Open todos from the previous session (%d of %d)\n", open, todos.Total)
+			for _, item := range todos.Recent {
+				if item.Status == "completed" {
+					continue
+				}
+				fmt.Fprintf(&b, "- [%s] %s\n", item.Status, item.Content)
+			}
+			blocks = append(blocks, b.String())
+		}
+	}
+
+	if cfg.PromptSubmit.RecentFiles {
+		files := recentFiles(previous.Files, cfg.PromptSubmit.MaxFiles)
+		if len(files) > 0 {
+			var b strings.Builder
+			b.WriteString("## Files changed in the previous session\n")
+			for _, path := range files {
+				b.WriteString("- " + relativeTo(rootPath, path) + "\n")
+			}
+			blocks = append(blocks, b.String())
+		}
+	}
+
+	if len(blocks) == 0 {
+		return ""
+	}
+	header := fmt.Sprintf("Context from previous spcstr session %s:\n\n", previous.SessionID)
+	return truncate(header+strings.Join(blocks, "\n"), cfg.MaxChars)
+}
+
+// recentFiles returns up to max distinct paths, edited files before created
+// ones and newest first within each
+func recentFiles(files state.FileOperations, max int) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, list := range [][]string{files.Edited, files.New} {
+		for i := len(list) - 1; i >= 0 && len(result) < max; i-- {
+			path := filepath.Clean(list[i])
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package injection
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/docs"
+)
+
+// Story is a development story parsed from DevStoryLocation
+type Story struct {
+	Path     string
+	Title    string
+	Status   string
+	sections map[string]string
+}
+
+// Section returns the body of a "## " section, or "" when absent
+func (s *Story) Section(name string) string {
+	return s.sections[strings.ToLower(name)]
+}
+
+// SessionStartContext builds the context injected when a session starts
+func SessionStartContext(rootPath string, cfg Config) (string, error) {
+	if !cfg.SessionStart.Enabled {
+		return "", nil
+	}
+
+	var blocks []string
+
+	if cfg.SessionStart.Story || cfg.SessionStart.AcceptanceCriteria {
+		coreConfig, err := config.LoadCoreConfig(rootPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to load core config: %w", err)
+		}
+		story, err := CurrentStory(filepath.Join(rootPath, coreConfig.DevStoryLocation))
+		if err != nil {
+			return "", err
+		}
+		if story != nil {
+			var b strings.Builder
+			b.WriteString("## Current story: " + story.Title + "\n")
+			b.WriteString("File: " + relativeTo(rootPath, story.Path) + "\n")
+			b.WriteString("Status: " + story.Status + "\n")
+			if body := story.Section("Story"); cfg.SessionStart.Story && body != "" {
+				b.WriteString("\n" + body + "\n")
+			}
+			if criteria := story.Section("Acceptance Criteria"); cfg.SessionStart.AcceptanceCriteria && criteria != "" {
+				b.WriteString("\n### Acceptance Criteria\n" + criteria + "\n")
+			}
+			blocks = append(blocks, b.String())
+		}
+	}
+
+	if cfg.SessionStart.ArchitectureIndex {
+		// Only the architecture documents are indexed, not the whole docs tree
+		documents, err := docs.NewEngine(rootPath).IndexArchitecture()
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		for _, doc := range documents {
+			b.WriteString("- " + doc.Title + " (" + relativeTo(rootPath, doc.Path) + ")\n")
+		}
+		if b.Len() > 0 {
+			blocks = append(blocks, "## Architecture documents\n"+b.String())
+		}
+	}
+
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return truncate(strings.Join(blocks, "\n"), cfg.MaxChars), nil
+}
+
+// CurrentStory returns the in-progress story in dir, preferring the most
+// recently modified one when several are in progress. It returns nil when
+// no story is in progress.
+func CurrentStory(dir string) (*Story, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		story   *Story
+		modTime int64
+	}
+	var candidates []candidate
+	for _, path := range paths {
+		story, err := ParseStory(path)
+		if err != nil {
+			continue
+		}
+		if !isInProgress(story.Status) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, candidate{story, info.ModTime().UnixNano()})
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].modTime > candidates[j].modTime
+	})
+	return candidates[0].story, nil
+}
+
+// ParseStory reads a story markdown file into its title, status and sections
+func ParseStory(path string) (*Story, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	story := &Story{
+		Path:     path,
+		Title:    filepath.Base(path),
+		sections: make(map[string]string),
+	}
+
+	current := ""
+	var body []string
+	flush := func() {
+		if current != "" {
+			story.sections[current] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+		body = body[:0]
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# ") && current == "":
+			story.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		case strings.HasPrefix(line, "## "):
+			flush()
+			current = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "## ")))
+		default:
+			body = append(body, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	// The status section holds a single line such as "InProgress"
+	if status := story.sections["status"]; status != "" {
+		story.Status = strings.TrimSpace(strings.SplitN(status, "\n", 2)[0])
+	}
+
+	return story, nil
+}
+
+// isInProgress accepts the spellings BMAD stories use for in-progress work
+func isInProgress(status string) bool {
+	normalized := strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(status))
+	return normalized == "inprogress"
+}
+
+// relativeTo returns path relative to root when possible
+func relativeTo(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,404 @@
+// Package metrics keeps rolling latency and failure aggregates for hook
+// executions in .spcstr/metrics.json, fed by samples appended to
+// .spcstr/metrics.jsonl
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+const (
+	// FileName holds the aggregates in the .spcstr directory
+	FileName = "metrics.json"
+	// SamplesFileName collects one JSON line per hook execution until the
+	// samples are folded into FileName
+	SamplesFileName = "metrics.jsonl"
+	// lockFileName serializes folding samples across hook processes
+	lockFileName = "metrics.lock"
+	// compactSize is the samples file size that triggers folding
+	compactSize = 256 << 10
+	// WindowSize is how many recent executions per hook the percentiles cover
+	WindowSize = 200
+	// maxErrorLength caps the last error kept per hook
+	maxErrorLength = 500
+)
+
+// SlowThreshold is the p95 latency above which a hook is flagged as slow
+const SlowThreshold = 250 * time.Millisecond
+
+// FailingRate is the share of failed executions in the window above which a
+// hook is flagged as failing
+const FailingRate = 0.1
+
+// Hook health flags
+const (
+	HealthOK      = "ok"
+	HealthSlow    = "slow"
+	HealthFailing = "failing"
+)
+
+// Sample is the phase timing of one hook execution, in microseconds
+type Sample struct {
+	At time.Time `json:"at"`
+	// SetupUs covers loading settings, redaction and middleware config
+	SetupUs   int64 `json:"setup_us"`
+	ParseUs   int64 `json:"parse_us"`
+	HandlerUs int64 `json:"handler_us"`
+	LogUs     int64 `json:"log_us"`
+	Success   bool  `json:"ok"`
+}
+
+// TotalUs is the sample's duration across all phases
+func (s Sample) TotalUs() int64 {
+	return s.SetupUs + s.ParseUs + s.HandlerUs + s.LogUs
+}
+
+// Stats are latency percentiles over the window, in microseconds
+type Stats struct {
+	P50Us int64 `json:"p50_us"`
+	P95Us int64 `json:"p95_us"`
+	MaxUs int64 `json:"max_us"`
+}
+
+// HookMetrics aggregates the executions of one hook
+type HookMetrics struct {
+	Count       int        `json:"count"`
+	Failures    int        `json:"failures"`
+	LastRunAt   time.Time  `json:"last_run_at"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	Total       Stats      `json:"total"`
+	Setup       Stats      `json:"setup"`
+	Parse       Stats      `json:"parse"`
+	Handler     Stats      `json:"handler"`
+	Logging     Stats      `json:"logging"`
+	// Window holds the most recent samples, oldest first
+	Window []Sample `json:"window"`
+}
+
+// File is the content of metrics.json
+type File struct {
+	UpdatedAt time.Time               `json:"updated_at"`
+	Hooks     map[string]*HookMetrics `json:"hooks"`
+	// Folded is the last samples segment folded into the aggregates
+	Folded string `json:"folded,omitempty"`
+}
+
+// Path returns the metrics file path for a .spcstr directory
+func Path(basePath string) string {
+	return filepath.Join(basePath, FileName)
+}
+
+// SamplesPath returns the file new samples are appended to
+func SamplesPath(basePath string) string {
+	return filepath.Join(basePath, SamplesFileName)
+}
+
+// entry is one line of metrics.jsonl
+type entry struct {
+	Hook string `json:"hook"`
+	Sample
+	Error string `json:"error,omitempty"`
+}
+
+// Load reads the aggregates in metrics.json and folds in the samples
+// appended since they were written. Missing files yield empty metrics.
+func Load(basePath string) (*File, error) {
+	file, err := loadFolded(basePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.foldSamples(SamplesPath(basePath)); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// loadFolded reads metrics.json and the sample segments set aside for it,
+// leaving out the live samples file
+func loadFolded(basePath string) (*File, error) {
+	file := &File{Hooks: make(map[string]*HookMetrics)}
+
+	data, err := os.ReadFile(Path(basePath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, file); err != nil {
+			return nil, fmt.Errorf("failed to parse metrics %s: %w", Path(basePath), err)
+		}
+		if file.Hooks == nil {
+			file.Hooks = make(map[string]*HookMetrics)
+		}
+	}
+
+	pending, err := pendingSegments(basePath, file.Folded)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range pending {
+		if err := file.foldSamples(path); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// Record appends one hook execution to metrics.jsonl with a single write,
+// so concurrent hooks never wait on each other. Once the file passes
+// compactSize, the samples are folded into metrics.json under a lock.
+// basePath must be absolute. hookErr is the execution's error, if any.
+func Record(basePath, hookName string, sample Sample, hookErr error) error {
+	if sample.At.IsZero() {
+		sample.At = time.Now().UTC()
+	}
+	sample.Success = hookErr == nil
+	record := entry{Hook: hookName, Sample: sample}
+	if hookErr != nil {
+		record.Error = truncateError(hookErr.Error())
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics sample: %w", err)
+	}
+	line = append(line, '\n')
+
+	samples, err := os.OpenFile(SamplesPath(basePath), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics samples: %w", err)
+	}
+	if _, err := samples.Write(line); err != nil {
+		samples.Close()
+		return fmt.Errorf("failed to append metrics sample: %w", err)
+	}
+	info, err := samples.Stat()
+	if err := errors.Join(err, samples.Close()); err != nil {
+		return fmt.Errorf("failed to append metrics sample: %w", err)
+	}
+
+	if info.Size() < compactSize {
+		return nil
+	}
+	return compact(basePath)
+}
+
+// compact folds metrics.jsonl into metrics.json. The samples file is
+// renamed to a stamped segment first, so appends that follow go to a fresh
+// file, and metrics.json names the segment it folded in case the process
+// dies before removing it. A sample whose write races the rename can be
+// lost; metrics are approximate by design.
+func compact(basePath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), state.DefaultTimeout)
+	defer cancel()
+
+	lock, err := state.AcquireFileLock(ctx, filepath.Join(basePath, lockFileName))
+	if err != nil {
+		return fmt.Errorf("failed to lock metrics: %w", err)
+	}
+	defer lock.Release()
+
+	// Another process may have compacted while we waited
+	info, err := os.Stat(SamplesPath(basePath))
+	if err != nil || info.Size() < compactSize {
+		return nil
+	}
+
+	segment := filepath.Join(basePath, fmt.Sprintf("metrics.%d.jsonl", time.Now().UnixNano()))
+	if err := os.Rename(SamplesPath(basePath), segment); err != nil {
+		return fmt.Errorf("failed to rotate metrics samples: %w", err)
+	}
+
+	file, err := loadFolded(basePath)
+	if err != nil {
+		return err
+	}
+	pending, err := pendingSegments(basePath, "")
+	if err != nil {
+		return err
+	}
+	file.Folded = filepath.Base(segment)
+
+	writer := state.NewAtomicWriter(state.DefaultTimeout)
+	if err := writer.WriteJSON(ctx, Path(basePath), file); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	for _, path := range pending {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove folded metrics samples: %w", err)
+		}
+	}
+	return nil
+}
+
+// pendingSegments lists the stamped sample segments not yet folded into
+// metrics.json, oldest first. folded names the last segment that was.
+func pendingSegments(basePath, folded string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(basePath, "metrics.*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics samples: %w", err)
+	}
+	// Stamps share a width for centuries, so name order is time order
+	sort.Strings(paths)
+	segments := paths[:0]
+	for _, path := range paths {
+		if filepath.Base(path) != folded {
+			segments = append(segments, path)
+		}
+	}
+	return segments, nil
+}
+
+// foldSamples adds every sample in a metrics.jsonl file. A line cut short
+// by a concurrent write is skipped.
+func (f *File) foldSamples(path string) error {
+	samples, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read metrics samples: %w", err)
+	}
+	defer samples.Close()
+
+	scanner := bufio.NewScanner(samples)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record entry
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Hook == "" {
+			continue
+		}
+		var hookErr error
+		if !record.Success {
+			hookErr = errors.New(record.Error)
+		}
+		f.Add(record.Hook, record.Sample, hookErr)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read metrics samples %s: %w", path, err)
+	}
+	return nil
+}
+
+// Add folds a sample into the hook's aggregates
+func (f *File) Add(hookName string, sample Sample, hookErr error) {
+	if sample.At.IsZero() {
+		sample.At = time.Now().UTC()
+	}
+	sample.Success = hookErr == nil
+
+	h := f.Hooks[hookName]
+	if h == nil {
+		h = &HookMetrics{}
+		f.Hooks[hookName] = h
+	}
+
+	h.Count++
+	h.LastRunAt = sample.At
+	if hookErr != nil {
+		h.Failures++
+		h.LastError = truncateError(hookErr.Error())
+		at := sample.At
+		h.LastErrorAt = &at
+	}
+
+	h.Window = append(h.Window, sample)
+	if len(h.Window) > WindowSize {
+		h.Window = slices.Clone(h.Window[len(h.Window)-WindowSize:])
+	}
+	h.recompute()
+	f.UpdatedAt = sample.At
+}
+
+// truncateError caps an error message at maxErrorLength
+func truncateError(message string) string {
+	if len(message) > maxErrorLength {
+		message = strings.ToValidUTF8(message[:maxErrorLength], "") + "…"
+	}
+	return message
+}
+
+// recompute refreshes the percentiles from the window
+func (h *HookMetrics) recompute() {
+	h.Total = statsOf(h.Window, Sample.TotalUs)
+	h.Setup = statsOf(h.Window, func(s Sample) int64 { return s.SetupUs })
+	h.Parse = statsOf(h.Window, func(s Sample) int64 { return s.ParseUs })
+	h.Handler = statsOf(h.Window, func(s Sample) int64 { return s.HandlerUs })
+	h.Logging = statsOf(h.Window, func(s Sample) int64 { return s.LogUs })
+}
+
+// statsOf computes nearest-rank percentiles of one phase across samples
+func statsOf(samples []Sample, value func(Sample) int64) Stats {
+	if len(samples) == 0 {
+		return Stats{}
+	}
+	values := make([]int64, len(samples))
+	for i, s := range samples {
+		values[i] = value(s)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return Stats{
+		P50Us: percentile(values, 50),
+		P95Us: percentile(values, 95),
+		MaxUs: values[len(values)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WindowFailures counts the failed executions in the window
+func (h *HookMetrics) WindowFailures() int {
+	failures := 0
+	for _, s := range h.Window {
+		if !s.Success {
+			failures++
+		}
+	}
+	return failures
+}
+
+// Health flags a hook as failing when its latest run failed or failures
+// make up FailingRate of the window, and as slow when its p95 exceeds
+// SlowThreshold
+func (h *HookMetrics) Health() string {
+	if len(h.Window) > 0 {
+		last := h.Window[len(h.Window)-1]
+		failures := h.WindowFailures()
+		if !last.Success || (failures > 0 && float64(failures) >= FailingRate*float64(len(h.Window))) {
+			return HealthFailing
+		}
+	}
+	if time.Duration(h.Total.P95Us)*time.Microsecond > SlowThreshold {
+		return HealthSlow
+	}
+	return HealthOK
+}
+
+// Names returns the hooks with metrics, sorted
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Hooks))
+	for name := range f.Hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
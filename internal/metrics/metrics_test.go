@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+		p      int
+		want   int64
+	}{
+		{"single", []int64{7}, 50, 7},
+		{"single p95", []int64{7}, 95, 7},
+		{"median of four", []int64{1, 2, 3, 4}, 50, 2},
+		{"p95 of twenty", []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 95, 19},
+		{"p100", []int64{1, 2, 3}, 100, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.values, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %d) = %d, want %d", tt.values, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAggregates(t *testing.T) {
+	file := &File{Hooks: make(map[string]*HookMetrics)}
+	for i := 1; i <= 4; i++ {
+		file.Add("stop", Sample{ParseUs: 10, HandlerUs: int64(i) * 100, LogUs: 5}, nil)
+	}
+	file.Add("stop", Sample{ParseUs: 10, HandlerUs: 50, LogUs: 5}, errors.New("boom"))
+
+	h := file.Hooks["stop"]
+	if h.Count != 5 || h.Failures != 1 {
+		t.Errorf("Count, Failures = %d, %d, want 5, 1", h.Count, h.Failures)
+	}
+	if h.LastError != "boom" || h.LastErrorAt == nil {
+		t.Errorf("LastError = %q at %v, want boom with a time", h.LastError, h.LastErrorAt)
+	}
+	if h.Handler.MaxUs != 400 || h.Handler.P50Us != 200 {
+		t.Errorf("Handler = %+v, want p50 200 and max 400", h.Handler)
+	}
+	if h.Total.MaxUs != 415 {
+		t.Errorf("Total.MaxUs = %d, want 415", h.Total.MaxUs)
+	}
+	if h.Parse.P95Us != 10 {
+		t.Errorf("Parse.P95Us = %d, want 10", h.Parse.P95Us)
+	}
+}
+
+func TestAddTrimsWindow(t *testing.T) {
+	file := &File{Hooks: make(map[string]*HookMetrics)}
+	for i := 0; i < WindowSize+10; i++ {
+		file.Add("stop", Sample{HandlerUs: int64(i)}, nil)
+	}
+
+	h := file.Hooks["stop"]
+	if h.Count != WindowSize+10 {
+		t.Errorf("Count = %d, want %d", h.Count, WindowSize+10)
+	}
+	if len(h.Window) != WindowSize {
+		t.Fatalf("len(Window) = %d, want %d", len(h.Window), WindowSize)
+	}
+	if h.Window[0].HandlerUs != 10 {
+		t.Errorf("oldest sample = %d, want 10", h.Window[0].HandlerUs)
+	}
+}
+
+func TestAddTruncatesLongErrors(t *testing.T) {
+	file := &File{Hooks: make(map[string]*HookMetrics)}
+	file.Add("stop", Sample{}, errors.New(strings.Repeat("é", maxErrorLength)))
+
+	message := file.Hooks["stop"].LastError
+	if !strings.HasSuffix(message, "…") || len(message) > maxErrorLength+len("…") {
+		t.Errorf("LastError has length %d, want at most %d ending in …", len(message), maxErrorLength+len("…"))
+	}
+}
+
+func TestHealth(t *testing.T) {
+	fast := Sample{HandlerUs: 1000, Success: true}
+	slow := Sample{HandlerUs: (SlowThreshold + time.Millisecond).Microseconds(), Success: true}
+	failed := Sample{HandlerUs: 1000}
+
+	repeat := func(s Sample, n int) []Sample {
+		samples := make([]Sample, n)
+		for i := range samples {
+			samples[i] = s
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name   string
+		window []Sample
+		want   string
+	}{
+		{"no runs", nil, HealthOK},
+		{"fast", repeat(fast, 20), HealthOK},
+		{"last run failed", append(repeat(fast, 20), failed), HealthFailing},
+		{"rare old failure", append([]Sample{failed}, repeat(fast, 19)...), HealthOK},
+		{"frequent failures", append(repeat(failed, 2), repeat(fast, 18)...), HealthFailing},
+		{"slow p95", append(repeat(fast, 18), repeat(slow, 2)...), HealthSlow},
+		{"rare slow run", append(repeat(slow, 1), repeat(fast, 39)...), HealthOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HookMetrics{Window: tt.window}
+			h.recompute()
+			if got := h.Health(); got != tt.want {
+				t.Errorf("Health() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	basePath := t.TempDir()
+
+	file, err := Load(basePath)
+	if err != nil {
+		t.Fatalf("Load() on missing file error = %v", err)
+	}
+	if len(file.Hooks) != 0 {
+		t.Fatalf("Load() on missing file = %d hooks, want 0", len(file.Hooks))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Record(basePath, "pre_tool_use", Sample{HandlerUs: 100}, nil); err != nil {
+				t.Errorf("Record() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := Record(basePath, "stop", Sample{HandlerUs: 100}, errors.New("gate failed")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	file, err = Load(basePath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := file.Hooks["pre_tool_use"].Count; got != 8 {
+		t.Errorf("pre_tool_use Count = %d, want 8", got)
+	}
+	if got := file.Hooks["stop"].Health(); got != HealthFailing {
+		t.Errorf("stop Health() = %q, want %q", got, HealthFailing)
+	}
+	if names := file.Names(); len(names) != 2 || names[0] != "pre_tool_use" || names[1] != "stop" {
+		t.Errorf("Names() = %v, want [pre_tool_use stop]", names)
+	}
+}
+
+func TestRecordCompactsSamples(t *testing.T) {
+	basePath := t.TempDir()
+	hookErr := errors.New(strings.Repeat("x", maxErrorLength))
+
+	// Long errors fill the samples file past compactSize quickly
+	const writers, perWriter = 8, 80
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				if err := Record(basePath, "stop", Sample{HandlerUs: 100}, hookErr); err != nil {
+					t.Errorf("Record() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := os.Stat(Path(basePath)); err != nil {
+		t.Fatalf("samples were never folded into %s: %v", FileName, err)
+	}
+	if info, err := os.Stat(SamplesPath(basePath)); err == nil && info.Size() >= compactSize {
+		t.Errorf("samples file holds %d bytes after compaction", info.Size())
+	}
+	if segments, _ := pendingSegments(basePath, ""); len(segments) != 0 {
+		t.Errorf("folded segments left behind: %v", segments)
+	}
+
+	file, err := Load(basePath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := file.Hooks["stop"]; got.Count != writers*perWriter || got.Failures != writers*perWriter {
+		t.Errorf("Count, Failures = %d, %d, want %d each", got.Count, got.Failures, writers*perWriter)
+	}
+}
+
+func TestLoadSkipsFoldedSegment(t *testing.T) {
+	basePath := t.TempDir()
+	line := `{"hook":"stop","at":"2025-01-01T12:00:00Z","handler_us":100,"ok":true}` + "\n"
+
+	// A compaction that died before removing the segment it folded
+	folded := `{"hooks":{"stop":{"count":1,"window":[{"at":"2025-01-01T12:00:00Z","handler_us":100,"ok":true}]}},"folded":"metrics.1.jsonl"}`
+	os.WriteFile(Path(basePath), []byte(folded), 0644)
+	os.WriteFile(filepath.Join(basePath, "metrics.1.jsonl"), []byte(line), 0644)
+	// ... and one that died before writing metrics.json
+	os.WriteFile(filepath.Join(basePath, "metrics.2.jsonl"), []byte(line), 0644)
+	os.WriteFile(SamplesPath(basePath), []byte(line+`{"hook":"stop","at":`), 0644)
+
+	file, err := Load(basePath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := file.Hooks["stop"].Count; got != 3 {
+		t.Errorf("Count = %d, want 3 without the folded segment or the torn line", got)
+	}
+}
@@ -0,0 +1,204 @@
+// Package plugin runs user-configured external commands alongside the
+// built-in hook handlers
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the plugin settings file in the .spcstr directory
+const FileName = "plugins.yaml"
+
+// DefaultTimeout bounds a plugin that sets no timeout
+const DefaultTimeout = 10 * time.Second
+
+// maxErrorOutput caps the stderr quoted in a plugin failure
+const maxErrorOutput = 500
+
+// Failure policies decide what a plugin failure does to the hook
+const (
+	// FailIgnore drops the failure silently
+	FailIgnore = "ignore"
+	// FailWarn prints the failure to stderr and carries on
+	FailWarn = "warn"
+	// FailBlock fails the hook, which blocks the Claude Code action
+	FailBlock = "block"
+)
+
+// Config lists the plugins of a project
+type Config struct {
+	Plugins []Plugin `yaml:"plugins"`
+}
+
+// Plugin is an external command run for selected hooks. It receives the
+// hook input JSON on stdin and may print a Result as JSON on stdout.
+type Plugin struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+	// Hooks limits the plugin to these hook names; empty runs it for all
+	Hooks   []string      `yaml:"hooks"`
+	Timeout time.Duration `yaml:"timeout"`
+	// Order sorts plugins of the same hook, lowest first. Plugins with the
+	// same order run in file order.
+	Order     int    `yaml:"order"`
+	OnFailure string `yaml:"on_failure"`
+}
+
+// Result is the structured reply a plugin may print on stdout
+type Result struct {
+	// Decision "block" denies a tool call or keeps Claude working
+	Decision string `json:"decision,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// AdditionalContext is added to Claude's context where the hook allows
+	AdditionalContext string `json:"additional_context,omitempty"`
+	// State is merged into the plugin's section of the session state; a
+	// null value removes the key
+	State map[string]json.RawMessage `json:"state,omitempty"`
+}
+
+// Blocks reports whether the plugin asked to block the action
+func (r Result) Blocks() bool {
+	return r.Decision == "block"
+}
+
+// LoadConfig reads plugins.yaml, filling defaults and sorting plugins into
+// run order. A missing file yields no plugins.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read plugin config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse plugin config %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range cfg.Plugins {
+		p := &cfg.Plugins[i]
+		if p.Name == "" {
+			return cfg, fmt.Errorf("plugin %d has no name", i+1)
+		}
+		if seen[p.Name] {
+			return cfg, fmt.Errorf("plugin %q is defined twice", p.Name)
+		}
+		seen[p.Name] = true
+		if strings.TrimSpace(p.Command) == "" {
+			return cfg, fmt.Errorf("plugin %q has no command", p.Name)
+		}
+		if p.Timeout <= 0 {
+			p.Timeout = DefaultTimeout
+		}
+		switch p.OnFailure {
+		case "":
+			p.OnFailure = FailWarn
+		case FailIgnore, FailWarn, FailBlock:
+		default:
+			return cfg, fmt.Errorf("plugin %q: invalid on_failure %q (want ignore, warn or block)", p.Name, p.OnFailure)
+		}
+	}
+
+	sort.SliceStable(cfg.Plugins, func(i, j int) bool {
+		return cfg.Plugins[i].Order < cfg.Plugins[j].Order
+	})
+	return cfg, nil
+}
+
+// ForHook returns the plugins that run for hookName, in run order
+func (c Config) ForHook(hookName string) []Plugin {
+	var plugins []Plugin
+	for _, p := range c.Plugins {
+		if p.Handles(hookName) {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// Handles reports whether the plugin runs for hookName
+func (p Plugin) Handles(hookName string) bool {
+	if len(p.Hooks) == 0 {
+		return true
+	}
+	for _, name := range p.Hooks {
+		if name == hookName {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes the plugin through the shell in dir with input on stdin.
+// A non-zero exit, a timeout or stdout that is not a Result is an error.
+func Run(ctx context.Context, dir, hookName string, p Plugin, input []byte) (Result, error) {
+	var result Result
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "SPCSTR_HOOK="+hookName, "SPCSTR_PLUGIN="+p.Name)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children of the shell may outlive it and hold the output pipe open
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		return result, fmt.Errorf("plugin %s timed out after %s", p.Name, timeout)
+	case errors.As(err, &exitErr):
+		return result, fmt.Errorf("plugin %s exited with code %d%s", p.Name, exitErr.ExitCode(), quoteStderr(stderr.String()))
+	default:
+		return result, fmt.Errorf("failed to run plugin %s: %w", p.Name, err)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if len(out) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return result, fmt.Errorf("plugin %s printed invalid result: %w", p.Name, err)
+	}
+	if result.Decision != "" && !result.Blocks() {
+		return result, fmt.Errorf("plugin %s returned unknown decision %q", p.Name, result.Decision)
+	}
+	return result, nil
+}
+
+// quoteStderr formats the end of a plugin's stderr for an error message
+func quoteStderr(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	if len(stderr) > maxErrorOutput {
+		stderr = "…" + strings.ToValidUTF8(stderr[len(stderr)-maxErrorOutput:], "")
+	}
+	return ": " + stderr
+}
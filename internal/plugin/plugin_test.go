@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantErr   string
+		wantOrder []string
+	}{
+		{
+			name: "defaults and ordering",
+			content: `plugins:
+  - name: late
+    command: ./late.sh
+    order: 10
+  - name: first
+    command: ./first.sh
+  - name: second
+    command: ./second.sh
+    on_failure: block
+`,
+			wantOrder: []string{"first", "second", "late"},
+		},
+		{name: "missing name", content: "plugins:\n  - command: x\n", wantErr: "has no name"},
+		{name: "missing command", content: "plugins:\n  - name: x\n", wantErr: "has no command"},
+		{name: "duplicate name", content: "plugins:\n  - name: x\n    command: a\n  - name: x\n    command: b\n", wantErr: "defined twice"},
+		{name: "bad failure policy", content: "plugins:\n  - name: x\n    command: a\n    on_failure: panic\n", wantErr: "invalid on_failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error: %v", err)
+			}
+
+			var order []string
+			for _, p := range cfg.Plugins {
+				order = append(order, p.Name)
+				if p.Timeout != DefaultTimeout {
+					t.Errorf("%s timeout = %v, want default", p.Name, p.Timeout)
+				}
+			}
+			if strings.Join(order, ",") != strings.Join(tt.wantOrder, ",") {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+			if cfg.Plugins[0].OnFailure != FailWarn || cfg.Plugins[1].OnFailure != FailBlock {
+				t.Errorf("failure policies = %q, %q", cfg.Plugins[0].OnFailure, cfg.Plugins[1].OnFailure)
+			}
+		})
+	}
+
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), FileName))
+	if err != nil || len(cfg.Plugins) != 0 {
+		t.Errorf("missing config = %+v, %v", cfg, err)
+	}
+}
+
+func TestForHook(t *testing.T) {
+	cfg := Config{Plugins: []Plugin{
+		{Name: "all"},
+		{Name: "stop-only", Hooks: []string{"stop"}},
+	}}
+
+	if got := cfg.ForHook("stop"); len(got) != 2 {
+		t.Errorf("ForHook(stop) = %d plugins, want 2", len(got))
+	}
+	if got := cfg.ForHook("pre_tool_use"); len(got) != 1 || got[0].Name != "all" {
+		t.Errorf("ForHook(pre_tool_use) = %+v", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := []byte(`{"session_id":"s1"}`)
+
+	tests := []struct {
+		name     string
+		command  string
+		timeout  time.Duration
+		wantErr  string
+		validate func(t *testing.T, result Result)
+	}{
+		{
+			name:    "structured result from stdin",
+			command: `grep -q s1 && printf '{"decision":"block","reason":"from %s","state":{"seen":true}}' "$SPCSTR_HOOK"`,
+			validate: func(t *testing.T, result Result) {
+				if !result.Blocks() || result.Reason != "from stop" || string(result.State["seen"]) != "true" {
+					t.Errorf("result = %+v", result)
+				}
+			},
+		},
+		{
+			name:    "no output",
+			command: "cat > /dev/null",
+			validate: func(t *testing.T, result Result) {
+				if result.Blocks() || result.State != nil {
+					t.Errorf("result = %+v, want empty", result)
+				}
+			},
+		},
+		{name: "non-zero exit", command: "echo broken >&2; exit 3", wantErr: "exited with code 3: broken"},
+		{name: "invalid output", command: "echo not json", wantErr: "invalid result"},
+		{name: "unknown decision", command: `echo '{"decision":"approve"}'`, wantErr: "unknown decision"},
+		{name: "timeout", command: "sleep 5", timeout: 100 * time.Millisecond, wantErr: "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Plugin{Name: "test", Command: tt.command, Timeout: tt.timeout}
+			result, err := Run(context.Background(), dir, "stop", p, input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			tt.validate(t, result)
+		})
+	}
+}
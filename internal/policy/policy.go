@@ -0,0 +1,245 @@
+// Package policy evaluates declarative allow/deny/ask rules for tool calls
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the policy file inside the .spcstr directory
+const FileName = "policy.yaml"
+
+// Decision is the permission outcome of a matching rule
+type Decision string
+
+const (
+	Allow Decision = "allow"
+	Deny  Decision = "deny"
+	Ask   Decision = "ask"
+)
+
+// MainAgent is the agent type used for tool calls made outside any subagent
+const MainAgent = "main"
+
+// precedence orders decisions when several rules match; the strictest wins
+var precedence = map[Decision]int{
+	Allow: 1,
+	Ask:   2,
+	Deny:  3,
+}
+
+// Rule matches tool calls and assigns them a decision. Every non-empty
+// matcher must match; empty matchers match anything.
+type Rule struct {
+	Name     string   `yaml:"name"`
+	Decision Decision `yaml:"decision"`
+	Reason   string   `yaml:"reason"`
+	Tools    []string `yaml:"tools"`
+	Paths    []string `yaml:"paths"`
+	Command  string   `yaml:"command"`
+	Agents   []string `yaml:"agents"`
+
+	tools   []*regexp.Regexp
+	paths   []*regexp.Regexp
+	command *regexp.Regexp
+}
+
+// Policy is an ordered set of rules loaded from policy.yaml
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Call describes the tool call being evaluated
+type Call struct {
+	ToolName string
+	// FilePath is the file the tool operates on, relative to the project
+	// root when it lies inside it
+	FilePath string
+	Command  string
+	Agent    string
+}
+
+// Result is the outcome of evaluating a call against the policy
+type Result struct {
+	Decision Decision
+	Rule     string
+	Reason   string
+}
+
+// Load reads and compiles the policy at path. A missing file yields a nil
+// policy and no error.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse compiles a policy from YAML data
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			name := p.Rules[i].Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("invalid policy rule %s: %w", name, err)
+		}
+	}
+
+	return &p, nil
+}
+
+// Evaluate returns the strictest decision among matching rules. The first
+// matching rule at that level supplies the reason. ok is false when no
+// rule matched.
+func (p *Policy) Evaluate(call Call) (Result, bool) {
+	if p == nil {
+		return Result{}, false
+	}
+
+	var best Result
+	matched := false
+	for _, rule := range p.Rules {
+		if !rule.matches(call) {
+			continue
+		}
+		if !matched || precedence[rule.Decision] > precedence[best.Decision] {
+			best = Result{Decision: rule.Decision, Rule: rule.Name, Reason: rule.reason()}
+			matched = true
+		}
+	}
+
+	return best, matched
+}
+
+// compile validates the rule and prepares its matchers
+func (r *Rule) compile() error {
+	if _, ok := precedence[r.Decision]; !ok {
+		return fmt.Errorf("decision must be allow, deny or ask, got %q", r.Decision)
+	}
+
+	for _, tool := range r.Tools {
+		re, err := globToRegexp(tool, false)
+		if err != nil {
+			return fmt.Errorf("bad tool pattern %q: %w", tool, err)
+		}
+		r.tools = append(r.tools, re)
+	}
+
+	for _, path := range r.Paths {
+		re, err := globToRegexp(filepath.ToSlash(path), true)
+		if err != nil {
+			return fmt.Errorf("bad path glob %q: %w", path, err)
+		}
+		r.paths = append(r.paths, re)
+	}
+
+	if r.Command != "" {
+		re, err := regexp.Compile(r.Command)
+		if err != nil {
+			return fmt.Errorf("bad command regex: %w", err)
+		}
+		r.command = re
+	}
+
+	return nil
+}
+
+// matches reports whether every matcher on the rule accepts the call
+func (r *Rule) matches(call Call) bool {
+	if len(r.tools) > 0 && !anyMatch(r.tools, call.ToolName) {
+		return false
+	}
+	if len(r.paths) > 0 && (call.FilePath == "" || !anyMatch(r.paths, filepath.ToSlash(call.FilePath))) {
+		return false
+	}
+	if r.command != nil && (call.Command == "" || !r.command.MatchString(call.Command)) {
+		return false
+	}
+	if len(r.Agents) > 0 {
+		agent := call.Agent
+		if agent == "" {
+			agent = MainAgent
+		}
+		found := false
+		for _, a := range r.Agents {
+			if a == agent {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// reason returns the rule's reason, falling back to its name
+func (r *Rule) reason() string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	if r.Name != "" {
+		return fmt.Sprintf("spcstr policy rule %q", r.Name)
+	}
+	return "spcstr policy"
+}
+
+func anyMatch(patterns []*regexp.Regexp, value string) bool {
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a glob into an anchored regular expression. "*" and
+// "?" stop at path separators when pathAware is set, and "**" spans them.
+func globToRegexp(glob string, pathAware bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	star, single := ".*", "."
+	if pathAware {
+		star, single = "[^/]*", "[^/]"
+	}
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && pathAware && strings.HasPrefix(glob[i:], "**/"):
+			// "**/" matches zero or more leading directories
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && pathAware && strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString(star)
+		case c == '?':
+			b.WriteString(single)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
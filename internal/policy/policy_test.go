@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPolicy = `
+rules:
+  - name: no-force-push
+    decision: deny
+    reason: Force pushes rewrite shared history
+    tools: [Bash]
+    command: 'git\s+push\s+.*--force'
+  - name: env-files
+    decision: ask
+    tools: [Read, Write, Edit, MultiEdit]
+    paths: ["**/.env", "**/.env.*"]
+  - name: allow-docs
+    decision: allow
+    tools: [Write, Edit]
+    paths: ["docs/**"]
+  - name: qa-read-only
+    decision: deny
+    tools: [Write, Edit, MultiEdit]
+    agents: [qa]
+  - name: mcp-ask
+    decision: ask
+    tools: ["mcp__*"]
+`
+
+func TestPolicyEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		call         Call
+		wantMatch    bool
+		wantDecision Decision
+		wantRule     string
+	}{
+		{
+			name:         "bash command regex",
+			call:         Call{ToolName: "Bash", Command: "git push origin main --force"},
+			wantMatch:    true,
+			wantDecision: Deny,
+			wantRule:     "no-force-push",
+		},
+		{
+			name:      "harmless bash command",
+			call:      Call{ToolName: "Bash", Command: "git push origin main"},
+			wantMatch: false,
+		},
+		{
+			name:         "double star matches root file",
+			call:         Call{ToolName: "Read", FilePath: ".env"},
+			wantMatch:    true,
+			wantDecision: Ask,
+			wantRule:     "env-files",
+		},
+		{
+			name:         "double star matches nested file",
+			call:         Call{ToolName: "Edit", FilePath: "config/.env.local"},
+			wantMatch:    true,
+			wantDecision: Ask,
+			wantRule:     "env-files",
+		},
+		{
+			name:         "allow rule",
+			call:         Call{ToolName: "Write", FilePath: "docs/stories/1.1.md"},
+			wantMatch:    true,
+			wantDecision: Allow,
+			wantRule:     "allow-docs",
+		},
+		{
+			name:         "deny outranks allow for the same call",
+			call:         Call{ToolName: "Write", FilePath: "docs/guide.md", Agent: "qa"},
+			wantMatch:    true,
+			wantDecision: Deny,
+			wantRule:     "qa-read-only",
+		},
+		{
+			name:      "agent rule does not apply to main thread",
+			call:      Call{ToolName: "Write", FilePath: "src/main.go"},
+			wantMatch: false,
+		},
+		{
+			name:         "tool glob",
+			call:         Call{ToolName: "mcp__github__create_issue"},
+			wantMatch:    true,
+			wantDecision: Ask,
+			wantRule:     "mcp-ask",
+		},
+		{
+			name:      "path rule needs a path",
+			call:      Call{ToolName: "Read"},
+			wantMatch: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, matched := p.Evaluate(tt.call)
+			if matched != tt.wantMatch {
+				t.Fatalf("Evaluate() matched = %v, want %v (%+v)", matched, tt.wantMatch, result)
+			}
+			if !matched {
+				return
+			}
+			if result.Decision != tt.wantDecision || result.Rule != tt.wantRule {
+				t.Errorf("Evaluate() = %+v, want %s from %s", result, tt.wantDecision, tt.wantRule)
+			}
+			if result.Reason == "" {
+				t.Error("Evaluate() returned an empty reason")
+			}
+		})
+	}
+}
+
+func TestParseInvalidPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{"unknown decision", "rules:\n  - decision: block\n"},
+		{"bad command regex", "rules:\n  - decision: deny\n    command: '('\n"},
+		{"not yaml", "rules: [\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.policy)); err == nil {
+				t.Error("Parse() expected error")
+			}
+		})
+	}
+}
+
+func TestLoadMissingPolicy(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil || p != nil {
+		t.Errorf("Load() = %v, %v; want nil policy without error", p, err)
+	}
+
+	if _, matched := p.Evaluate(Call{ToolName: "Bash"}); matched {
+		t.Error("nil policy should not match")
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(testPolicy), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if p, err := Load(path); err != nil || len(p.Rules) != 5 {
+		t.Errorf("Load() = %v, %v; want 5 rules", p, err)
+	}
+}
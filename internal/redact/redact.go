@@ -0,0 +1,287 @@
+// Package redact replaces secrets in hook input and session data before
+// they are written to .spcstr
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the redaction settings file in the .spcstr directory
+const FileName = "redaction.yaml"
+
+// Counts tallies redactions by detector name
+type Counts map[string]int
+
+// Add accumulates other into c
+func (c Counts) Add(other Counts) {
+	for name, n := range other {
+		c[name] += n
+	}
+}
+
+// Total returns the number of redactions across all detectors
+func (c Counts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+// Names returns the detector names in c, sorted
+func (c Counts) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pattern is a user-defined detector. When the regex has a capture group
+// only the first group is replaced, so context such as a key name survives.
+type Pattern struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// Config selects the detectors applied to hook data
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Disable turns off built-in detectors by name
+	Disable  []string  `yaml:"disable"`
+	Patterns []Pattern `yaml:"patterns"`
+}
+
+// DefaultConfig returns the settings used when no redaction.yaml exists:
+// every built-in detector is on
+func DefaultConfig() Config {
+	return Config{Enabled: true}
+}
+
+// LoadConfig reads redaction.yaml over the defaults. A missing file yields
+// the defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read redaction config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse redaction config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// detector finds one kind of secret
+type detector struct {
+	name string
+	re   *regexp.Regexp
+	// skip rejects matches that look like ordinary values
+	skip func(match string) bool
+}
+
+// builtins are the detectors enabled by default. Patterns with a capture
+// group redact only that group.
+var builtins = []detector{
+	{name: "private_key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws_access_key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github_token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{name: "api_key", re: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`)},
+	{
+		// .env style KEY=value where the name suggests a secret
+		name: "env_secret",
+		re:   regexp.MustCompile(`\b[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)[A-Z0-9_]*\s*[=:]\s*["']?([^\s"'\[]{6,})`),
+		skip: isNumeric,
+	},
+}
+
+// isNumeric reports whether s is all digits, like MAX_TOKENS=100000
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Builtins returns the names of the built-in detectors
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for _, d := range builtins {
+		names = append(names, d.name)
+	}
+	return names
+}
+
+// Redactor replaces secrets with a [REDACTED:<detector>] marker
+type Redactor struct {
+	detectors []detector
+}
+
+// New compiles the detectors selected by cfg. A disabled config yields a
+// Redactor that changes nothing.
+func New(cfg Config) (*Redactor, error) {
+	r := &Redactor{}
+	if !cfg.Enabled {
+		return r, nil
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range cfg.Disable {
+		disabled[name] = true
+	}
+	for _, d := range builtins {
+		if !disabled[d.name] {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+
+	for i, p := range cfg.Patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("redaction pattern %d has no name", i+1)
+		}
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", p.Name, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("redaction pattern %q matches the empty string", p.Name)
+		}
+		r.detectors = append(r.detectors, detector{name: p.Name, re: re})
+	}
+
+	return r, nil
+}
+
+// Load reads redaction.yaml at path and compiles its detectors
+func Load(path string) (*Redactor, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// Default returns a Redactor running every built-in detector, the fallback
+// when redaction.yaml cannot be used
+func Default() *Redactor {
+	return &Redactor{detectors: slices.Clone(builtins)}
+}
+
+// Marker returns the text that replaces a secret found by a detector
+func Marker(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+// String replaces every secret in s
+func (r *Redactor) String(s string) (string, Counts) {
+	counts := Counts{}
+	for _, d := range r.detectors {
+		s = d.replace(s, counts)
+	}
+	return s, counts
+}
+
+// replace substitutes the detector's matches in s, or only the first
+// capture group of each match when the pattern has one
+func (d detector) replace(s string, counts Counts) string {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	marker := Marker(d.name)
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		// Leave secrets already replaced by an earlier detector alone
+		if strings.HasPrefix(s[start:end], "[REDACTED:") || (d.skip != nil && d.skip(s[start:end])) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(marker)
+		last = end
+		counts[d.name]++
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Value replaces secrets in every string of a decoded JSON value, including
+// object keys
+func (r *Redactor) Value(v interface{}) (interface{}, Counts) {
+	counts := Counts{}
+	return r.value(v, counts), counts
+}
+
+func (r *Redactor) value(v interface{}, counts Counts) interface{} {
+	switch v := v.(type) {
+	case string:
+		redacted, c := r.String(v)
+		counts.Add(c)
+		return redacted
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redactedKey, c := r.String(key)
+			counts.Add(c)
+			result[redactedKey] = r.value(item, counts)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = r.value(item, counts)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// JSON replaces secrets in the strings of a JSON document. The document is
+// returned unchanged when nothing was redacted.
+func (r *Redactor) JSON(data []byte) ([]byte, Counts, error) {
+	if len(r.detectors) == 0 || len(bytes.TrimSpace(data)) == 0 {
+		return data, Counts{}, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode JSON for redaction: %w", err)
+	}
+
+	redacted, counts := r.Value(v)
+	if counts.Total() == 0 {
+		return data, counts, nil
+	}
+
+	out, err := json.Marshal(redacted)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode redacted JSON: %w", err)
+	}
+	return out, counts, nil
+}
@@ -0,0 +1,157 @@
+// Package replay re-executes logged hook events against a scratch project,
+// reproducing session state without running Claude Code
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks"
+)
+
+// Filter selects which logged events are replayed
+type Filter struct {
+	// SessionID matches sessions by exact ID or prefix
+	SessionID string
+	Since     time.Time
+	Until     time.Time
+}
+
+// Match reports whether event passes the filter
+func (f Filter) Match(event hooks.HookEvent) bool {
+	if f.SessionID != "" && !strings.HasPrefix(event.SessionID, f.SessionID) {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Options controls playback
+type Options struct {
+	// Speed scales the recorded gaps between events: 1 is real time, 2 is
+	// twice as fast. 0 replays without waiting.
+	Speed float64
+	// MaxGap caps the wait between two events; 0 leaves gaps uncapped
+	MaxGap time.Duration
+	// Progress is called after each event with its index and hook error
+	Progress func(index int, event hooks.HookEvent, err error)
+}
+
+// Result summarizes a replay
+type Result struct {
+	Events   int
+	Failed   int
+	Sessions []string
+}
+
+// Load reads the logged events of every hook in logsDir that pass filter,
+// ordered by timestamp
+func Load(logsDir string, filter Filter) ([]hooks.HookEvent, error) {
+	hookNames, err := hooks.LoggedHooks(logsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []hooks.HookEvent
+	for _, hookName := range hookNames {
+		err := hooks.ReadEvents(logsDir, hookName, func(event hooks.HookEvent) error {
+			if event.HookName == "" {
+				event.HookName = hookName
+			}
+			if filter.Match(event) {
+				events = append(events, event)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s log: %w", hookName, err)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// PrepareTarget creates the .spcstr layout hooks need in targetDir
+func PrepareTarget(targetDir string) error {
+	for _, dir := range []string{"sessions", "logs"} {
+		path := filepath.Join(targetDir, ".spcstr", dir)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Run executes events in order through the hook registry with targetDir as
+// the project root. Hook failures are counted rather than aborting the
+// replay, since the original run may have failed the same way.
+func Run(ctx context.Context, targetDir string, events []hooks.HookEvent, opts Options) (Result, error) {
+	var result Result
+	seen := make(map[string]bool)
+
+	for i, event := range events {
+		if i > 0 {
+			if err := wait(ctx, events[i-1].Timestamp, event.Timestamp, opts); err != nil {
+				return result, err
+			}
+		}
+
+		input, err := json.Marshal(event.InputData)
+		if err != nil {
+			return result, fmt.Errorf("failed to encode input of event %d: %w", i, err)
+		}
+
+		_, hookErr := hooks.ExecuteHookWithOutput(event.HookName, targetDir, input)
+		result.Events++
+		if hookErr != nil {
+			result.Failed++
+		}
+		if event.SessionID != "" && !seen[event.SessionID] {
+			seen[event.SessionID] = true
+			result.Sessions = append(result.Sessions, event.SessionID)
+		}
+		if opts.Progress != nil {
+			opts.Progress(i, event, hookErr)
+		}
+	}
+
+	return result, nil
+}
+
+// wait sleeps for the scaled gap between two recorded events
+func wait(ctx context.Context, previous, next time.Time, opts Options) error {
+	if opts.Speed <= 0 {
+		return ctx.Err()
+	}
+
+	gap := time.Duration(float64(next.Sub(previous)) / opts.Speed)
+	if opts.MaxGap > 0 && gap > opts.MaxGap {
+		gap = opts.MaxGap
+	}
+	if gap <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(gap)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/hooks"
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// writeLog writes events as a hook's active JSON Lines log
+func writeLog(t *testing.T, logsDir, hookName string, events ...hooks.HookEvent) {
+	t.Helper()
+	var lines []string
+	for _, event := range events {
+		event.HookName = hookName
+		line, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+		lines = append(lines, string(line))
+	}
+	path := filepath.Join(logsDir, hookName+hooks.LogFileExt)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+}
+
+// sampleLogs records one session across several hook logs
+func sampleLogs(t *testing.T) (string, time.Time) {
+	t.Helper()
+	logsDir := t.TempDir()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	input := func(fields string) interface{} {
+		var data map[string]interface{}
+		json.Unmarshal([]byte(`{"session_id": "replay-1"`+fields+`}`), &data)
+		return data
+	}
+
+	writeLog(t, logsDir, "session_start",
+		hooks.HookEvent{Timestamp: at(0), SessionID: "replay-1", InputData: input(`, "source": "startup"`)},
+		hooks.HookEvent{Timestamp: at(1), SessionID: "other", InputData: map[string]interface{}{"session_id": "other"}},
+	)
+	writeLog(t, logsDir, "user_prompt_submit",
+		hooks.HookEvent{Timestamp: at(2), SessionID: "replay-1", InputData: input(`, "prompt": "read main.go"`)},
+	)
+	writeLog(t, logsDir, "post_tool_use",
+		hooks.HookEvent{Timestamp: at(4), SessionID: "replay-1", InputData: input(`, "tool_name": "Read", "tool_input": {"file_path": "main.go"}, "tool_response": {}`)},
+	)
+	writeLog(t, logsDir, "pre_tool_use",
+		hooks.HookEvent{Timestamp: at(3), SessionID: "replay-1", InputData: input(`, "tool_name": "Read", "tool_input": {"file_path": "main.go"}`)},
+	)
+	return logsDir, start
+}
+
+func TestLoad(t *testing.T) {
+	logsDir, start := sampleLogs(t)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "all events in time order", want: []string{"session_start", "session_start", "user_prompt_submit", "pre_tool_use", "post_tool_use"}},
+		{name: "session prefix", filter: Filter{SessionID: "replay"}, want: []string{"session_start", "user_prompt_submit", "pre_tool_use", "post_tool_use"}},
+		{name: "time range", filter: Filter{Since: start.Add(time.Second), Until: start.Add(3 * time.Second)}, want: []string{"session_start", "user_prompt_submit", "pre_tool_use"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Load(logsDir, tt.filter)
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			var got []string
+			for _, event := range events {
+				got = append(got, event.HookName)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	logsDir, _ := sampleLogs(t)
+	events, err := Load(logsDir, Filter{SessionID: "replay-1"})
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := PrepareTarget(targetDir); err != nil {
+		t.Fatalf("PrepareTarget() error: %v", err)
+	}
+
+	progress := 0
+	result, err := Run(context.Background(), targetDir, events, Options{
+		Progress: func(int, hooks.HookEvent, error) { progress++ },
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Events != 4 || result.Failed != 0 || progress != 4 || len(result.Sessions) != 1 {
+		t.Errorf("unexpected result: %+v (progress %d)", result, progress)
+	}
+
+	manager := state.NewStateManager(filepath.Join(targetDir, ".spcstr"))
+	sessionState, err := manager.LoadState(context.Background(), "replay-1")
+	if err != nil {
+		t.Fatalf("replayed session missing: %v", err)
+	}
+	if len(sessionState.Prompts) != 1 || sessionState.ToolsUsed["Read"] != 1 || len(sessionState.Files.Read) != 1 {
+		t.Errorf("replayed state incomplete: prompts=%d tools=%v files=%v",
+			len(sessionState.Prompts), sessionState.ToolsUsed, sessionState.Files.Read)
+	}
+}
+
+func TestRunSpeed(t *testing.T) {
+	base := time.Now()
+	events := []hooks.HookEvent{
+		{Timestamp: base, HookName: "missing_hook"},
+		{Timestamp: base.Add(time.Hour), HookName: "missing_hook"},
+	}
+	targetDir := t.TempDir()
+	PrepareTarget(targetDir)
+
+	// A one hour gap capped to 20ms
+	started := time.Now()
+	result, err := Run(context.Background(), targetDir, events, Options{Speed: 1, MaxGap: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 20*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("replay took %v, want about 20ms", elapsed)
+	}
+	if result.Failed != 2 {
+		t.Errorf("Failed = %d, want 2 for unknown hooks", result.Failed)
+	}
+
+	// Cancellation interrupts a long wait
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, targetDir, events, Options{Speed: 1}); err == nil {
+		t.Error("Run() ignored a cancelled context")
+	}
+}
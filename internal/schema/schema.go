@@ -0,0 +1,172 @@
+// Package schema validates decoded JSON against a small subset of JSON
+// Schema: type, required, properties, items, enum and minLength
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema document restricted to the supported keywords
+type Schema struct {
+	// Type is a type name or a list of type names
+	Type       interface{}        `json:"type,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Enum       []interface{}      `json:"enum,omitempty"`
+	MinLength  *int               `json:"minLength,omitempty"`
+}
+
+// Parse decodes a schema document
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if _, err := s.types(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ValidationError lists every way a document violates a schema
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validate checks raw JSON against the schema
+func (s *Schema) Validate(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return &ValidationError{Problems: []string{fmt.Sprintf("not valid JSON: %v", err)}}
+	}
+
+	var problems []string
+	s.validate("$", v, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v interface{}, problems *[]string) {
+	types, _ := s.types()
+	if len(types) > 0 && !matchesType(types, v) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), typeName(v)))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		*problems = append(*problems, fmt.Sprintf("%s: value %v is not allowed", path, v))
+	}
+
+	switch v := v.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			*problems = append(*problems, fmt.Sprintf("%s: shorter than %d characters", path, *s.MinLength))
+		}
+	case map[string]interface{}:
+		for _, key := range s.Required {
+			if _, ok := v[key]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, key))
+			}
+		}
+		keys := make([]string, 0, len(s.Properties))
+		for key := range s.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if value, ok := v[key]; ok {
+				s.Properties[key].validate(path+"."+key, value, problems)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	}
+}
+
+// types returns the schema's allowed type names
+func (s *Schema) types() ([]string, error) {
+	switch t := s.Type.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{t}, nil
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("schema type list must hold strings")
+			}
+			types = append(types, name)
+		}
+		return types, nil
+	default:
+		return nil, fmt.Errorf("schema type must be a string or a list of strings")
+	}
+}
+
+// matchesType reports whether v has one of the JSON types
+func matchesType(types []string, v interface{}) bool {
+	actual := typeName(v)
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON Schema type of a decoded value
+func typeName(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// inEnum reports whether v equals one of the allowed values
+func inEnum(enum []interface{}, v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		// Only scalar enums are supported
+		return false
+	}
+	for _, allowed := range enum {
+		if allowed == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string", "minLength": 2},
+			"count": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"mode": {"enum": ["fast", "slow"]},
+			"note": {"type": ["string", "null"]},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "valid", input: `{"name":"ab","count":3,"ratio":3,"mode":"fast","note":null,"tags":["x"]}`},
+		{name: "missing required", input: `{}`, want: []string{`missing required property "name"`}},
+		{name: "too short", input: `{"name":"a"}`, want: []string{"$.name: shorter than 2 characters"}},
+		{name: "integer expected", input: `{"name":"ab","count":1.5}`, want: []string{"$.count: expected integer, got number"}},
+		{name: "enum", input: `{"name":"ab","mode":"medium"}`, want: []string{"$.mode: value medium is not allowed"}},
+		{name: "array items", input: `{"name":"ab","tags":["x",1]}`, want: []string{"$.tags[1]: expected string, got integer"}},
+		{name: "several problems", input: `{"count":"x","note":1}`, want: []string{"missing required", "$.count", "$.note: expected string or null"}},
+		{name: "wrong root", input: `[]`, want: []string{"$: expected object, got array"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.input))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() accepted invalid input")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsBadType(t *testing.T) {
+	if _, err := Parse([]byte(`{"type": 3}`)); err == nil {
+		t.Error("Parse() accepted a numeric type")
+	}
+}
@@ -0,0 +1,169 @@
+package state
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stateCache keeps session states in memory so a long-running process does
+// not re-read and re-decode state.json on every event. Entries are validated
+// against the snapshot file's identity, size and modification time; journal
+// events recorded since are applied on top. Snapshots are written by renaming
+// a fresh file into place, so writes by other processes are picked up even
+// within the mtime granularity.
+type stateCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedState
+}
+
+// cachedState is a session state, the metadata of the state.json snapshot
+// it was built from and the journal offset that snapshot reflects
+type cachedState struct {
+	state          *SessionState
+	info           os.FileInfo
+	snapshotOffset int64
+}
+
+// sharedCache is the process-wide cache, nil unless EnableCache was called
+var sharedCache atomic.Pointer[stateCache]
+
+// EnableCache turns on the in-memory state cache for every StateManager in
+// this process. It is meant for the hook daemon, which then owns session
+// state: events are only journaled and snapshots are written by
+// FlushSnapshots. Short-lived hook processes gain nothing from it.
+func EnableCache() {
+	sharedCache.CompareAndSwap(nil, &stateCache{entries: make(map[string]cachedState)})
+}
+
+// DisableCache turns the in-memory state cache off and drops its entries
+func DisableCache() {
+	sharedCache.Store(nil)
+}
+
+// get returns a copy of the cached state for path and the journal offset of
+// its snapshot if the file is unchanged
+func (c *stateCache) get(path string, info os.FileInfo) (*SessionState, int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[path]
+	if !ok || !os.SameFile(entry.info, info) || entry.info.Size() != info.Size() ||
+		!entry.info.ModTime().Equal(info.ModTime()) {
+		return nil, 0, false
+	}
+	return entry.state.Clone(), entry.snapshotOffset, true
+}
+
+// put stores a copy of state as built from the snapshot at path, described
+// by info and reflecting the journal up to snapshotOffset. Callers pass
+// metadata taken before reading the file, so a concurrent rewrite can only
+// cause a miss, never a stale hit.
+func (c *stateCache) put(path string, info os.FileInfo, snapshotOffset int64, state *SessionState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = cachedState{
+		state:          state.Clone(),
+		info:           info,
+		snapshotOffset: snapshotOffset,
+	}
+}
+
+// snapshots returns the journal offset reflected by each cached snapshot
+func (c *stateCache) snapshots() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	offsets := make(map[string]int64, len(c.entries))
+	for path, entry := range c.entries {
+		offsets[path] = entry.snapshotOffset
+	}
+	return offsets
+}
+
+// Clone returns a deep copy of the session state
+func (s *SessionState) Clone() *SessionState {
+	clone := *s
+
+	clone.Agents = slices.Clone(s.Agents)
+	clone.AgentsHistory = slices.Clone(s.AgentsHistory)
+	for i := range clone.AgentsHistory {
+		agent := &clone.AgentsHistory[i]
+		agent.CompletedAt = cloneTime(agent.CompletedAt)
+		agent.Usage = cloneUsage(agent.Usage)
+	}
+
+	clone.Files = FileOperations{
+		New:    slices.Clone(s.Files.New),
+		Edited: slices.Clone(s.Files.Edited),
+		Read:   slices.Clone(s.Files.Read),
+	}
+
+	clone.ToolsUsed = maps.Clone(s.ToolsUsed)
+
+	clone.ToolCalls = slices.Clone(s.ToolCalls)
+	for i := range clone.ToolCalls {
+		clone.ToolCalls[i].EndedAt = cloneTime(clone.ToolCalls[i].EndedAt)
+	}
+
+	clone.Commands = slices.Clone(s.Commands)
+	for i := range clone.Commands {
+		if code := clone.Commands[i].ExitCode; code != nil {
+			value := *code
+			clone.Commands[i].ExitCode = &value
+		}
+	}
+
+	clone.Errors = slices.Clone(s.Errors)
+	clone.PolicyDecisions = slices.Clone(s.PolicyDecisions)
+
+	clone.GateResults = slices.Clone(s.GateResults)
+	for i := range clone.GateResults {
+		clone.GateResults[i].Checks = slices.Clone(clone.GateResults[i].Checks)
+	}
+
+	clone.Prompts = slices.Clone(s.Prompts)
+	for i := range clone.Prompts {
+		prompt := &clone.Prompts[i]
+		prompt.ToolsUsed = slices.Clone(prompt.ToolsUsed)
+		prompt.CompletedAt = cloneTime(prompt.CompletedAt)
+		prompt.Usage = cloneUsage(prompt.Usage)
+	}
+
+	clone.Notifications = slices.Clone(s.Notifications)
+	clone.Todos.Recent = slices.Clone(s.Todos.Recent)
+
+	clone.Usage.ByModel = maps.Clone(s.Usage.ByModel)
+	clone.Redactions = maps.Clone(s.Redactions)
+	clone.HookTimings = maps.Clone(s.HookTimings)
+	if s.PluginData != nil {
+		clone.PluginData = make(map[string]map[string]json.RawMessage, len(s.PluginData))
+		for name, section := range s.PluginData {
+			clone.PluginData[name] = maps.Clone(section)
+		}
+	}
+
+	return &clone
+}
+
+// cloneTime copies an optional timestamp
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := *t
+	return &value
+}
+
+// cloneUsage copies optional token usage
+func cloneUsage(u *TokenUsage) *TokenUsage {
+	if u == nil {
+		return nil
+	}
+	value := *u
+	return &value
+}
@@ -0,0 +1,130 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSessionStateClone(t *testing.T) {
+	now := time.Now().UTC()
+	exitCode := 1
+	original := newSessionState("clone_session")
+	original.ToolsUsed["Read"] = 2
+	original.AgentsHistory = []AgentExecution{{ID: "agent-1", Name: "dev", CompletedAt: &now, Usage: &TokenUsage{InputTokens: 10}}}
+	original.Commands = []CommandEntry{{Command: "go test", ExitCode: &exitCode}}
+	original.Prompts = []PromptEntry{{Prompt: "hi", ToolsUsed: []string{"Read"}}}
+	original.GateResults = []GateResult{{Outcome: GateBlocked, Checks: []GateCheck{{Name: "tests"}}}}
+	original.Usage.ByModel["claude-sonnet-4"] = TokenUsage{Messages: 1}
+
+	clone := original.Clone()
+	want, _ := json.Marshal(original)
+	got, _ := json.Marshal(clone)
+	if string(got) != string(want) {
+		t.Fatalf("clone differs from original:\n got %s\nwant %s", got, want)
+	}
+
+	clone.ToolsUsed["Read"] = 99
+	*clone.AgentsHistory[0].CompletedAt = now.Add(time.Hour)
+	clone.AgentsHistory[0].Usage.InputTokens = 99
+	*clone.Commands[0].ExitCode = 99
+	clone.Prompts[0].ToolsUsed[0] = "Bash"
+	clone.GateResults[0].Checks[0].Name = "lint"
+	clone.Usage.ByModel["claude-sonnet-4"] = TokenUsage{Messages: 99}
+
+	after, _ := json.Marshal(original)
+	if string(after) != string(want) {
+		t.Errorf("mutating the clone changed the original:\n got %s\nwant %s", after, want)
+	}
+}
+
+func TestStateCache(t *testing.T) {
+	EnableCache()
+	defer DisableCache()
+
+	ctx := context.Background()
+	manager := NewStateManager(t.TempDir())
+	sessionID := "cached_session"
+	if _, err := manager.InitializeState(ctx, sessionID); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+	if err := manager.AddAgent(ctx, sessionID, "dev"); err != nil {
+		t.Fatalf("AddAgent() error: %v", err)
+	}
+
+	first, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	first.Agents = append(first.Agents, "mutated")
+
+	second, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if len(second.Agents) != 1 || second.Agents[0] != "dev" {
+		t.Errorf("cached state leaked a caller's mutation: %v", second.Agents)
+	}
+
+	// A write that bypasses this process's cache must still be seen
+	external := newSessionState(sessionID)
+	external.Agents = []string{"qa"}
+	external.JournalOffset = second.JournalOffset
+	if err := manager.writer.WriteJSON(ctx, manager.getSessionPath(sessionID), external); err != nil {
+		t.Fatalf("WriteJSON() error: %v", err)
+	}
+	third, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if len(third.Agents) != 1 || third.Agents[0] != "qa" {
+		t.Errorf("stale cache entry served after external write: %v", third.Agents)
+	}
+}
+
+func TestFlushSnapshots(t *testing.T) {
+	EnableCache()
+	defer DisableCache()
+
+	ctx := context.Background()
+	manager := NewStateManager(t.TempDir())
+	sessionID := "flushed_session"
+	if _, err := manager.InitializeState(ctx, sessionID); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+	if err := manager.AddAgent(ctx, sessionID, "dev"); err != nil {
+		t.Fatalf("AddAgent() error: %v", err)
+	}
+	if err := manager.CompletePrompt(ctx, sessionID, PromptCompletedData{}); err != nil {
+		t.Fatalf("CompletePrompt() error: %v", err)
+	}
+
+	snapshot := func() *SessionState {
+		data, err := os.ReadFile(manager.getSessionPath(sessionID))
+		if err != nil {
+			t.Fatalf("Failed to read state.json: %v", err)
+		}
+		state, _, err := decodeState(data)
+		if err != nil {
+			t.Fatalf("Failed to decode state.json: %v", err)
+		}
+		return state
+	}
+
+	// With the cache on, events stay in the journal until a flush
+	if agents := snapshot().Agents; len(agents) != 0 {
+		t.Errorf("state.json written before a flush: agents = %v", agents)
+	}
+	if err := manager.FlushSnapshots(ctx); err != nil {
+		t.Fatalf("FlushSnapshots() error: %v", err)
+	}
+	info, err := os.Stat(manager.journal.Path(sessionID))
+	if err != nil {
+		t.Fatalf("Failed to stat journal: %v", err)
+	}
+	if got := snapshot(); len(got.Agents) != 1 || got.JournalOffset != info.Size() {
+		t.Errorf("flushed state.json = agents %v at offset %d, want the agent at %d", got.Agents, got.JournalOffset, info.Size())
+	}
+}
@@ -0,0 +1,1072 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// JournalFileName is the standard name for session event journals
+	JournalFileName = "events.jsonl"
+	// maxJournalLineSize bounds a single journal record (large prompts included)
+	maxJournalLineSize = 10 * 1024 * 1024
+)
+
+// EventType identifies the kind of record stored in a session journal
+type EventType string
+
+const (
+	EventSessionStarted       EventType = "session_started"
+	EventSessionActiveChanged EventType = "session_active_changed"
+	EventPromptSubmitted      EventType = "prompt_submitted"
+	EventPromptCompleted      EventType = "prompt_completed"
+	EventToolInvoked          EventType = "tool_invoked"
+	EventToolCallStarted      EventType = "tool_call_started"
+	EventToolCallFinished     EventType = "tool_call_finished"
+	EventCommandExecuted      EventType = "command_executed"
+	EventAgentStarted         EventType = "agent_started"
+	EventAgentCompleted       EventType = "agent_completed"
+	EventAgentSpawned         EventType = "agent_spawned"
+	EventAgentFinished        EventType = "agent_finished"
+	EventSubagentStopped      EventType = "subagent_stopped"
+	EventFileRecorded         EventType = "file_recorded"
+	EventErrorRecorded        EventType = "error_recorded"
+	EventPolicyDecided        EventType = "policy_decided"
+	EventGateEvaluated        EventType = "gate_evaluated"
+	EventNotificationReceived EventType = "notification_received"
+	EventTodosUpdated         EventType = "todos_updated"
+	EventUsageRecorded        EventType = "usage_recorded"
+	EventRedactionsRecorded   EventType = "redactions_recorded"
+	EventPluginStatePatched   EventType = "plugin_state_patched"
+	EventHookTimed            EventType = "hook_timed"
+)
+
+// Event is a single typed record in a session journal
+type Event struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Type      EventType       `json:"type"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// PromptCompletedData is the payload of an EventPromptCompleted record
+type PromptCompletedData struct {
+	Response string `json:"response"`
+	Turns    int    `json:"turns"`
+}
+
+// ToolInvokedData is the payload of an EventToolInvoked record
+type ToolInvokedData struct {
+	Tool string `json:"tool"`
+}
+
+// ToolCallStartedData is the payload of an EventToolCallStarted record
+type ToolCallStartedData struct {
+	ID       string `json:"id,omitempty"`
+	ToolName string `json:"tool_name"`
+	Input    string `json:"input"`
+}
+
+// ToolCallFinishedData is the payload of an EventToolCallFinished record
+type ToolCallFinishedData struct {
+	ID       string `json:"id,omitempty"`
+	ToolName string `json:"tool_name"`
+	Input    string `json:"input"`
+	Success  bool   `json:"success"`
+}
+
+// AgentData is the payload of agent lifecycle records
+type AgentData struct {
+	Name string `json:"name"`
+}
+
+// AgentSpawnedData is the payload of an EventAgentSpawned record
+type AgentSpawnedData struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Prompt      string `json:"prompt"`
+	// ParentID is the execution that launched the agent, when the hook input
+	// names one; agents without it belong to the main thread
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// AgentFinishedData is the payload of an EventAgentFinished record
+type AgentFinishedData struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Result      string `json:"result"`
+}
+
+// UsageRecord is the token usage of one assistant message
+type UsageRecord struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Model     string     `json:"model"`
+	Sidechain bool       `json:"sidechain"`
+	Usage     TokenUsage `json:"usage"`
+}
+
+// UsageRecordedData is the payload of an EventUsageRecorded record. It
+// covers the transcript bytes between FromOffset and ToOffset.
+type UsageRecordedData struct {
+	TranscriptPath string        `json:"transcript_path"`
+	FromOffset     int64         `json:"from_offset"`
+	ToOffset       int64         `json:"to_offset"`
+	LastMessageID  string        `json:"last_message_id,omitempty"`
+	LastUsage      TokenUsage    `json:"last_usage,omitzero"`
+	Records        []UsageRecord `json:"records"`
+}
+
+// FileRecordedData is the payload of an EventFileRecorded record
+type FileRecordedData struct {
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+}
+
+// SessionActiveData is the payload of an EventSessionActiveChanged record
+type SessionActiveData struct {
+	Active bool `json:"active"`
+}
+
+// RedactionsRecordedData is the payload of an EventRedactionsRecorded record
+type RedactionsRecordedData struct {
+	Counts map[string]int `json:"counts"`
+}
+
+// PluginStatePatchedData is the payload of an EventPluginStatePatched
+// record. A null value in Patch removes the key.
+type PluginStatePatchedData struct {
+	Plugin string                     `json:"plugin"`
+	Patch  map[string]json.RawMessage `json:"patch"`
+}
+
+// HookTimedData is the payload of an EventHookTimed record
+type HookTimedData struct {
+	Hook       string `json:"hook"`
+	DurationMs int64  `json:"duration_ms"`
+	Success    bool   `json:"success"`
+}
+
+// NewEvent creates a timestamped event with the payload encoded as JSON
+func NewEvent(eventType EventType, payload interface{}) (Event, error) {
+	event := Event{
+		Timestamp: time.Now().UTC(),
+		Type:      eventType,
+	}
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+		}
+		event.Data = data
+	}
+
+	return event, nil
+}
+
+// Journal provides append-only storage of session events as JSON Lines
+type Journal struct {
+	basePath string
+}
+
+// NewJournal creates a Journal rooted at the .spcstr base path
+func NewJournal(basePath string) *Journal {
+	return &Journal{
+		basePath: basePath,
+	}
+}
+
+// Path returns the full path to a session's journal file
+func (j *Journal) Path(sessionID string) string {
+	return filepath.Join(j.basePath, "sessions", sessionID, JournalFileName)
+}
+
+// Append writes a single event to the end of the session journal
+func (j *Journal) Append(ctx context.Context, sessionID string, event Event) error {
+	_, _, err := j.AppendAll(ctx, sessionID, []Event{event})
+	return err
+}
+
+// AppendAll writes events to the end of the session journal in one write
+// and returns the offsets they were written between. Offsets are exact
+// while the session lock is held. Events too large for a journal record
+// are rejected and nothing is written.
+func (j *Journal) AppendAll(ctx context.Context, sessionID string, events []Event) (int64, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return 0, 0, &FileError{
+				Op:  "marshal_event",
+				Err: err,
+			}
+		}
+		if len(line) >= maxJournalLineSize {
+			return 0, 0, &StateError{
+				Code:    "event_too_large",
+				Message: fmt.Sprintf("%s event is %d bytes; journal records are limited to %d", event.Type, len(line), maxJournalLineSize),
+			}
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := j.Path(sessionID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, 0, &FileError{
+			Op:   "create_directory",
+			Path: filepath.Dir(path),
+			Err:  err,
+		}
+	}
+
+	// O_APPEND with a single write keeps records from interleaving
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, 0, &FileError{
+			Op:   "open_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return 0, 0, &FileError{
+			Op:   "append_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, &FileError{
+			Op:   "stat_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	return info.Size() - int64(buf.Len()), info.Size(), nil
+}
+
+// ReadFrom returns the complete events recorded at or after the byte offset
+// and the offset just past the last one. A missing journal has no events.
+// An unterminated final line may still be being written and is left for
+// the next read. Records over the size limit are skipped with a warning.
+func (j *Journal) ReadFrom(ctx context.Context, sessionID string, offset int64) ([]Event, int64, error) {
+	path := j.Path(sessionID)
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, offset, nil
+		}
+		return nil, offset, &FileError{
+			Op:   "open_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, &FileError{
+			Op:   "stat_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+	if info.Size() < offset {
+		return nil, offset, &StateError{
+			Code:    "journal_truncated",
+			Message: fmt.Sprintf("session %s journal is shorter than its state snapshot", sessionID),
+		}
+	}
+	if info.Size() == offset {
+		return nil, offset, nil
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, &FileError{
+			Op:   "seek_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var events []Event
+	var pendingErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, offset, err
+		}
+
+		line, size, err := readJournalLine(reader)
+		if err == io.EOF {
+			break
+		}
+		if err == errJournalLineTooLong {
+			warnSkippedRecord(fmt.Sprintf("%s@%d", path, offset), size)
+			offset += size
+			continue
+		}
+		if err != nil {
+			return nil, offset, &FileError{
+				Op:   "read_journal",
+				Path: path,
+				Err:  err,
+			}
+		}
+
+		// Only a bad line followed by more records is real corruption
+		if pendingErr != nil {
+			return nil, offset, pendingErr
+		}
+
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 {
+			var event Event
+			if err := json.Unmarshal(trimmed, &event); err != nil {
+				pendingErr = &FileError{
+					Op:   "unmarshal_event",
+					Path: fmt.Sprintf("%s@%d", path, offset),
+					Err:  err,
+				}
+				continue
+			}
+			events = append(events, event)
+		}
+		offset += size
+	}
+
+	return events, offset, nil
+}
+
+// errJournalLineTooLong reports a record over maxJournalLineSize
+var errJournalLineTooLong = errors.New("journal record exceeds the size limit")
+
+// readJournalLine reads the next newline-terminated record and the number
+// of bytes it took. A record over maxJournalLineSize is consumed without
+// being kept and reported as errJournalLineTooLong. At the end of the file
+// the unterminated remainder is returned with io.EOF.
+func readJournalLine(reader *bufio.Reader) ([]byte, int64, error) {
+	line, err := reader.ReadSlice('\n')
+	size := int64(len(line))
+	if err != bufio.ErrBufferFull {
+		return line, size, err
+	}
+
+	// Records longer than the buffer are collected in full
+	full := append([]byte(nil), line...)
+	for err == bufio.ErrBufferFull {
+		line, err = reader.ReadSlice('\n')
+		size += int64(len(line))
+		if full != nil && size <= maxJournalLineSize {
+			full = append(full, line...)
+		} else {
+			full = nil
+		}
+	}
+	if full == nil && err == nil {
+		return nil, size, errJournalLineTooLong
+	}
+	return full, size, err
+}
+
+// warnSkippedRecord reports a journal record too large to read
+func warnSkippedRecord(location string, size int64) {
+	fmt.Fprintf(os.Stderr, "Warning: skipping %d byte journal record at %s; records are limited to %d bytes\n", size, location, maxJournalLineSize)
+}
+
+// ReadAll returns every event in the session journal in write order.
+// A malformed final line is treated as a torn write and ignored, and
+// records over the size limit are skipped with a warning.
+func (j *Journal) ReadAll(ctx context.Context, sessionID string) ([]Event, error) {
+	path := j.Path(sessionID)
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &StateError{
+				Code:    "journal_not_found",
+				Message: fmt.Sprintf("session %s has no journal", sessionID),
+			}
+		}
+		return nil, &FileError{
+			Op:   "open_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+
+	var events []Event
+	var pendingErr error
+	lineNum := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		raw, size, err := readJournalLine(reader)
+		atEOF := err == io.EOF
+		if atEOF && len(raw) == 0 {
+			break
+		}
+		lineNum++
+		if err == errJournalLineTooLong {
+			warnSkippedRecord(fmt.Sprintf("%s:%d", path, lineNum), size)
+			continue
+		}
+		if err != nil && !atEOF {
+			return nil, &FileError{
+				Op:   "read_journal",
+				Path: path,
+				Err:  err,
+			}
+		}
+
+		line := bytes.TrimSpace(raw)
+		if len(line) == 0 {
+			if atEOF {
+				break
+			}
+			continue
+		}
+
+		// Only a bad line followed by more records is real corruption
+		if pendingErr != nil {
+			return nil, pendingErr
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			pendingErr = &FileError{
+				Op:   "unmarshal_event",
+				Path: fmt.Sprintf("%s:%d", path, lineNum),
+				Err:  err,
+			}
+			continue
+		}
+		events = append(events, event)
+		if atEOF {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// Reset truncates the session journal, discarding all recorded events
+func (j *Journal) Reset(ctx context.Context, sessionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	path := j.Path(sessionID)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return &FileError{
+			Op:   "reset_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	return nil
+}
+
+// Rewrite replaces the session journal with events, writing a temporary
+// file first so a crash leaves either the old or the new journal
+func (j *Journal) Rewrite(ctx context.Context, sessionID string, events []Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return &FileError{
+				Op:  "marshal_event",
+				Err: err,
+			}
+		}
+	}
+
+	path := j.Path(sessionID)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, buf.Bytes(), 0644); err != nil {
+		return &FileError{
+			Op:   "write_journal",
+			Path: tempPath,
+			Err:  err,
+		}
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return &FileError{
+			Op:   "rename_journal",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	return nil
+}
+
+// Apply folds a journal event into the session state projection
+func (s *SessionState) Apply(event Event) error {
+	switch event.Type {
+	case EventSessionStarted:
+		s.CreatedAt = event.Timestamp
+		s.SessionActive = true
+
+	case EventSessionActiveChanged:
+		var data SessionActiveData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.SessionActive = data.Active
+
+	case EventPromptSubmitted:
+		var prompt PromptEntry
+		if err := decodeEventData(event, &prompt); err != nil {
+			return err
+		}
+		if prompt.ToolsUsed == nil {
+			prompt.ToolsUsed = []string{}
+		}
+		s.Prompts = append(s.Prompts, prompt)
+
+	case EventPromptCompleted:
+		var data PromptCompletedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.completePrompt(data, event.Timestamp)
+
+	case EventToolInvoked:
+		var data ToolInvokedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		if s.ToolsUsed == nil {
+			s.ToolsUsed = make(map[string]int)
+		}
+		s.ToolsUsed[data.Tool]++
+
+	case EventToolCallStarted:
+		var data ToolCallStartedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.ToolCalls = append(s.ToolCalls, ToolCallEntry{
+			ID:        data.ID,
+			ToolName:  data.ToolName,
+			Input:     data.Input,
+			Status:    ToolCallRunning,
+			StartedAt: event.Timestamp,
+		})
+
+	case EventToolCallFinished:
+		var data ToolCallFinishedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.finishToolCall(data, event.Timestamp)
+
+	case EventCommandExecuted:
+		var entry CommandEntry
+		if err := decodeEventData(event, &entry); err != nil {
+			return err
+		}
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = event.Timestamp
+		}
+		if call := s.findFinishedToolCall("Bash", entry.ToolUseID); call != nil {
+			entry.DurationMs = call.DurationMs
+		}
+		s.Commands = append(s.Commands, entry)
+
+	case EventAgentStarted:
+		var data AgentData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		// Add to current agents if not already present
+		for _, agent := range s.Agents {
+			if agent == data.Name {
+				return nil
+			}
+		}
+		s.Agents = append(s.Agents, data.Name)
+		s.AgentsHistory = append(s.AgentsHistory, AgentExecution{
+			ID:        s.nextAgentID(),
+			Name:      data.Name,
+			StartedAt: event.Timestamp,
+		})
+
+	case EventAgentCompleted:
+		var data AgentData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		for i, agent := range s.Agents {
+			if agent == data.Name {
+				s.Agents = append(s.Agents[:i], s.Agents[i+1:]...)
+				break
+			}
+		}
+		completedAt := event.Timestamp
+		for i := range s.AgentsHistory {
+			if s.AgentsHistory[i].Name == data.Name && s.AgentsHistory[i].CompletedAt == nil {
+				s.AgentsHistory[i].CompletedAt = &completedAt
+				break
+			}
+		}
+
+	case EventAgentSpawned:
+		var data AgentSpawnedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.spawnAgent(data, event.Timestamp)
+
+	case EventAgentFinished:
+		var data AgentFinishedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.finishAgent(data, event.Timestamp)
+
+	case EventSubagentStopped:
+		// SubagentStop carries no agent identity, so only an unambiguous
+		// single running agent can be completed here
+		if running := s.soleRunningAgent(); running >= 0 {
+			s.completeAgent(running, "", event.Timestamp)
+		}
+
+	case EventFileRecorded:
+		var data FileRecordedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		switch data.Operation {
+		case "new":
+			s.Files.New = append(s.Files.New, data.Path)
+		case "edited":
+			s.Files.Edited = append(s.Files.Edited, data.Path)
+		case "read":
+			s.Files.Read = append(s.Files.Read, data.Path)
+		default:
+			return &StateError{
+				Code:    "invalid_operation",
+				Message: fmt.Sprintf("invalid file operation: %s", data.Operation),
+			}
+		}
+
+	case EventErrorRecorded:
+		var entry ErrorEntry
+		if err := decodeEventData(event, &entry); err != nil {
+			return err
+		}
+		s.Errors = append(s.Errors, entry)
+
+	case EventPolicyDecided:
+		var decision PolicyDecision
+		if err := decodeEventData(event, &decision); err != nil {
+			return err
+		}
+		if decision.Timestamp.IsZero() {
+			decision.Timestamp = event.Timestamp
+		}
+		s.PolicyDecisions = append(s.PolicyDecisions, decision)
+
+	case EventGateEvaluated:
+		var result GateResult
+		if err := decodeEventData(event, &result); err != nil {
+			return err
+		}
+		if result.Timestamp.IsZero() {
+			result.Timestamp = event.Timestamp
+		}
+		s.GateResults = append(s.GateResults, result)
+
+	case EventNotificationReceived:
+		var entry NotificationEntry
+		if err := decodeEventData(event, &entry); err != nil {
+			return err
+		}
+		s.Notifications = append(s.Notifications, entry)
+
+	case EventTodosUpdated:
+		var todos TodoState
+		if err := decodeEventData(event, &todos); err != nil {
+			return err
+		}
+		s.Todos = todos
+
+	case EventUsageRecorded:
+		var data UsageRecordedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.recordUsage(data)
+
+	case EventRedactionsRecorded:
+		var data RedactionsRecordedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		if s.Redactions == nil {
+			s.Redactions = make(map[string]int)
+		}
+		for name, n := range data.Counts {
+			s.Redactions[name] += n
+		}
+
+	case EventPluginStatePatched:
+		var data PluginStatePatchedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		s.patchPluginData(data)
+
+	case EventHookTimed:
+		var data HookTimedData
+		if err := decodeEventData(event, &data); err != nil {
+			return err
+		}
+		if s.HookTimings == nil {
+			s.HookTimings = make(map[string]HookTiming)
+		}
+		timing := s.HookTimings[data.Hook]
+		timing.Count++
+		if !data.Success {
+			timing.Failures++
+		}
+		timing.TotalMs += data.DurationMs
+		timing.MaxMs = max(timing.MaxMs, data.DurationMs)
+		timing.LastMs = data.DurationMs
+		s.HookTimings[data.Hook] = timing
+
+	default:
+		return &StateError{
+			Code:    "unknown_event",
+			Message: fmt.Sprintf("unknown event type: %s", event.Type),
+		}
+	}
+
+	s.UpdatedAt = event.Timestamp
+	return nil
+}
+
+// patchPluginData merges a plugin's patch into its section of the state
+func (s *SessionState) patchPluginData(data PluginStatePatchedData) {
+	if s.PluginData == nil {
+		s.PluginData = make(map[string]map[string]json.RawMessage)
+	}
+	section := s.PluginData[data.Plugin]
+	if section == nil {
+		section = make(map[string]json.RawMessage)
+	}
+	for key, value := range data.Patch {
+		if value == nil || string(value) == "null" {
+			delete(section, key)
+			continue
+		}
+		section[key] = value
+	}
+	if len(section) == 0 {
+		delete(s.PluginData, data.Plugin)
+		return
+	}
+	s.PluginData[data.Plugin] = section
+}
+
+// finishToolCall closes the running call matching the finish record.
+// Calls are matched by tool_use_id when present, otherwise the oldest
+// running call of the same tool wins. Unmatched finishes are recorded
+// as zero-duration calls so no completion is dropped.
+func (s *SessionState) finishToolCall(data ToolCallFinishedData, endedAt time.Time) {
+	status := ToolCallSucceeded
+	if !data.Success {
+		status = ToolCallFailed
+	}
+
+	match := -1
+	for i := range s.ToolCalls {
+		call := &s.ToolCalls[i]
+		if call.Status != ToolCallRunning {
+			continue
+		}
+		if data.ID != "" {
+			if call.ID == data.ID {
+				match = i
+				break
+			}
+			continue
+		}
+		if call.ToolName == data.ToolName {
+			match = i
+			break
+		}
+	}
+
+	if match < 0 {
+		s.ToolCalls = append(s.ToolCalls, ToolCallEntry{
+			ID:        data.ID,
+			ToolName:  data.ToolName,
+			Input:     data.Input,
+			StartedAt: endedAt,
+		})
+		match = len(s.ToolCalls) - 1
+	}
+
+	call := &s.ToolCalls[match]
+	call.Status = status
+	call.EndedAt = &endedAt
+	call.DurationMs = endedAt.Sub(call.StartedAt).Milliseconds()
+}
+
+// spawnAgent records a new agent execution. Its parent comes only from the
+// spawn record, since timing cannot tell nested launches from parallel ones.
+func (s *SessionState) spawnAgent(data AgentSpawnedData, startedAt time.Time) {
+	id := data.ID
+	if id == "" {
+		id = s.nextAgentID()
+	}
+	for _, agent := range s.AgentsHistory {
+		if agent.ID == id {
+			return
+		}
+	}
+
+	s.Agents = append(s.Agents, data.Name)
+	s.AgentsHistory = append(s.AgentsHistory, AgentExecution{
+		ID:          id,
+		Name:        data.Name,
+		Description: data.Description,
+		Prompt:      data.Prompt,
+		ParentID:    data.ParentID,
+		StartedAt:   startedAt,
+	})
+}
+
+// finishAgent completes the execution matching the finish record. Executions
+// are matched by ID when present, otherwise by type and description, then by
+// type alone. A record with no identity at all only closes a sole running
+// agent. A result for an execution already closed by SubagentStop is
+// attached to it rather than dropped.
+func (s *SessionState) finishAgent(data AgentFinishedData, completedAt time.Time) {
+	if data.ID == "" && data.Name == "" {
+		if running := s.soleRunningAgent(); running >= 0 {
+			s.completeAgent(running, data.Result, completedAt)
+		}
+		return
+	}
+
+	matches := func(agent AgentExecution, byDescription bool) bool {
+		if data.ID != "" {
+			return agent.ID == data.ID
+		}
+		if agent.Name != data.Name {
+			return false
+		}
+		return !byDescription || agent.Description == data.Description
+	}
+
+	for _, byDescription := range []bool{true, false} {
+		for i := range s.AgentsHistory {
+			if s.AgentsHistory[i].Running() && matches(s.AgentsHistory[i], byDescription) {
+				s.completeAgent(i, data.Result, completedAt)
+				return
+			}
+		}
+	}
+
+	for i := len(s.AgentsHistory) - 1; i >= 0; i-- {
+		agent := &s.AgentsHistory[i]
+		if !agent.Running() && agent.Result == "" && matches(*agent, true) {
+			agent.Result = data.Result
+			return
+		}
+	}
+}
+
+// completeAgent closes an execution and drops it from the active list
+func (s *SessionState) completeAgent(index int, result string, completedAt time.Time) {
+	agent := &s.AgentsHistory[index]
+	agent.CompletedAt = &completedAt
+	agent.Result = result
+
+	for i, name := range s.Agents {
+		if name == agent.Name {
+			s.Agents = append(s.Agents[:i], s.Agents[i+1:]...)
+			break
+		}
+	}
+}
+
+// soleRunningAgent returns the index of the only running execution, or -1
+// when none or several are running
+func (s *SessionState) soleRunningAgent() int {
+	running := -1
+	for i := range s.AgentsHistory {
+		if !s.AgentsHistory[i].Running() {
+			continue
+		}
+		if running >= 0 {
+			return -1
+		}
+		running = i
+	}
+	return running
+}
+
+// recordUsage folds transcript usage into the session, prompt and agent
+// totals. Batches that do not continue from the stored offset were read
+// concurrently by another hook and are dropped to avoid double counting.
+func (s *SessionState) recordUsage(data UsageRecordedData) {
+	if data.TranscriptPath != s.Usage.TranscriptPath {
+		s.Usage.TranscriptPath = data.TranscriptPath
+		s.Usage.Offset = 0
+		s.Usage.LastMessageID = ""
+		s.Usage.LastUsage = TokenUsage{}
+	}
+	if data.FromOffset != s.Usage.Offset {
+		return
+	}
+	s.Usage.Offset = data.ToOffset
+	s.Usage.LastMessageID = data.LastMessageID
+	s.Usage.LastUsage = data.LastUsage
+
+	if s.Usage.ByModel == nil {
+		s.Usage.ByModel = make(map[string]TokenUsage)
+	}
+	for _, record := range data.Records {
+		s.Usage.Total.Add(record.Usage)
+
+		byModel := s.Usage.ByModel[record.Model]
+		byModel.Add(record.Usage)
+		s.Usage.ByModel[record.Model] = byModel
+
+		if prompt := s.promptAt(record.Timestamp); prompt != nil {
+			if prompt.Usage == nil {
+				prompt.Usage = &TokenUsage{}
+			}
+			prompt.Usage.Add(record.Usage)
+		}
+		if record.Sidechain {
+			if agent := s.agentAt(record.Timestamp); agent != nil {
+				if agent.Usage == nil {
+					agent.Usage = &TokenUsage{}
+				}
+				agent.Usage.Add(record.Usage)
+			}
+		}
+	}
+}
+
+// completePrompt closes out the most recent prompt with the assistant's
+// answer and the distinct tools called since it was submitted. A prompt
+// that keeps going after a Stop is simply closed again.
+func (s *SessionState) completePrompt(data PromptCompletedData, completedAt time.Time) {
+	if len(s.Prompts) == 0 {
+		return
+	}
+	prompt := &s.Prompts[len(s.Prompts)-1]
+
+	tools := []string{}
+	seen := make(map[string]bool)
+	for _, call := range s.ToolCalls {
+		if call.StartedAt.Before(prompt.Timestamp) || seen[call.ToolName] {
+			continue
+		}
+		seen[call.ToolName] = true
+		tools = append(tools, call.ToolName)
+	}
+
+	prompt.Response = data.Response
+	prompt.Turns = data.Turns
+	prompt.ToolsUsed = tools
+	prompt.CompletedAt = &completedAt
+	prompt.DurationMs = completedAt.Sub(prompt.Timestamp).Milliseconds()
+}
+
+// promptAt returns the latest prompt submitted at or before ts
+func (s *SessionState) promptAt(ts time.Time) *PromptEntry {
+	for i := len(s.Prompts) - 1; i >= 0; i-- {
+		if !s.Prompts[i].Timestamp.After(ts) {
+			return &s.Prompts[i]
+		}
+	}
+	return nil
+}
+
+// agentAt returns the most recently started agent that was running at ts
+func (s *SessionState) agentAt(ts time.Time) *AgentExecution {
+	for i := len(s.AgentsHistory) - 1; i >= 0; i-- {
+		agent := &s.AgentsHistory[i]
+		if agent.StartedAt.After(ts) {
+			continue
+		}
+		if agent.CompletedAt == nil || !agent.CompletedAt.Before(ts) {
+			return agent
+		}
+	}
+	return nil
+}
+
+// nextAgentID generates a deterministic ID for executions without a tool_use_id
+func (s *SessionState) nextAgentID() string {
+	return fmt.Sprintf("agent-%d", len(s.AgentsHistory)+1)
+}
+
+// findFinishedToolCall returns the most recent completed call of a tool,
+// preferring an exact tool_use_id match when one is given
+func (s *SessionState) findFinishedToolCall(toolName, id string) *ToolCallEntry {
+	for i := len(s.ToolCalls) - 1; i >= 0; i-- {
+		call := &s.ToolCalls[i]
+		if call.ToolName != toolName || call.Status == ToolCallRunning {
+			continue
+		}
+		if id == "" || call.ID == id {
+			return call
+		}
+	}
+	return nil
+}
+
+// decodeEventData unmarshals an event payload into the target value
+func decodeEventData(event Event, target interface{}) error {
+	if len(event.Data) == 0 {
+		return &StateError{
+			Code:    "invalid_event",
+			Message: fmt.Sprintf("%s event has no data", event.Type),
+		}
+	}
+	if err := json.Unmarshal(event.Data, target); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+	return nil
+}
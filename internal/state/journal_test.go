@@ -0,0 +1,729 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournal_AppendAndReadAll(t *testing.T) {
+	tmpDir := t.TempDir()
+	journal := NewJournal(tmpDir)
+	ctx := context.Background()
+	sessionID := "journal_session"
+
+	types := []EventType{EventSessionStarted, EventToolInvoked, EventPromptSubmitted}
+	for _, eventType := range types {
+		var payload interface{}
+		switch eventType {
+		case EventToolInvoked:
+			payload = ToolInvokedData{Tool: "Read"}
+		case EventPromptSubmitted:
+			payload = PromptEntry{Prompt: "hello"}
+		}
+
+		event, err := NewEvent(eventType, payload)
+		if err != nil {
+			t.Fatalf("NewEvent(%s) error: %v", eventType, err)
+		}
+		if err := journal.Append(ctx, sessionID, event); err != nil {
+			t.Fatalf("Append(%s) error: %v", eventType, err)
+		}
+	}
+
+	events, err := journal.ReadAll(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+
+	if len(events) != len(types) {
+		t.Fatalf("ReadAll() returned %d events, want %d", len(events), len(types))
+	}
+	for i, event := range events {
+		if event.Type != types[i] {
+			t.Errorf("event[%d].Type = %q, want %q", i, event.Type, types[i])
+		}
+	}
+}
+
+func TestJournal_ReadAllCorruption(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantEvents int
+		wantErr    bool
+	}{
+		{
+			name:       "torn final line is ignored",
+			content:    `{"timestamp":"2025-01-01T00:00:00Z","type":"session_started"}` + "\n" + `{"timestamp":"2025-01-01T00:00:01Z","ty`,
+			wantEvents: 1,
+		},
+		{
+			name:       "blank lines are skipped",
+			content:    "\n" + `{"timestamp":"2025-01-01T00:00:00Z","type":"session_started"}` + "\n\n",
+			wantEvents: 1,
+		},
+		{
+			name:    "corrupt interior line fails",
+			content: `{"timestamp":"2025-01-01T00:00:00Z","type":"session_started"}` + "\nnot json\n" + `{"timestamp":"2025-01-01T00:00:01Z","type":"session_started"}` + "\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			journal := NewJournal(tmpDir)
+			path := journal.Path("corrupt_session")
+
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create session dir: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write journal: %v", err)
+			}
+
+			events, err := journal.ReadAll(context.Background(), "corrupt_session")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ReadAll() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadAll() unexpected error: %v", err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Errorf("ReadAll() returned %d events, want %d", len(events), tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestStateManager_RebuildStateMatchesProjection(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewStateManager(tmpDir)
+	ctx := context.Background()
+	sessionID := "rebuild_session"
+
+	if _, err := manager.InitializeState(ctx, sessionID); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+
+	steps := []func() error{
+		func() error { return manager.IncrementToolUsage(ctx, sessionID, "Task") },
+		func() error { return manager.AddAgent(ctx, sessionID, "dev") },
+		func() error { return manager.RecordFileOperation(ctx, sessionID, "new", "/tmp/a.go") },
+		func() error { return manager.RecordFileOperation(ctx, sessionID, "read", "/tmp/b.go") },
+		func() error { return manager.RecordError(ctx, sessionID, "boom", "Bash", "error") },
+		func() error {
+			return manager.AddPrompt(ctx, sessionID, PromptEntry{Timestamp: time.Now().UTC(), Prompt: "do it"})
+		},
+		func() error {
+			return manager.AddNotification(ctx, sessionID, NotificationEntry{Timestamp: time.Now().UTC(), Message: "waiting"})
+		},
+		func() error {
+			return manager.UpdateTodos(ctx, sessionID, TodoState{Total: 1, Pending: 1, Recent: []TodoItem{{Content: "x", Status: "pending"}}})
+		},
+		func() error { return manager.CompleteAgent(ctx, sessionID, "dev") },
+		func() error { return manager.SetSessionActive(ctx, sessionID, false) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	live, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+
+	// Remove the projection entirely and rebuild it from the journal
+	if err := os.Remove(manager.getSessionPath(sessionID)); err != nil {
+		t.Fatalf("Failed to remove state file: %v", err)
+	}
+
+	fromJournal, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() without state.json error: %v", err)
+	}
+
+	rebuilt, err := manager.RebuildState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("RebuildState() error: %v", err)
+	}
+
+	for name, got := range map[string]*SessionState{"LoadState": fromJournal, "RebuildState": rebuilt} {
+		if !reflect.DeepEqual(got, live) {
+			t.Errorf("%s projection differs from live state\n got: %+v\nwant: %+v", name, got, live)
+		}
+	}
+
+	if _, err := os.Stat(manager.getSessionPath(sessionID)); err != nil {
+		t.Errorf("RebuildState() did not rewrite state.json: %v", err)
+	}
+}
+
+func TestJournal_ReadFrom(t *testing.T) {
+	journal := NewJournal(t.TempDir())
+	ctx := context.Background()
+	sessionID := "tail_session"
+
+	if events, offset, err := journal.ReadFrom(ctx, sessionID, 0); err != nil || len(events) != 0 || offset != 0 {
+		t.Fatalf("ReadFrom() on a missing journal = %d events, %d, %v", len(events), offset, err)
+	}
+
+	first, _ := NewEvent(EventToolInvoked, ToolInvokedData{Tool: "Read"})
+	second, _ := NewEvent(EventToolInvoked, ToolInvokedData{Tool: "Bash"})
+	_, mid, err := journal.AppendAll(ctx, sessionID, []Event{first})
+	if err != nil {
+		t.Fatalf("AppendAll() error: %v", err)
+	}
+	start, end, err := journal.AppendAll(ctx, sessionID, []Event{second})
+	if err != nil || start != mid {
+		t.Fatalf("AppendAll() = %d, %d, %v, want to start at %d", start, end, err, mid)
+	}
+
+	events, offset, err := journal.ReadFrom(ctx, sessionID, mid)
+	if err != nil || len(events) != 1 || offset != end {
+		t.Fatalf("ReadFrom(%d) = %d events, %d, %v, want 1 event up to %d", mid, len(events), offset, err, end)
+	}
+
+	// A record still being written is left for the next read
+	file, _ := os.OpenFile(journal.Path(sessionID), os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"type":"tool_invoked"`)
+	file.Close()
+	if events, offset, err := journal.ReadFrom(ctx, sessionID, end); err != nil || len(events) != 0 || offset != end {
+		t.Errorf("ReadFrom() with a torn tail = %d events, %d, %v, want none up to %d", len(events), offset, err, end)
+	}
+
+	if _, _, err := journal.ReadFrom(ctx, sessionID, end+1024); err == nil {
+		t.Error("ReadFrom() past the end of the journal succeeded")
+	}
+}
+
+func TestJournal_OversizedRecords(t *testing.T) {
+	journal := NewJournal(t.TempDir())
+	ctx := context.Background()
+	sessionID := "oversized_session"
+
+	huge, _ := NewEvent(EventPromptSubmitted, PromptEntry{Prompt: strings.Repeat("x", maxJournalLineSize)})
+	_, _, err := journal.AppendAll(ctx, sessionID, []Event{huge})
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || stateErr.Code != "event_too_large" {
+		t.Fatalf("AppendAll() error = %v, want event_too_large", err)
+	}
+	if _, err := os.Stat(journal.Path(sessionID)); !os.IsNotExist(err) {
+		t.Errorf("rejected event created the journal: %v", err)
+	}
+
+	// A record written before the limit was enforced is skipped on read
+	first, _ := NewEvent(EventToolInvoked, ToolInvokedData{Tool: "Read"})
+	second, _ := NewEvent(EventToolInvoked, ToolInvokedData{Tool: "Bash"})
+	if _, _, err := journal.AppendAll(ctx, sessionID, []Event{first}); err != nil {
+		t.Fatalf("AppendAll() error: %v", err)
+	}
+	file, _ := os.OpenFile(journal.Path(sessionID), os.O_WRONLY|os.O_APPEND, 0644)
+	file.Write(append(bytes.Repeat([]byte("x"), maxJournalLineSize+1), '\n'))
+	file.Close()
+	_, end, err := journal.AppendAll(ctx, sessionID, []Event{second})
+	if err != nil {
+		t.Fatalf("AppendAll() error: %v", err)
+	}
+
+	events, offset, err := journal.ReadFrom(ctx, sessionID, 0)
+	if err != nil || len(events) != 2 || offset != end {
+		t.Errorf("ReadFrom() = %d events, %d, %v; want 2 events up to %d", len(events), offset, err, end)
+	}
+	if events, err := journal.ReadAll(ctx, sessionID); err != nil || len(events) != 2 {
+		t.Errorf("ReadAll() = %d events, %v; want 2", len(events), err)
+	}
+}
+
+func TestStateManager_SnapshotFollowsJournal(t *testing.T) {
+	manager := NewStateManager(t.TempDir())
+	ctx := context.Background()
+	sessionID := "snapshot_session"
+
+	if _, err := manager.InitializeState(ctx, sessionID); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+	if err := manager.AddAgent(ctx, sessionID, "dev"); err != nil {
+		t.Fatalf("AddAgent() error: %v", err)
+	}
+
+	// Mid-turn events are journaled without rewriting state.json
+	snapshot := func() *SessionState {
+		data, err := os.ReadFile(manager.getSessionPath(sessionID))
+		if err != nil {
+			t.Fatalf("Failed to read state.json: %v", err)
+		}
+		state, _, err := decodeState(data)
+		if err != nil {
+			t.Fatalf("Failed to decode state.json: %v", err)
+		}
+		return state
+	}
+	if agents := snapshot().Agents; len(agents) != 0 {
+		t.Errorf("state.json rewritten mid-turn: agents = %v", agents)
+	}
+	loaded, err := manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if len(loaded.Agents) != 1 {
+		t.Errorf("LoadState() agents = %v, want the journaled agent", loaded.Agents)
+	}
+
+	// The end of a turn brings the snapshot up to date
+	if err := manager.CompletePrompt(ctx, sessionID, PromptCompletedData{}); err != nil {
+		t.Fatalf("CompletePrompt() error: %v", err)
+	}
+	if got := snapshot(); len(got.Agents) != 1 || got.JournalOffset == 0 {
+		t.Errorf("state.json after a turn = agents %v at offset %d", got.Agents, got.JournalOffset)
+	}
+
+	// A snapshot from before offsets were recorded covers the whole journal
+	legacy := snapshot()
+	raw, _ := json.Marshal(legacy)
+	var fields map[string]interface{}
+	json.Unmarshal(raw, &fields)
+	delete(fields, "journal_offset")
+	fields["schema_version"] = 2
+	raw, _ = json.Marshal(fields)
+	if err := os.WriteFile(manager.getSessionPath(sessionID), raw, 0644); err != nil {
+		t.Fatalf("Failed to write legacy state: %v", err)
+	}
+	loaded, err = manager.LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() of a legacy snapshot error: %v", err)
+	}
+	if len(loaded.Agents) != 1 || len(loaded.Prompts) != len(legacy.Prompts) {
+		t.Errorf("legacy snapshot replayed the journal again: agents %v", loaded.Agents)
+	}
+}
+
+func TestStateManager_InitializeStateResumesSession(t *testing.T) {
+	manager := NewStateManager(t.TempDir())
+	ctx := context.Background()
+	sessionID := "resumed_session"
+
+	started, err := manager.InitializeState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+	if err := manager.AddAgent(ctx, sessionID, "dev"); err != nil {
+		t.Fatalf("AddAgent() error: %v", err)
+	}
+	if err := manager.SetSessionActive(ctx, sessionID, false); err != nil {
+		t.Fatalf("SetSessionActive() error: %v", err)
+	}
+
+	// A resume or compaction starts the same session again
+	resumed, err := manager.InitializeState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("InitializeState() on resume error: %v", err)
+	}
+	if !resumed.SessionActive || len(resumed.Agents) != 1 || !resumed.CreatedAt.Equal(started.CreatedAt) {
+		t.Errorf("resumed state = active %v, agents %v, created %v; want the original session active again",
+			resumed.SessionActive, resumed.Agents, resumed.CreatedAt)
+	}
+
+	events, err := manager.ReadJournal(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("ReadJournal() error: %v", err)
+	}
+	if len(events) != 4 || events[0].Type != EventSessionStarted {
+		t.Errorf("journal has %d events starting with %s, want the original 4", len(events), events[0].Type)
+	}
+}
+
+func TestStateManager_DeleteStateRemovesJournal(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewStateManager(tmpDir)
+	ctx := context.Background()
+
+	if _, err := manager.InitializeState(ctx, "delete_journal"); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+	if err := manager.DeleteState(ctx, "delete_journal"); err != nil {
+		t.Fatalf("DeleteState() error: %v", err)
+	}
+
+	if _, err := manager.LoadState(ctx, "delete_journal"); err == nil {
+		t.Error("LoadState() after delete should fail, session was rebuilt from journal")
+	}
+}
+
+func TestSessionState_ApplyUnknownEvent(t *testing.T) {
+	state := newSessionState("unknown_event")
+	err := state.Apply(Event{Timestamp: time.Now(), Type: "not_a_real_event"})
+	if err == nil {
+		t.Error("Apply() expected error for unknown event type")
+	}
+}
+
+func TestSessionState_ToolCallPairing(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	event := func(t *testing.T, eventType EventType, ts time.Time, payload interface{}) Event {
+		t.Helper()
+		e, err := NewEvent(eventType, payload)
+		if err != nil {
+			t.Fatalf("NewEvent() error: %v", err)
+		}
+		e.Timestamp = ts
+		return e
+	}
+
+	tests := []struct {
+		name      string
+		events    func(t *testing.T) []Event
+		wantCalls []ToolCallEntry
+	}{
+		{
+			name: "matched by tool_use_id out of order",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventToolCallStarted, at(0), ToolCallStartedData{ID: "a", ToolName: "Bash", Input: "ls"}),
+					event(t, EventToolCallStarted, at(1), ToolCallStartedData{ID: "b", ToolName: "Bash", Input: "pwd"}),
+					event(t, EventToolCallFinished, at(3), ToolCallFinishedData{ID: "b", ToolName: "Bash", Success: true}),
+					event(t, EventToolCallFinished, at(5), ToolCallFinishedData{ID: "a", ToolName: "Bash", Success: false}),
+				}
+			},
+			wantCalls: []ToolCallEntry{
+				{ID: "a", ToolName: "Bash", Input: "ls", Status: ToolCallFailed, DurationMs: 5000},
+				{ID: "b", ToolName: "Bash", Input: "pwd", Status: ToolCallSucceeded, DurationMs: 2000},
+			},
+		},
+		{
+			name: "falls back to oldest running call of the same tool",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventToolCallStarted, at(0), ToolCallStartedData{ToolName: "Read", Input: "a.go"}),
+					event(t, EventToolCallStarted, at(1), ToolCallStartedData{ToolName: "Grep", Input: "foo"}),
+					event(t, EventToolCallStarted, at(2), ToolCallStartedData{ToolName: "Read", Input: "b.go"}),
+					event(t, EventToolCallFinished, at(4), ToolCallFinishedData{ToolName: "Read", Success: true}),
+				}
+			},
+			wantCalls: []ToolCallEntry{
+				{ToolName: "Read", Input: "a.go", Status: ToolCallSucceeded, DurationMs: 4000},
+				{ToolName: "Grep", Input: "foo", Status: ToolCallRunning},
+				{ToolName: "Read", Input: "b.go", Status: ToolCallRunning},
+			},
+		},
+		{
+			name: "unmatched finish is recorded",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventToolCallFinished, at(1), ToolCallFinishedData{ToolName: "Write", Input: "c.go", Success: true}),
+				}
+			},
+			wantCalls: []ToolCallEntry{
+				{ToolName: "Write", Input: "c.go", Status: ToolCallSucceeded},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newSessionState("tool_calls")
+			for _, e := range tt.events(t) {
+				if err := state.Apply(e); err != nil {
+					t.Fatalf("Apply() error: %v", err)
+				}
+			}
+
+			if len(state.ToolCalls) != len(tt.wantCalls) {
+				t.Fatalf("got %d tool calls, want %d", len(state.ToolCalls), len(tt.wantCalls))
+			}
+			for i, want := range tt.wantCalls {
+				got := state.ToolCalls[i]
+				if got.ID != want.ID || got.ToolName != want.ToolName || got.Input != want.Input ||
+					got.Status != want.Status || got.DurationMs != want.DurationMs {
+					t.Errorf("ToolCalls[%d] = %+v, want %+v", i, got, want)
+				}
+				if (got.Status == ToolCallRunning) != (got.EndedAt == nil) {
+					t.Errorf("ToolCalls[%d] EndedAt = %v inconsistent with status %s", i, got.EndedAt, got.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionState_AgentTree(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	event := func(t *testing.T, eventType EventType, ts time.Time, payload interface{}) Event {
+		t.Helper()
+		e, err := NewEvent(eventType, payload)
+		if err != nil {
+			t.Fatalf("NewEvent() error: %v", err)
+		}
+		e.Timestamp = ts
+		return e
+	}
+
+	type wantAgent struct {
+		ID       string
+		Name     string
+		ParentID string
+		Running  bool
+		Result   string
+	}
+
+	tests := []struct {
+		name       string
+		events     func(t *testing.T) []Event
+		wantAgents []wantAgent
+		wantActive []string
+	}{
+		{
+			name: "parallel agents of the same type complete by id",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t1", Name: "dev", Description: "api"}),
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t2", Name: "dev", Description: "ui"}),
+					event(t, EventAgentFinished, at(30), AgentFinishedData{ID: "t2", Name: "dev", Result: "ui done"}),
+				}
+			},
+			wantAgents: []wantAgent{
+				{ID: "t1", Name: "dev", Running: true},
+				{ID: "t2", Name: "dev", Result: "ui done"},
+			},
+			wantActive: []string{"dev"},
+		},
+		{
+			name: "late launch stays on the main thread",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t1", Name: "sm"}),
+					event(t, EventAgentSpawned, at(10), AgentSpawnedData{ID: "t2", Name: "qa"}),
+				}
+			},
+			wantAgents: []wantAgent{
+				{ID: "t1", Name: "sm", Running: true},
+				{ID: "t2", Name: "qa", Running: true},
+			},
+			wantActive: []string{"sm", "qa"},
+		},
+		{
+			name: "recorded parent nests the agent",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t1", Name: "sm"}),
+					event(t, EventAgentSpawned, at(10), AgentSpawnedData{ID: "t2", Name: "qa", ParentID: "t1"}),
+				}
+			},
+			wantAgents: []wantAgent{
+				{ID: "t1", Name: "sm", Running: true},
+				{ID: "t2", Name: "qa", ParentID: "t1", Running: true},
+			},
+			wantActive: []string{"sm", "qa"},
+		},
+		{
+			name: "without ids matches type and description",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{Name: "dev", Description: "api"}),
+					event(t, EventAgentSpawned, at(1), AgentSpawnedData{Name: "dev", Description: "ui"}),
+					event(t, EventAgentFinished, at(5), AgentFinishedData{Name: "dev", Description: "ui", Result: "ok"}),
+				}
+			},
+			wantAgents: []wantAgent{
+				{ID: "agent-1", Name: "dev", Running: true},
+				{ID: "agent-2", Name: "dev", Result: "ok"},
+			},
+			wantActive: []string{"dev"},
+		},
+		{
+			name: "subagent stop is ignored while several agents run",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t1", Name: "dev"}),
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t2", Name: "qa"}),
+					event(t, EventSubagentStopped, at(5), nil),
+				}
+			},
+			wantAgents: []wantAgent{
+				{ID: "t1", Name: "dev", Running: true},
+				{ID: "t2", Name: "qa", Running: true},
+			},
+			wantActive: []string{"dev", "qa"},
+		},
+		{
+			name: "result attaches after subagent stop closed the agent",
+			events: func(t *testing.T) []Event {
+				return []Event{
+					event(t, EventAgentSpawned, at(0), AgentSpawnedData{ID: "t1", Name: "dev"}),
+					event(t, EventSubagentStopped, at(5), nil),
+					event(t, EventAgentFinished, at(6), AgentFinishedData{ID: "t1", Name: "dev", Result: "done"}),
+				}
+			},
+			wantAgents: []wantAgent{
+				{ID: "t1", Name: "dev", Result: "done"},
+			},
+			wantActive: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newSessionState("agent_tree")
+			for _, e := range tt.events(t) {
+				if err := state.Apply(e); err != nil {
+					t.Fatalf("Apply() error: %v", err)
+				}
+			}
+
+			if len(state.AgentsHistory) != len(tt.wantAgents) {
+				t.Fatalf("got %d agents, want %d", len(state.AgentsHistory), len(tt.wantAgents))
+			}
+			for i, want := range tt.wantAgents {
+				got := state.AgentsHistory[i]
+				if got.ID != want.ID || got.Name != want.Name || got.ParentID != want.ParentID ||
+					got.Running() != want.Running || got.Result != want.Result {
+					t.Errorf("AgentsHistory[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+			if !reflect.DeepEqual(state.Agents, tt.wantActive) {
+				t.Errorf("Agents = %v, want %v", state.Agents, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestSessionState_RecordUsage(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	completed := at(30)
+
+	state := newSessionState("usage")
+	state.Prompts = []PromptEntry{{Timestamp: at(0), Prompt: "first"}, {Timestamp: at(60), Prompt: "second"}}
+	state.AgentsHistory = []AgentExecution{{ID: "t1", Name: "dev", StartedAt: at(10), CompletedAt: &completed}}
+
+	batch := UsageRecordedData{
+		TranscriptPath: "/tmp/t.jsonl",
+		FromOffset:     0,
+		ToOffset:       500,
+		LastMessageID:  "msg_3",
+		Records: []UsageRecord{
+			{Timestamp: at(5), Model: "sonnet", Usage: TokenUsage{Messages: 1, InputTokens: 10, CostUSD: 0.1}},
+			{Timestamp: at(20), Model: "haiku", Sidechain: true, Usage: TokenUsage{Messages: 1, OutputTokens: 5, CostUSD: 0.01}},
+			{Timestamp: at(70), Model: "sonnet", Usage: TokenUsage{Messages: 1, InputTokens: 30, CostUSD: 0.3}},
+		},
+	}
+	stale := batch
+	stale.Records = batch.Records[:1]
+
+	for _, data := range []UsageRecordedData{batch, stale} {
+		event, err := NewEvent(EventUsageRecorded, data)
+		if err != nil {
+			t.Fatalf("NewEvent() error: %v", err)
+		}
+		if err := state.Apply(event); err != nil {
+			t.Fatalf("Apply() error: %v", err)
+		}
+	}
+
+	if state.Usage.Offset != 500 || state.Usage.LastMessageID != "msg_3" {
+		t.Errorf("cursor = %d/%s, want 500/msg_3", state.Usage.Offset, state.Usage.LastMessageID)
+	}
+	if got := state.Usage.Total; got.Messages != 3 || got.TotalTokens() != 45 {
+		t.Errorf("Total = %+v, want 3 messages and 45 tokens (stale batch dropped)", got)
+	}
+	if got := state.Usage.ByModel["sonnet"]; got.InputTokens != 40 {
+		t.Errorf("ByModel[sonnet] = %+v, want 40 input tokens", got)
+	}
+	if p := state.Prompts[0].Usage; p == nil || p.Messages != 2 {
+		t.Errorf("first prompt usage = %+v, want 2 messages", p)
+	}
+	if p := state.Prompts[1].Usage; p == nil || p.InputTokens != 30 {
+		t.Errorf("second prompt usage = %+v, want 30 input tokens", p)
+	}
+	if a := state.AgentsHistory[0].Usage; a == nil || a.OutputTokens != 5 {
+		t.Errorf("agent usage = %+v, want sidechain output tokens", a)
+	}
+}
+
+func TestSessionState_CompletePrompt(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	state := newSessionState("prompt_pairing")
+	state.Prompts = []PromptEntry{{Timestamp: at(0), Prompt: "old"}, {Timestamp: at(100), Prompt: "new"}}
+	state.ToolCalls = []ToolCallEntry{
+		{ToolName: "Grep", StartedAt: at(50)},
+		{ToolName: "Read", StartedAt: at(101)},
+		{ToolName: "Bash", StartedAt: at(102)},
+		{ToolName: "Read", StartedAt: at(103)},
+	}
+
+	event, err := NewEvent(EventPromptCompleted, PromptCompletedData{Response: "done", Turns: 3})
+	if err != nil {
+		t.Fatalf("NewEvent() error: %v", err)
+	}
+	event.Timestamp = at(130)
+	if err := state.Apply(event); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	got := state.Prompts[1]
+	if got.Response != "done" || got.Turns != 3 || got.DurationMs != 30000 || got.CompletedAt == nil {
+		t.Errorf("completed prompt = %+v", got)
+	}
+	if !reflect.DeepEqual(got.ToolsUsed, []string{"Read", "Bash"}) {
+		t.Errorf("ToolsUsed = %v, want [Read Bash]", got.ToolsUsed)
+	}
+	if state.Prompts[0].CompletedAt != nil {
+		t.Error("earlier prompt should be left untouched")
+	}
+}
+
+func TestSessionState_GateBlocks(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	tests := []struct {
+		name     string
+		outcomes []string
+		promptAt int
+		want     int
+	}{
+		{name: "no results", want: 0},
+		{name: "consecutive blocks", outcomes: []string{GateBlocked, GateBlocked}, want: 2},
+		{name: "pass resets the count", outcomes: []string{GateBlocked, GatePassed, GateBlocked}, want: 1},
+		{name: "blocks before the prompt are ignored", outcomes: []string{GateBlocked, GateBlocked, GateBlocked}, promptAt: 2, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newSessionState("gate_blocks")
+			state.Prompts = []PromptEntry{{Timestamp: at(tt.promptAt), Prompt: "go"}}
+			for i, outcome := range tt.outcomes {
+				event, err := NewEvent(EventGateEvaluated, GateResult{Outcome: outcome, Iteration: i + 1})
+				if err != nil {
+					t.Fatalf("NewEvent() error: %v", err)
+				}
+				event.Timestamp = at(i)
+				if err := state.Apply(event); err != nil {
+					t.Fatalf("Apply() error: %v", err)
+				}
+			}
+
+			if got := state.GateBlocks(); got != tt.want {
+				t.Errorf("GateBlocks() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
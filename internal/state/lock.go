@@ -0,0 +1,106 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// LockFileName is the per-session advisory lock file
+	LockFileName = "state.lock"
+	// lockRetryInterval is the initial delay between lock attempts
+	lockRetryInterval = 2 * time.Millisecond
+	// maxLockRetryInterval caps the backoff between lock attempts
+	maxLockRetryInterval = 50 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when a session lock cannot be acquired in time
+var ErrLockTimeout = &StateError{Code: "lock_timeout", Message: "timed out waiting for session lock"}
+
+// sessionLock is an exclusive advisory lock held on a session directory.
+// It serializes read-modify-write cycles across concurrent hook processes.
+type sessionLock struct {
+	file *os.File
+}
+
+// acquireSessionLock blocks until the lock file at path is held exclusively
+// or the context expires
+func acquireSessionLock(ctx context.Context, path string) (*sessionLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, &FileError{
+			Op:   "open_lock_file",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	delay := lockRetryInterval
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, &FileError{
+				Op:   "lock_file",
+				Path: path,
+				Err:  err,
+			}
+		}
+		if locked {
+			return &sessionLock{file: file}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, fmt.Errorf("%w: %s", ErrLockTimeout, filepath.Dir(path))
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxLockRetryInterval {
+			delay = maxLockRetryInterval
+		}
+	}
+}
+
+// Release unlocks and closes the lock file
+func (l *sessionLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	unlockErr := unlockFile(l.file)
+	closeErr := l.file.Close()
+	l.file = nil
+	if unlockErr != nil {
+		return unlockErr
+	}
+	return closeErr
+}
+
+// FileLock is an exclusive advisory lock on a file shared by hook processes
+// outside a session directory, such as project-wide metrics
+type FileLock struct {
+	lock *sessionLock
+}
+
+// AcquireFileLock blocks until the lock file at path is held exclusively
+// or the context expires
+func AcquireFileLock(ctx context.Context, path string) (*FileLock, error) {
+	lock, err := acquireSessionLock(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLock{lock: lock}, nil
+}
+
+// Release unlocks and closes the lock file
+func (l *FileLock) Release() error {
+	if l == nil {
+		return nil
+	}
+	return l.lock.Release()
+}
@@ -0,0 +1,34 @@
+//go:build !unix
+
+package state
+
+import (
+	"os"
+	"sync"
+)
+
+// Platforms without flock fall back to an in-process lock keyed by path.
+// This still serializes goroutines but not separate hook processes.
+var (
+	fallbackLocksMu sync.Mutex
+	fallbackLocks   = make(map[string]bool)
+)
+
+// tryLockFile attempts to take the in-process lock for the file path
+func tryLockFile(file *os.File) (bool, error) {
+	fallbackLocksMu.Lock()
+	defer fallbackLocksMu.Unlock()
+	if fallbackLocks[file.Name()] {
+		return false, nil
+	}
+	fallbackLocks[file.Name()] = true
+	return true, nil
+}
+
+// unlockFile releases the in-process lock for the file path
+func unlockFile(file *os.File) error {
+	fallbackLocksMu.Lock()
+	defer fallbackLocksMu.Unlock()
+	delete(fallbackLocks, file.Name())
+	return nil
+}
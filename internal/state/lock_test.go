@@ -0,0 +1,100 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStateManager_ConcurrentUpdatesNotLost(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	sessionID := "concurrent_session"
+
+	if _, err := NewStateManager(tmpDir).InitializeState(ctx, sessionID); err != nil {
+		t.Fatalf("InitializeState() error: %v", err)
+	}
+
+	const workers = 20
+	const perWorker = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Separate managers open separate lock file descriptors,
+			// matching how independent hook processes behave
+			manager := NewStateManager(tmpDir)
+			for i := 0; i < perWorker; i++ {
+				if err := manager.IncrementToolUsage(ctx, sessionID, "Bash"); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("IncrementToolUsage() error: %v", err)
+	}
+
+	state, err := NewStateManager(tmpDir).LoadState(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if got := state.ToolsUsed["Bash"]; got != workers*perWorker {
+		t.Errorf("ToolsUsed[Bash] = %d, want %d", got, workers*perWorker)
+	}
+
+	events, err := NewStateManager(tmpDir).ReadJournal(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("ReadJournal() error: %v", err)
+	}
+	if len(events) != workers*perWorker+1 {
+		t.Errorf("journal has %d events, want %d", len(events), workers*perWorker+1)
+	}
+}
+
+func TestAcquireSessionLock_Timeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFileName)
+
+	held, err := acquireSessionLock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("acquireSessionLock() error: %v", err)
+	}
+	defer held.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	if _, err := acquireSessionLock(ctx, path); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("acquireSessionLock() error = %v, want ErrLockTimeout", err)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+
+	again, err := acquireSessionLock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("acquireSessionLock() after release error: %v", err)
+	}
+	again.Release()
+}
+
+func TestStateManager_RecordEventMissingSession(t *testing.T) {
+	manager := NewStateManager(t.TempDir())
+
+	err := manager.SetSessionActive(context.Background(), "missing", false)
+
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || stateErr.Code != "session_not_found" {
+		t.Errorf("SetSessionActive() error = %v, want session_not_found", err)
+	}
+}
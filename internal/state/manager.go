@@ -3,10 +3,15 @@ package state
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/dylan/spcstr/internal/redact"
 )
 
 const (
@@ -14,28 +19,47 @@ const (
 	DefaultTimeout = 5 * time.Second
 	// StateFileName is the standard name for session state files
 	StateFileName = "state.json"
+	// snapshotInterval is how far the journal may grow past state.json
+	// before the snapshot is rewritten mid-turn
+	snapshotInterval = 128 * 1024
 )
 
 // StateManager provides CRUD operations for session state
 type StateManager struct {
 	writer   *AtomicWriter
+	journal  *Journal
 	basePath string
 	timeout  time.Duration
+
+	// redaction settings are read from basePath on first write
+	redactOnce sync.Once
+	redactor   *redact.Redactor
+}
+
+// operationTimeout overrides DefaultTimeout for NewStateManager when set
+var operationTimeout atomic.Int64
+
+// SetDefaultTimeout changes the timeout of StateManagers created by
+// NewStateManager in this process, as configured by state.timeout. A
+// non-positive timeout restores DefaultTimeout.
+func SetDefaultTimeout(timeout time.Duration) {
+	operationTimeout.Store(int64(timeout))
 }
 
 // NewStateManager creates a new StateManager with the specified base path
 func NewStateManager(basePath string) *StateManager {
-	return &StateManager{
-		writer:   NewAtomicWriter(DefaultTimeout),
-		basePath: basePath,
-		timeout:  DefaultTimeout,
+	timeout := DefaultTimeout
+	if configured := time.Duration(operationTimeout.Load()); configured > 0 {
+		timeout = configured
 	}
+	return NewStateManagerWithTimeout(basePath, timeout)
 }
 
 // NewStateManagerWithTimeout creates a StateManager with custom timeout
 func NewStateManagerWithTimeout(basePath string, timeout time.Duration) *StateManager {
 	return &StateManager{
 		writer:   NewAtomicWriter(timeout),
+		journal:  NewJournal(basePath),
 		basePath: basePath,
 		timeout:  timeout,
 	}
@@ -50,37 +74,61 @@ func (sm *StateManager) InitializeState(ctx context.Context, sessionID string) (
 		}
 	}
 
-	// Create session state with current timestamp
-	now := time.Now().UTC()
-	state := &SessionState{
-		SessionID:     sessionID,
-		CreatedAt:     now,
-		UpdatedAt:     now,
-		SessionActive: true,
-		Agents:        make([]string, 0),
-		AgentsHistory: make([]AgentExecution, 0),
-		Files: FileOperations{
-			New:    make([]string, 0),
-			Edited: make([]string, 0),
-			Read:   make([]string, 0),
-		},
-		ToolsUsed:     make(map[string]int),
-		Errors:        make([]ErrorEntry, 0),
-		Prompts:       make([]PromptEntry, 0),
-		Notifications: make([]NotificationEntry, 0),
-		Todos: TodoState{
-			Total:       0,
-			Pending:     0,
-			InProgress:  0,
-			Completed:   0,
-			Recent:      make([]TodoItem, 0),
-			LastUpdated: "",
-		},
+	// Hold the session lock while the journal and state are reset
+	if err := os.MkdirAll(sm.getSessionDir(sessionID), 0755); err != nil {
+		return nil, &FileError{
+			Op:   "create_directory",
+			Path: sm.getSessionDir(sessionID),
+			Err:  err,
+		}
+	}
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	// A resumed or compacted session keeps its journal and is only
+	// marked active again
+	if sm.sessionExists(sessionID) {
+		activeEvent, err := NewEvent(EventSessionActiveChanged, SessionActiveData{Active: true})
+		if err != nil {
+			return nil, err
+		}
+		if _, _, err := sm.journal.AppendAll(ctx, sessionID, []Event{activeEvent}); err != nil {
+			return nil, fmt.Errorf("failed to write journal: %w", err)
+		}
+		state, err := sm.LoadState(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := sm.saveState(ctx, sessionID, state); err != nil {
+			return nil, fmt.Errorf("failed to initialize state: %w", err)
+		}
+		return state, nil
+	}
+
+	// Start a fresh journal and project the initial state from it
+	startEvent, err := NewEvent(EventSessionStarted, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := sm.journal.Reset(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to reset journal: %w", err)
+	}
+	_, end, err := sm.journal.AppendAll(ctx, sessionID, []Event{startEvent})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	state := newSessionState(sessionID)
+	if err := state.Apply(startEvent); err != nil {
+		return nil, err
 	}
+	state.JournalOffset = end
 
 	// Create session directory and write initial state
-	sessionPath := sm.getSessionPath(sessionID)
-	if err := sm.writer.WriteJSON(ctx, sessionPath, state); err != nil {
+	if err := sm.saveState(ctx, sessionID, state); err != nil {
 		return nil, fmt.Errorf("failed to initialize state: %w", err)
 	}
 
@@ -98,71 +146,66 @@ func (sm *StateManager) LoadState(ctx context.Context, sessionID string) (*Sessi
 
 	sessionPath := sm.getSessionPath(sessionID)
 
-	// Check if file exists
-	if _, err := os.Stat(sessionPath); os.IsNotExist(err) {
+	// Check if file exists, falling back to the journal projection
+	info, err := os.Stat(sessionPath)
+	if os.IsNotExist(err) {
+		if _, err := os.Stat(sm.journal.Path(sessionID)); err == nil {
+			return sm.projectState(ctx, sessionID)
+		}
 		return nil, &StateError{
 			Code:    "session_not_found",
 			Message: fmt.Sprintf("session %s does not exist", sessionID),
 		}
 	}
 
-	// Create context with timeout for read operation
-	readCtx, cancel := context.WithTimeout(ctx, sm.timeout)
-	defer cancel()
-
-	// Read file with context
-	data, err := sm.readFileWithContext(readCtx, sessionPath)
-	if err != nil {
-		return nil, &FileError{
-			Op:   "read_state_file",
-			Path: sessionPath,
-			Err:  err,
+	// Serve an unchanged snapshot from memory when the cache is enabled
+	cache := sharedCache.Load()
+	var state *SessionState
+	var snapshotOffset int64
+	if cache != nil && err == nil {
+		if cached, offset, ok := cache.get(sessionPath, info); ok {
+			state, snapshotOffset = cached, offset
 		}
 	}
 
-	// Unmarshal JSON data
-	var state SessionState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, &FileError{
-			Op:   "unmarshal_json",
-			Path: sessionPath,
-			Err:  err,
+	if state == nil {
+		// Create context with timeout for read operation
+		readCtx, cancel := context.WithTimeout(ctx, sm.timeout)
+		defer cancel()
+
+		// Read file with context
+		data, err := sm.readFileWithContext(readCtx, sessionPath)
+		if err != nil {
+			return nil, &FileError{
+				Op:   "read_state_file",
+				Path: sessionPath,
+				Err:  err,
+			}
 		}
-	}
 
-	return &state, nil
-}
-
-// UpdateState atomically updates an existing session state
-func (sm *StateManager) UpdateState(ctx context.Context, sessionID string, updateFunc func(*SessionState) error) error {
-	if sessionID == "" {
-		return &StateError{
-			Code:    "invalid_session_id",
-			Message: "session ID cannot be empty",
+		// Unmarshal JSON data, upgrading older schema versions in memory
+		state, _, err = decodeState(data)
+		if err != nil {
+			return nil, &FileError{
+				Op:   "unmarshal_json",
+				Path: sessionPath,
+				Err:  err,
+			}
 		}
+		sm.resolveJournalOffset(sessionID, state)
+		snapshotOffset = state.JournalOffset
 	}
 
-	// Load current state
-	state, err := sm.LoadState(ctx, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to load state for update: %w", err)
+	// Fold in the events recorded since the snapshot was written
+	if err := sm.applyJournalTail(ctx, sessionID, state); err != nil {
+		return nil, err
 	}
 
-	// Apply update function
-	if err := updateFunc(state); err != nil {
-		return fmt.Errorf("update function failed: %w", err)
+	if cache != nil && info != nil {
+		cache.put(sessionPath, info, snapshotOffset, state)
 	}
 
-	// Update timestamp
-	state.UpdatedAt = time.Now().UTC()
-
-	// Write updated state atomically
-	sessionPath := sm.getSessionPath(sessionID)
-	if err := sm.writer.WriteJSON(ctx, sessionPath, state); err != nil {
-		return fmt.Errorf("failed to write updated state: %w", err)
-	}
-
-	return nil
+	return state, nil
 }
 
 // DeleteState removes a session state file
@@ -184,6 +227,12 @@ func (sm *StateManager) DeleteState(ctx context.Context, sessionID string) error
 		}
 	}
 
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	// Remove the state file
 	if err := os.Remove(sessionPath); err != nil {
 		return &FileError{
@@ -193,9 +242,52 @@ func (sm *StateManager) DeleteState(ctx context.Context, sessionID string) error
 		}
 	}
 
+	// Remove the journal so the session cannot be rebuilt
+	if err := sm.journal.Reset(ctx, sessionID); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// RebuildState replays the session journal and rewrites state.json from it
+func (sm *StateManager) RebuildState(ctx context.Context, sessionID string) (*SessionState, error) {
+	if sessionID == "" {
+		return nil, &StateError{
+			Code:    "invalid_session_id",
+			Message: "session ID cannot be empty",
+		}
+	}
+
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	state, err := sm.projectState(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sm.saveState(ctx, sessionID, state); err != nil {
+		return nil, fmt.Errorf("failed to write rebuilt state: %w", err)
+	}
+
+	return state, nil
+}
+
+// ReadJournal returns all recorded events for a session in write order
+func (sm *StateManager) ReadJournal(ctx context.Context, sessionID string) ([]Event, error) {
+	if sessionID == "" {
+		return nil, &StateError{
+			Code:    "invalid_session_id",
+			Message: "session ID cannot be empty",
+		}
+	}
+	return sm.journal.ReadAll(ctx, sessionID)
+}
+
 // ListSessions returns all available session IDs
 func (sm *StateManager) ListSessions(ctx context.Context) ([]string, error) {
 	sessionsDir := filepath.Join(sm.basePath, "sessions")
@@ -229,9 +321,135 @@ func (sm *StateManager) ListSessions(ctx context.Context) ([]string, error) {
 	return sessions, nil
 }
 
+// projectState folds the session journal into a fresh SessionState
+func (sm *StateManager) projectState(ctx context.Context, sessionID string) (*SessionState, error) {
+	if _, err := os.Stat(sm.journal.Path(sessionID)); os.IsNotExist(err) {
+		return nil, &StateError{
+			Code:    "journal_not_found",
+			Message: fmt.Sprintf("session %s has no journal", sessionID),
+		}
+	}
+
+	state := newSessionState(sessionID)
+	if err := sm.applyJournalTail(ctx, sessionID, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// applyJournalTail applies the journal events recorded after the state's
+// journal offset and advances the offset past them
+func (sm *StateManager) applyJournalTail(ctx context.Context, sessionID string, state *SessionState) error {
+	sm.resolveJournalOffset(sessionID, state)
+	events, offset, err := sm.journal.ReadFrom(ctx, sessionID, state.JournalOffset)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := state.Apply(event); err != nil {
+			return fmt.Errorf("failed to replay journal: %w", err)
+		}
+	}
+	state.JournalOffset = offset
+
+	return nil
+}
+
+// resolveJournalOffset replaces the offset of a snapshot written before
+// offsets were recorded with the end of the journal it reflects
+func (sm *StateManager) resolveJournalOffset(sessionID string, state *SessionState) {
+	if state.JournalOffset != wholeJournal {
+		return
+	}
+	state.JournalOffset = 0
+	if info, err := os.Stat(sm.journal.Path(sessionID)); err == nil {
+		state.JournalOffset = info.Size()
+	}
+}
+
+// saveState writes state as the session's state.json snapshot. Callers
+// hold the session lock, so the file just written is still theirs to cache.
+func (sm *StateManager) saveState(ctx context.Context, sessionID string, state *SessionState) error {
+	sessionPath := sm.getSessionPath(sessionID)
+	if err := sm.writer.WriteJSON(ctx, sessionPath, state); err != nil {
+		return err
+	}
+	if cache := sharedCache.Load(); cache != nil {
+		if info, err := os.Stat(sessionPath); err == nil {
+			cache.put(sessionPath, info, state.JournalOffset, state)
+		}
+	}
+	return nil
+}
+
+// sessionExists reports whether a session has a snapshot or a journal
+func (sm *StateManager) sessionExists(sessionID string) bool {
+	for _, path := range []string{sm.getSessionPath(sessionID), sm.journal.Path(sessionID)} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// newSessionState returns an empty SessionState with all collections allocated
+func newSessionState(sessionID string) *SessionState {
+	return &SessionState{
+		SchemaVersion: CurrentSchemaVersion,
+		SessionID:     sessionID,
+		Agents:        make([]string, 0),
+		AgentsHistory: make([]AgentExecution, 0),
+		Files: FileOperations{
+			New:    make([]string, 0),
+			Edited: make([]string, 0),
+			Read:   make([]string, 0),
+		},
+		ToolsUsed:       make(map[string]int),
+		ToolCalls:       make([]ToolCallEntry, 0),
+		Commands:        make([]CommandEntry, 0),
+		Errors:          make([]ErrorEntry, 0),
+		PolicyDecisions: make([]PolicyDecision, 0),
+		GateResults:     make([]GateResult, 0),
+		Prompts:         make([]PromptEntry, 0),
+		Notifications:   make([]NotificationEntry, 0),
+		Todos: TodoState{
+			Recent: make([]TodoItem, 0),
+		},
+		Usage: UsageState{
+			ByModel: make(map[string]TokenUsage),
+		},
+		Redactions:  make(map[string]int),
+		PluginData:  make(map[string]map[string]json.RawMessage),
+		HookTimings: make(map[string]HookTiming),
+	}
+}
+
+// lockSession acquires the cross-process lock for an existing session,
+// waiting at most the manager timeout
+func (sm *StateManager) lockSession(ctx context.Context, sessionID string) (*sessionLock, error) {
+	sessionDir := sm.getSessionDir(sessionID)
+	if _, err := os.Stat(sessionDir); os.IsNotExist(err) {
+		return nil, &StateError{
+			Code:    "session_not_found",
+			Message: fmt.Sprintf("session %s does not exist", sessionID),
+		}
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, sm.timeout)
+	defer cancel()
+
+	return acquireSessionLock(lockCtx, filepath.Join(sessionDir, LockFileName))
+}
+
+// getSessionDir returns the directory holding a session's files
+func (sm *StateManager) getSessionDir(sessionID string) string {
+	return filepath.Join(sm.basePath, "sessions", sessionID)
+}
+
 // getSessionPath returns the full path to a session's state file
 func (sm *StateManager) getSessionPath(sessionID string) string {
-	return filepath.Join(sm.basePath, "sessions", sessionID, StateFileName)
+	return filepath.Join(sm.getSessionDir(sessionID), StateFileName)
 }
 
 // readFileWithContext reads a file with context cancellation support
@@ -258,64 +476,215 @@ func (sm *StateManager) readFileWithContext(ctx context.Context, filename string
 
 // Convenience functions for common update operations
 
-// AddAgent adds an agent to the session state
-func (sm *StateManager) AddAgent(ctx context.Context, sessionID, agentName string) error {
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		// Add to current agents if not already present
-		for _, agent := range state.Agents {
-			if agent == agentName {
-				return nil // Already present
-			}
+// recordEvent appends a typed event to the session journal. Secrets in the
+// payload are redacted first and counted with a redactions event. The
+// state.json snapshot is only rewritten at turn boundaries and whenever the
+// journal has grown by another snapshotInterval; readers apply the rest of
+// the journal on load.
+func (sm *StateManager) recordEvent(ctx context.Context, sessionID string, eventType EventType, payload interface{}) error {
+	event, err := NewEvent(eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	data, counts, err := sm.loadRedactor().JSON(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to redact %s event: %w", eventType, err)
+	}
+	event.Data = data
+
+	events := []Event{event}
+	if counts.Total() > 0 {
+		countEvent, err := NewEvent(EventRedactionsRecorded, RedactionsRecordedData{Counts: counts})
+		if err != nil {
+			return err
+		}
+		events = append(events, countEvent)
+	}
+
+	// Reject events the projection cannot apply before they reach the journal
+	scratch := newSessionState(sessionID)
+	for _, event := range events {
+		if err := scratch.Apply(event); err != nil {
+			return err
+		}
+	}
+
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to lock state for update: %w", err)
+	}
+	defer lock.Release()
+
+	if !sm.sessionExists(sessionID) {
+		return &StateError{
+			Code:    "session_not_found",
+			Message: fmt.Sprintf("session %s does not exist", sessionID),
 		}
-		state.Agents = append(state.Agents, agentName)
+	}
 
-		// Add to history
-		state.AgentsHistory = append(state.AgentsHistory, AgentExecution{
-			Name:      agentName,
-			StartedAt: time.Now().UTC(),
-		})
+	if err := sm.settleSnapshot(ctx, sessionID); err != nil {
+		return err
+	}
+
+	start, end, err := sm.journal.AppendAll(ctx, sessionID, events)
+	if err != nil {
+		return fmt.Errorf("failed to append %s event: %w", eventType, err)
+	}
 
+	// The daemon keeps state in memory and writes snapshots with
+	// FlushSnapshots instead
+	if sharedCache.Load() != nil || !snapshotDue(eventType, start, end) {
 		return nil
-	})
+	}
+	return sm.writeSnapshot(ctx, sessionID)
 }
 
-// CompleteAgent marks an agent as completed
-func (sm *StateManager) CompleteAgent(ctx context.Context, sessionID, agentName string) error {
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		// Remove from current agents
-		for i, agent := range state.Agents {
-			if agent == agentName {
-				state.Agents = append(state.Agents[:i], state.Agents[i+1:]...)
-				break
-			}
+// settleSnapshot rewrites a state.json written by an older schema before
+// anything is appended to the journal. A snapshot from before journal
+// offsets were recorded covers the whole journal, which stays true only
+// until the next append. Callers hold the session lock.
+func (sm *StateManager) settleSnapshot(ctx context.Context, sessionID string) error {
+	sessionPath := sm.getSessionPath(sessionID)
+	version, err := snapshotSchemaVersion(sessionPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &FileError{
+			Op:   "read_state_file",
+			Path: sessionPath,
+			Err:  err,
 		}
+	}
+	if version == CurrentSchemaVersion {
+		return nil
+	}
+	return sm.writeSnapshot(ctx, sessionID)
+}
 
-		// Update history with completion time
-		now := time.Now().UTC()
-		for i := range state.AgentsHistory {
-			if state.AgentsHistory[i].Name == agentName && state.AgentsHistory[i].CompletedAt == nil {
-				state.AgentsHistory[i].CompletedAt = &now
-				break
-			}
-		}
+// writeSnapshot rewrites state.json from the snapshot and journal tail.
+// Callers hold the session lock.
+func (sm *StateManager) writeSnapshot(ctx context.Context, sessionID string) error {
+	state, err := sm.LoadState(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load state for snapshot: %w", err)
+	}
+	if err := sm.saveState(ctx, sessionID, state); err != nil {
+		return fmt.Errorf("failed to write state snapshot: %w", err)
+	}
+	return nil
+}
 
+// FlushSnapshots rewrites the state.json snapshot of every cached session
+// under the base path whose journal has grown since the snapshot was
+// written. The daemon calls it periodically and before it exits.
+func (sm *StateManager) FlushSnapshots(ctx context.Context) error {
+	cache := sharedCache.Load()
+	if cache == nil {
 		return nil
+	}
+
+	sessionsDir := filepath.Join(sm.basePath, "sessions")
+	var errs []error
+	for path, offset := range cache.snapshots() {
+		sessionDir := filepath.Dir(path)
+		if filepath.Dir(sessionDir) != sessionsDir {
+			continue
+		}
+		sessionID := filepath.Base(sessionDir)
+		if info, err := os.Stat(sm.journal.Path(sessionID)); err != nil || info.Size() == offset {
+			continue
+		}
+
+		if err := sm.flushSnapshot(ctx, sessionID); err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", sessionID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flushSnapshot writes one session's snapshot under the session lock
+func (sm *StateManager) flushSnapshot(ctx context.Context, sessionID string) error {
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	return sm.writeSnapshot(ctx, sessionID)
+}
+
+// snapshotDue reports whether state.json should be rewritten after an event
+// was journaled between the start and end offsets
+func snapshotDue(eventType EventType, start, end int64) bool {
+	switch eventType {
+	case EventPromptCompleted, EventSessionActiveChanged:
+		return true
+	}
+	return start/snapshotInterval != end/snapshotInterval
+}
+
+// loadRedactor returns the redactor configured by redaction.yaml in the
+// base path, reading it once per manager. Hook execution already warned
+// about a broken file, so it falls back to the built-in detectors quietly.
+func (sm *StateManager) loadRedactor() *redact.Redactor {
+	sm.redactOnce.Do(func() {
+		redactor, err := redact.Load(filepath.Join(sm.basePath, redact.FileName))
+		if err != nil {
+			redactor = redact.Default()
+		}
+		sm.redactor = redactor
 	})
+	return sm.redactor
+}
+
+// AddAgent adds an agent to the session state
+func (sm *StateManager) AddAgent(ctx context.Context, sessionID, agentName string) error {
+	return sm.recordEvent(ctx, sessionID, EventAgentStarted, AgentData{Name: agentName})
+}
+
+// CompleteAgent marks an agent as completed
+func (sm *StateManager) CompleteAgent(ctx context.Context, sessionID, agentName string) error {
+	return sm.recordEvent(ctx, sessionID, EventAgentCompleted, AgentData{Name: agentName})
+}
+
+// StartAgent records the launch of a Task agent execution
+func (sm *StateManager) StartAgent(ctx context.Context, sessionID string, data AgentSpawnedData) error {
+	return sm.recordEvent(ctx, sessionID, EventAgentSpawned, data)
+}
+
+// FinishAgent completes the agent execution matching data and stores its result
+func (sm *StateManager) FinishAgent(ctx context.Context, sessionID string, data AgentFinishedData) error {
+	return sm.recordEvent(ctx, sessionID, EventAgentFinished, data)
+}
+
+// StopSubagent completes the running agent when exactly one is running
+func (sm *StateManager) StopSubagent(ctx context.Context, sessionID string) error {
+	return sm.recordEvent(ctx, sessionID, EventSubagentStopped, nil)
+}
+
+// RecordUsage folds a batch of transcript usage records into the session
+func (sm *StateManager) RecordUsage(ctx context.Context, sessionID string, data UsageRecordedData) error {
+	return sm.recordEvent(ctx, sessionID, EventUsageRecorded, data)
 }
 
 // RecordError adds an error entry to the session state
 func (sm *StateManager) RecordError(ctx context.Context, sessionID string, message, source, severity string) error {
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		state.Errors = append(state.Errors, ErrorEntry{
-			Timestamp: time.Now().UTC(),
-			Message:   message,
-			Source:    source,
-			Severity:  severity,
-		})
-		return nil
+	return sm.RecordErrorEntry(ctx, sessionID, ErrorEntry{
+		Message:  message,
+		Source:   source,
+		Severity: severity,
 	})
 }
 
+// RecordErrorEntry adds a typed error entry, stamping it if no time is set
+func (sm *StateManager) RecordErrorEntry(ctx context.Context, sessionID string, entry ErrorEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	return sm.recordEvent(ctx, sessionID, EventErrorRecorded, entry)
+}
+
 // RecordFileOperation adds file operations to the session state
 func (sm *StateManager) RecordFileOperation(ctx context.Context, sessionID, operation, filepath string) error {
 	if operation != "new" && operation != "edited" && operation != "read" {
@@ -325,52 +694,91 @@ func (sm *StateManager) RecordFileOperation(ctx context.Context, sessionID, oper
 		}
 	}
 
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		switch operation {
-		case "new":
-			state.Files.New = append(state.Files.New, filepath)
-		case "edited":
-			state.Files.Edited = append(state.Files.Edited, filepath)
-		case "read":
-			state.Files.Read = append(state.Files.Read, filepath)
-		}
-		return nil
+	return sm.recordEvent(ctx, sessionID, EventFileRecorded, FileRecordedData{
+		Operation: operation,
+		Path:      filepath,
 	})
 }
 
 // IncrementToolUsage increments the usage counter for a tool
 func (sm *StateManager) IncrementToolUsage(ctx context.Context, sessionID, toolName string) error {
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		if state.ToolsUsed == nil {
-			state.ToolsUsed = make(map[string]int)
-		}
-		state.ToolsUsed[toolName]++
-		return nil
-	})
+	return sm.recordEvent(ctx, sessionID, EventToolInvoked, ToolInvokedData{Tool: toolName})
+}
+
+// StartToolCall records the start of a tool call on the session timeline
+func (sm *StateManager) StartToolCall(ctx context.Context, sessionID string, call ToolCallStartedData) error {
+	return sm.recordEvent(ctx, sessionID, EventToolCallStarted, call)
+}
+
+// FinishToolCall completes the matching running tool call on the timeline
+func (sm *StateManager) FinishToolCall(ctx context.Context, sessionID string, call ToolCallFinishedData) error {
+	return sm.recordEvent(ctx, sessionID, EventToolCallFinished, call)
+}
+
+// RecordCommand appends a Bash invocation to the session command history.
+// Duration is taken from the matching completed Bash tool call.
+func (sm *StateManager) RecordCommand(ctx context.Context, sessionID string, command CommandEntry) error {
+	return sm.recordEvent(ctx, sessionID, EventCommandExecuted, command)
 }
 
 // SetSessionActive sets the session active status
 func (sm *StateManager) SetSessionActive(ctx context.Context, sessionID string, active bool) error {
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		state.SessionActive = active
-		return nil
-	})
+	return sm.recordEvent(ctx, sessionID, EventSessionActiveChanged, SessionActiveData{Active: active})
 }
 
 // UpdateTodos updates the todo state for a session
 func (sm *StateManager) UpdateTodos(ctx context.Context, sessionID string, todos TodoState) error {
-	return sm.UpdateState(ctx, sessionID, func(state *SessionState) error {
-		state.Todos = todos
+	return sm.recordEvent(ctx, sessionID, EventTodosUpdated, todos)
+}
+
+// RecordPolicyDecision appends a policy decision to the session audit trail
+func (sm *StateManager) RecordPolicyDecision(ctx context.Context, sessionID string, decision PolicyDecision) error {
+	return sm.recordEvent(ctx, sessionID, EventPolicyDecided, decision)
+}
+
+// RecordRedactions adds secrets removed outside the state writers, such as
+// from logged hook input, to the session's redaction counts
+func (sm *StateManager) RecordRedactions(ctx context.Context, sessionID string, counts redact.Counts) error {
+	if counts.Total() == 0 {
 		return nil
+	}
+	return sm.recordEvent(ctx, sessionID, EventRedactionsRecorded, RedactionsRecordedData{Counts: counts})
+}
+
+// PatchPluginState merges an external plugin's state patch into the session
+func (sm *StateManager) PatchPluginState(ctx context.Context, sessionID, plugin string, patch map[string]json.RawMessage) error {
+	return sm.recordEvent(ctx, sessionID, EventPluginStatePatched, PluginStatePatchedData{
+		Plugin: plugin,
+		Patch:  patch,
 	})
 }
 
+// RecordHookTiming adds one hook execution to the session's hook timings
+func (sm *StateManager) RecordHookTiming(ctx context.Context, sessionID string, data HookTimedData) error {
+	return sm.recordEvent(ctx, sessionID, EventHookTimed, data)
+}
+
+// RecordGateResult appends a stop gate evaluation to the session state
+func (sm *StateManager) RecordGateResult(ctx context.Context, sessionID string, result GateResult) error {
+	return sm.recordEvent(ctx, sessionID, EventGateEvaluated, result)
+}
+
+// AddPrompt appends a user prompt to the session state
+func (sm *StateManager) AddPrompt(ctx context.Context, sessionID string, prompt PromptEntry) error {
+	return sm.recordEvent(ctx, sessionID, EventPromptSubmitted, prompt)
+}
+
+// CompletePrompt records the assistant's answer to the most recent prompt
+func (sm *StateManager) CompletePrompt(ctx context.Context, sessionID string, data PromptCompletedData) error {
+	return sm.recordEvent(ctx, sessionID, EventPromptCompleted, data)
+}
+
+// AddNotification appends a notification to the session state
+func (sm *StateManager) AddNotification(ctx context.Context, sessionID string, notification NotificationEntry) error {
+	return sm.recordEvent(ctx, sessionID, EventNotificationReceived, notification)
+}
+
 // GetSessionState returns the current session state (alias for LoadState)
 func (sm *StateManager) GetSessionState(ctx context.Context, sessionID string) (*SessionState, error) {
 	return sm.LoadState(ctx, sessionID)
 }
-
-// updateSessionState is a helper for updating session state with a custom function
-func (sm *StateManager) updateSessionState(ctx context.Context, sessionID string, updateFunc func(*SessionState) error) error {
-	return sm.UpdateState(ctx, sessionID, updateFunc)
-}
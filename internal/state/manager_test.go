@@ -193,96 +193,6 @@ func TestStateManager_LoadState(t *testing.T) {
 	}
 }
 
-func TestStateManager_UpdateState(t *testing.T) {
-	// Create temporary directory for tests
-	tmpDir, err := os.MkdirTemp("", "manager_update_test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	manager := NewStateManager(tmpDir)
-	ctx := context.Background()
-
-	// Initialize a state first
-	sessionID := "test_update_session"
-	_, err = manager.InitializeState(ctx, sessionID)
-	if err != nil {
-		t.Fatalf("Failed to initialize state for test: %v", err)
-	}
-
-	tests := []struct {
-		name       string
-		sessionID  string
-		updateFunc func(*SessionState) error
-		wantErr    bool
-	}{
-		{
-			name:      "valid update",
-			sessionID: sessionID,
-			updateFunc: func(state *SessionState) error {
-				state.SessionActive = false
-				state.Agents = append(state.Agents, "test_agent")
-				return nil
-			},
-			wantErr: false,
-		},
-		{
-			name:      "update function error",
-			sessionID: sessionID,
-			updateFunc: func(state *SessionState) error {
-				return &StateError{Code: "test_error", Message: "test error"}
-			},
-			wantErr: true,
-		},
-		{
-			name:      "non-existent session",
-			sessionID: "non_existent",
-			updateFunc: func(state *SessionState) error {
-				return nil
-			},
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			originalUpdatedAt := time.Time{}
-			if !tt.wantErr && tt.sessionID == sessionID {
-				// Get current UpdatedAt for comparison
-				state, _ := manager.LoadState(ctx, sessionID)
-				originalUpdatedAt = state.UpdatedAt
-			}
-
-			err := manager.UpdateState(ctx, tt.sessionID, tt.updateFunc)
-
-			if tt.wantErr {
-				if err == nil {
-					t.Errorf("UpdateState() expected error but got none")
-				}
-				return
-			}
-
-			if err != nil {
-				t.Errorf("UpdateState() unexpected error: %v", err)
-				return
-			}
-
-			// Verify update was applied
-			updatedState, err := manager.LoadState(ctx, tt.sessionID)
-			if err != nil {
-				t.Errorf("Failed to load updated state: %v", err)
-				return
-			}
-
-			// Verify UpdatedAt timestamp was updated
-			if !updatedState.UpdatedAt.After(originalUpdatedAt) {
-				t.Error("UpdateState() did not update the UpdatedAt timestamp")
-			}
-		})
-	}
-}
-
 func TestStateManager_DeleteState(t *testing.T) {
 	// Create temporary directory for tests
 	tmpDir, err := os.MkdirTemp("", "manager_delete_test")
@@ -643,3 +553,21 @@ func TestJSONSchemaCompliance(t *testing.T) {
 		t.Error("ToolsUsed mismatch after marshal/unmarshal")
 	}
 }
+
+func TestSetDefaultTimeout(t *testing.T) {
+	defer SetDefaultTimeout(0)
+
+	if sm := NewStateManager(t.TempDir()); sm.timeout != DefaultTimeout {
+		t.Errorf("timeout = %s, want %s", sm.timeout, DefaultTimeout)
+	}
+
+	SetDefaultTimeout(750 * time.Millisecond)
+	if sm := NewStateManager(t.TempDir()); sm.timeout != 750*time.Millisecond {
+		t.Errorf("configured timeout = %s, want 750ms", sm.timeout)
+	}
+
+	SetDefaultTimeout(0)
+	if sm := NewStateManager(t.TempDir()); sm.timeout != DefaultTimeout {
+		t.Errorf("reset timeout = %s, want %s", sm.timeout, DefaultTimeout)
+	}
+}
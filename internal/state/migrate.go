@@ -0,0 +1,269 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// CurrentSchemaVersion is the state.json schema written by this build.
+// Files without a schema_version field are treated as version 0.
+const CurrentSchemaVersion = 3
+
+// wholeJournal is the journal offset of snapshots written before offsets
+// were recorded. Those were rewritten on every event, so they reflect the
+// whole journal.
+const wholeJournal = -1
+
+// Migration upgrades raw state.json data from one schema version to the next
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(raw map[string]interface{}) error
+}
+
+// migrations is the registry of schema upgrades keyed by source version
+var migrations = map[int]Migration{}
+
+// RegisterMigration adds an upgrade step to the migration registry
+func RegisterMigration(m Migration) {
+	if _, exists := migrations[m.From]; exists {
+		panic(fmt.Sprintf("state: migration from schema version %d already registered", m.From))
+	}
+	migrations[m.From] = m
+}
+
+// Migrations returns all registered upgrade steps ordered by source version
+func Migrations() []Migration {
+	result := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].From < result[j].From
+	})
+	return result
+}
+
+// MigrationResult describes the outcome of migrating a single session
+type MigrationResult struct {
+	SessionID   string
+	FromVersion int
+	ToVersion   int
+}
+
+// Migrated reports whether the session needed an upgrade
+func (r MigrationResult) Migrated() bool {
+	return r.FromVersion != r.ToVersion
+}
+
+// decodeState unmarshals state.json data, upgrading older schemas in memory.
+// It returns the schema version the data was stored with.
+func decodeState(data []byte) (*SessionState, int, error) {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, 0, err
+	}
+
+	if header.SchemaVersion > CurrentSchemaVersion {
+		return nil, header.SchemaVersion, &StateError{
+			Code:    "unsupported_schema_version",
+			Message: fmt.Sprintf("schema version %d is newer than supported version %d", header.SchemaVersion, CurrentSchemaVersion),
+		}
+	}
+
+	if header.SchemaVersion < CurrentSchemaVersion {
+		migrated, err := migrateStateData(data, header.SchemaVersion)
+		if err != nil {
+			return nil, header.SchemaVersion, err
+		}
+		data = migrated
+	}
+
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, header.SchemaVersion, err
+	}
+
+	return &state, header.SchemaVersion, nil
+}
+
+// snapshotSchemaVersion reads the schema_version at the start of a
+// state.json without decoding the rest. Files written before versioning
+// report 0.
+func snapshotSchemaVersion(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return 0, fmt.Errorf("state file is not a JSON object")
+	}
+	key, err := decoder.Token()
+	if err != nil {
+		return 0, err
+	}
+	if key != "schema_version" {
+		return 0, nil
+	}
+	var version int
+	if err := decoder.Decode(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// migrateStateData runs every registered migration from the given version
+// up to CurrentSchemaVersion on raw state.json data
+func migrateStateData(data []byte, fromVersion int) ([]byte, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	for version := fromVersion; version < CurrentSchemaVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return nil, &StateError{
+				Code:    "missing_migration",
+				Message: fmt.Sprintf("no migration registered from schema version %d", version),
+			}
+		}
+		if err := m.Apply(raw); err != nil {
+			return nil, fmt.Errorf("migration from schema version %d failed: %w", version, err)
+		}
+		raw["schema_version"] = version + 1
+	}
+
+	return json.Marshal(raw)
+}
+
+// MigrateSession upgrades a session's state.json to the current schema.
+// With dryRun set the file is inspected but never rewritten.
+func (sm *StateManager) MigrateSession(ctx context.Context, sessionID string, dryRun bool) (MigrationResult, error) {
+	result := MigrationResult{SessionID: sessionID, ToVersion: CurrentSchemaVersion}
+
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return result, err
+	}
+	defer lock.Release()
+
+	sessionPath := sm.getSessionPath(sessionID)
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		return result, &FileError{
+			Op:   "read_state_file",
+			Path: sessionPath,
+			Err:  err,
+		}
+	}
+
+	state, fromVersion, err := decodeState(data)
+	result.FromVersion = fromVersion
+	if err != nil {
+		return result, &FileError{
+			Op:   "migrate_state",
+			Path: sessionPath,
+			Err:  err,
+		}
+	}
+
+	if !result.Migrated() || dryRun {
+		return result, nil
+	}
+
+	// Written snapshots record where in the journal they stand
+	sm.resolveJournalOffset(sessionID, state)
+
+	if err := sm.writer.WriteJSON(ctx, sessionPath, state); err != nil {
+		return result, fmt.Errorf("failed to write migrated state: %w", err)
+	}
+
+	return result, nil
+}
+
+// ensureList replaces a missing or null list field with an empty list
+func ensureList(raw map[string]interface{}, key string) {
+	if _, ok := raw[key].([]interface{}); !ok {
+		raw[key] = []interface{}{}
+	}
+}
+
+// ensureObject returns the named object field, creating it when missing
+func ensureObject(raw map[string]interface{}, key string) map[string]interface{} {
+	obj, ok := raw[key].(map[string]interface{})
+	if !ok {
+		obj = map[string]interface{}{}
+		raw[key] = obj
+	}
+	return obj
+}
+
+func init() {
+	// Fields added alongside existing ones decode to their zero value, so
+	// they are only filled in here, never given a version of their own
+	RegisterMigration(Migration{
+		From:        0,
+		Description: "add schema_version and replace missing collections with empty values",
+		Apply: func(raw map[string]interface{}) error {
+			for _, key := range []string{
+				"agents", "agents_history", "errors", "prompts", "notifications",
+				"tool_calls", "commands", "policy_decisions", "gate_results",
+			} {
+				ensureList(raw, key)
+			}
+			for _, key := range []string{"tools_used", "redactions", "plugin_data", "hook_timings"} {
+				ensureObject(raw, key)
+			}
+
+			files := ensureObject(raw, "files")
+			for _, key := range []string{"new", "edited", "read"} {
+				ensureList(files, key)
+			}
+
+			ensureList(ensureObject(raw, "todos"), "recent")
+
+			usage := ensureObject(raw, "usage")
+			ensureObject(usage, "by_model")
+			ensureObject(usage, "total")
+			return nil
+		},
+	})
+
+	RegisterMigration(Migration{
+		From:        1,
+		Description: "assign execution IDs to agent history",
+		Apply: func(raw map[string]interface{}) error {
+			history, _ := raw["agents_history"].([]interface{})
+			for i, item := range history {
+				agent, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if id, _ := agent["id"].(string); id == "" {
+					agent["id"] = fmt.Sprintf("agent-%d", i+1)
+				}
+			}
+			return nil
+		},
+	})
+
+	RegisterMigration(Migration{
+		From:        2,
+		Description: "record the journal offset reflected by the snapshot",
+		Apply: func(raw map[string]interface{}) error {
+			if _, ok := raw["journal_offset"]; !ok {
+				raw["journal_offset"] = wholeJournal
+			}
+			return nil
+		},
+	})
+}
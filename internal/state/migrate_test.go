@@ -0,0 +1,172 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// legacyState is a pre-versioning state.json with null and missing collections
+const legacyState = `{
+  "session_id": "legacy_session",
+  "created_at": "2025-09-06T03:34:49Z",
+  "updated_at": "2025-09-06T03:39:02Z",
+  "session_active": false,
+  "agents": null,
+  "agents_history": [{"name": "sm", "started_at": "2025-09-06T03:35:10Z", "completed_at": "2025-09-06T03:36:00Z"}],
+  "files": {"new": ["a.go"]},
+  "tools_used": {"Read": 2},
+  "prompts": [{"timestamp": "2025-09-06T03:35:00Z", "prompt": "hi", "response": "", "tools_used": []}]
+}`
+
+func writeRawState(t *testing.T, basePath, sessionID, content string) string {
+	t.Helper()
+	path := filepath.Join(basePath, "sessions", sessionID, StateFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create session dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+	return path
+}
+
+func TestLoadState_MigratesLegacySchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeRawState(t, tmpDir, "legacy_session", legacyState)
+
+	state, err := NewStateManager(tmpDir).LoadState(context.Background(), "legacy_session")
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+
+	if state.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", state.SchemaVersion, CurrentSchemaVersion)
+	}
+	if state.Agents == nil || state.Errors == nil || state.Notifications == nil {
+		t.Error("migration left nil collections")
+	}
+	if state.Files.Edited == nil || state.Files.Read == nil || state.Todos.Recent == nil {
+		t.Error("migration left nil nested collections")
+	}
+	if state.ToolCalls == nil || state.GateResults == nil || state.PluginData == nil || state.Usage.ByModel == nil {
+		t.Error("migration left later collections nil")
+	}
+	if len(state.Files.New) != 1 || state.ToolsUsed["Read"] != 2 || len(state.Prompts) != 1 {
+		t.Errorf("migration lost existing data: %+v", state)
+	}
+	if len(state.AgentsHistory) != 1 || state.AgentsHistory[0].ID != "agent-1" {
+		t.Errorf("migration did not assign agent execution IDs: %+v", state.AgentsHistory)
+	}
+}
+
+func TestLoadState_RejectsNewerSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeRawState(t, tmpDir, "future_session", `{"schema_version": 999, "session_id": "future_session"}`)
+
+	_, err := NewStateManager(tmpDir).LoadState(context.Background(), "future_session")
+
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || stateErr.Code != "unsupported_schema_version" {
+		t.Errorf("LoadState() error = %v, want unsupported_schema_version", err)
+	}
+}
+
+func TestStateManager_MigrateSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantVersion int
+	}{
+		{name: "dry run leaves file untouched", dryRun: true, wantVersion: 0},
+		{name: "migration rewrites file", dryRun: false, wantVersion: CurrentSchemaVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := writeRawState(t, tmpDir, "legacy_session", legacyState)
+			manager := NewStateManager(tmpDir)
+			event, _ := NewEvent(EventToolInvoked, ToolInvokedData{Tool: "Read"})
+			if err := manager.journal.Append(context.Background(), "legacy_session", event); err != nil {
+				t.Fatalf("Append() error: %v", err)
+			}
+			journalInfo, _ := os.Stat(manager.journal.Path("legacy_session"))
+
+			result, err := manager.MigrateSession(context.Background(), "legacy_session", tt.dryRun)
+			if err != nil {
+				t.Fatalf("MigrateSession() error: %v", err)
+			}
+			if !result.Migrated() || result.FromVersion != 0 || result.ToVersion != CurrentSchemaVersion {
+				t.Errorf("MigrateSession() result = %+v", result)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read state: %v", err)
+			}
+			var header struct {
+				SchemaVersion int    `json:"schema_version"`
+				JournalOffset *int64 `json:"journal_offset"`
+			}
+			if err := json.Unmarshal(data, &header); err != nil {
+				t.Fatalf("Failed to parse state: %v", err)
+			}
+			if header.SchemaVersion != tt.wantVersion {
+				t.Errorf("schema_version on disk = %d, want %d", header.SchemaVersion, tt.wantVersion)
+			}
+			// The migrated snapshot covers the journal written so far
+			if !tt.dryRun && (header.JournalOffset == nil || *header.JournalOffset != journalInfo.Size()) {
+				t.Errorf("journal_offset on disk = %v, want %d", header.JournalOffset, journalInfo.Size())
+			}
+
+			// A second pass has nothing left to do
+			if !tt.dryRun {
+				again, err := manager.MigrateSession(context.Background(), "legacy_session", false)
+				if err != nil || again.Migrated() {
+					t.Errorf("second MigrateSession() = %+v, %v; want no-op", again, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordEvent_SettlesLegacySnapshot(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeRawState(t, tmpDir, "legacy_session", legacyState)
+	manager := NewStateManager(tmpDir)
+	ctx := context.Background()
+
+	// The legacy snapshot covers the whole journal only until this append
+	if err := manager.IncrementToolUsage(ctx, "legacy_session", "Bash"); err != nil {
+		t.Fatalf("IncrementToolUsage() error: %v", err)
+	}
+
+	state, err := manager.LoadState(ctx, "legacy_session")
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if state.ToolsUsed["Bash"] != 1 || state.ToolsUsed["Read"] != 2 {
+		t.Errorf("ToolsUsed = %v, want the legacy counts and the new Bash call", state.ToolsUsed)
+	}
+
+	version, err := snapshotSchemaVersion(manager.getSessionPath("legacy_session"))
+	if err != nil || version != CurrentSchemaVersion {
+		t.Errorf("snapshot schema version = %d, %v; want %d", version, err, CurrentSchemaVersion)
+	}
+}
+
+func TestMigrations_RegistryCoversAllVersions(t *testing.T) {
+	registered := Migrations()
+	if len(registered) != CurrentSchemaVersion {
+		t.Fatalf("registered %d migrations, want %d", len(registered), CurrentSchemaVersion)
+	}
+	for i, m := range registered {
+		if m.From != i {
+			t.Errorf("migration %d starts at version %d", i, m.From)
+		}
+	}
+}
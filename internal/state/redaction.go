@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dylan/spcstr/internal/redact"
+)
+
+// RedactSession scrubs secrets from a session's journal and state.json in
+// place and records the removals in both. It returns the number of secrets
+// found; with dryRun set nothing is rewritten.
+func (sm *StateManager) RedactSession(ctx context.Context, sessionID string, dryRun bool) (redact.Counts, error) {
+	redactor := sm.loadRedactor()
+
+	lock, err := sm.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	// Journal first: its events are the source state.json is rebuilt from
+	var events []Event
+	hasJournal := false
+	if _, err := os.Stat(sm.journal.Path(sessionID)); err == nil {
+		hasJournal = true
+		if events, err = sm.journal.ReadAll(ctx, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	journalCounts := redact.Counts{}
+	for i := range events {
+		data, counts, err := redactor.JSON(events[i].Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redact %s event: %w", events[i].Type, err)
+		}
+		events[i].Data = data
+		journalCounts.Add(counts)
+	}
+
+	sessionPath := sm.getSessionPath(sessionID)
+	stateData, err := os.ReadFile(sessionPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, &FileError{
+			Op:   "read_state_file",
+			Path: sessionPath,
+			Err:  err,
+		}
+	}
+	stateCounts := redact.Counts{}
+	if stateData != nil {
+		if stateData, stateCounts, err = redactor.JSON(stateData); err != nil {
+			return nil, &FileError{
+				Op:   "redact_state",
+				Path: sessionPath,
+				Err:  err,
+			}
+		}
+	}
+
+	// state.json repeats what the journal holds, so take the larger tally
+	// per detector rather than counting the same secret twice
+	counts := redact.Counts{}
+	for _, c := range []redact.Counts{journalCounts, stateCounts} {
+		for name, n := range c {
+			counts[name] = max(counts[name], n)
+		}
+	}
+	if counts.Total() == 0 || dryRun {
+		return counts, nil
+	}
+
+	countEvent, err := NewEvent(EventRedactionsRecorded, RedactionsRecordedData{Counts: counts})
+	if err != nil {
+		return nil, err
+	}
+	if hasJournal {
+		if err := sm.journal.Rewrite(ctx, sessionID, append(events, countEvent)); err != nil {
+			return nil, fmt.Errorf("failed to rewrite journal: %w", err)
+		}
+		// The journal offsets moved, so the snapshot is rebuilt from it
+		state, err := sm.projectState(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := sm.saveState(ctx, sessionID, state); err != nil {
+			return nil, fmt.Errorf("failed to write redacted state: %w", err)
+		}
+		return counts, nil
+	}
+
+	if stateData == nil {
+		return counts, nil
+	}
+	state, _, err := decodeState(stateData)
+	if err != nil {
+		return nil, &FileError{
+			Op:   "unmarshal_json",
+			Path: sessionPath,
+			Err:  err,
+		}
+	}
+	if err := state.Apply(countEvent); err != nil {
+		return nil, err
+	}
+	if err := sm.saveState(ctx, sessionID, state); err != nil {
+		return nil, fmt.Errorf("failed to write redacted state: %w", err)
+	}
+
+	return counts, nil
+}
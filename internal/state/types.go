@@ -1,30 +1,76 @@
 package state
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // SessionState represents the complete state of a Claude Code session
 type SessionState struct {
-	SessionID     string              `json:"session_id"`
-	CreatedAt     time.Time           `json:"created_at"`
-	UpdatedAt     time.Time           `json:"updated_at"`
-	SessionActive bool                `json:"session_active"`
-	Agents        []string            `json:"agents"`
-	AgentsHistory []AgentExecution    `json:"agents_history"`
-	Files         FileOperations      `json:"files"`
-	ToolsUsed     map[string]int      `json:"tools_used"`
-	Errors        []ErrorEntry        `json:"errors"`
-	Prompts       []PromptEntry       `json:"prompts"`
-	Notifications []NotificationEntry `json:"notifications"`
-	Todos         TodoState           `json:"todos"`
-}
-
-// AgentExecution tracks the execution lifecycle of an agent
+	SchemaVersion   int                 `json:"schema_version"`
+	SessionID       string              `json:"session_id"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	SessionActive   bool                `json:"session_active"`
+	Agents          []string            `json:"agents"`
+	AgentsHistory   []AgentExecution    `json:"agents_history"`
+	Files           FileOperations      `json:"files"`
+	ToolsUsed       map[string]int      `json:"tools_used"`
+	ToolCalls       []ToolCallEntry     `json:"tool_calls"`
+	Commands        []CommandEntry      `json:"commands"`
+	Errors          []ErrorEntry        `json:"errors"`
+	PolicyDecisions []PolicyDecision    `json:"policy_decisions"`
+	GateResults     []GateResult        `json:"gate_results"`
+	Prompts         []PromptEntry       `json:"prompts"`
+	Notifications   []NotificationEntry `json:"notifications"`
+	Todos           TodoState           `json:"todos"`
+	Usage           UsageState          `json:"usage"`
+	// Redactions counts secrets removed from this session's data by detector
+	Redactions map[string]int `json:"redactions"`
+	// PluginData holds the state each external plugin keeps, by plugin name
+	PluginData map[string]map[string]json.RawMessage `json:"plugin_data"`
+	// HookTimings aggregates how long each hook took to run, by hook name
+	HookTimings map[string]HookTiming `json:"hook_timings"`
+	// JournalOffset is how many bytes of the journal this snapshot reflects
+	JournalOffset int64 `json:"journal_offset"`
+}
+
+// HookTiming summarizes the executions of one hook in a session
+type HookTiming struct {
+	Count    int   `json:"count"`
+	Failures int   `json:"failures"`
+	TotalMs  int64 `json:"total_ms"`
+	MaxMs    int64 `json:"max_ms"`
+	LastMs   int64 `json:"last_ms"`
+}
+
+// AgentExecution tracks a single Task invocation from launch to completion
 type AgentExecution struct {
-	Name        string     `json:"name"`
-	StartedAt   time.Time  `json:"started_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Prompt      string      `json:"prompt,omitempty"`
+	ParentID    string      `json:"parent_id,omitempty"`
+	StartedAt   time.Time   `json:"started_at"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+	Result      string      `json:"result,omitempty"`
+	Usage       *TokenUsage `json:"usage,omitempty"`
+}
+
+// Running reports whether the agent has not completed yet
+func (a AgentExecution) Running() bool {
+	return a.CompletedAt == nil
+}
+
+// CurrentAgent returns the type of the most recently started agent that is
+// still running, or "" when only the main thread is active
+func (s *SessionState) CurrentAgent() string {
+	for i := len(s.AgentsHistory) - 1; i >= 0; i-- {
+		if s.AgentsHistory[i].Running() {
+			return s.AgentsHistory[i].Name
+		}
+	}
+	return ""
 }
 
 // FileOperations tracks all file operations during a session
@@ -34,20 +80,117 @@ type FileOperations struct {
 	Read   []string `json:"read"`
 }
 
+// Tool call statuses recorded on ToolCallEntry
+const (
+	ToolCallRunning   = "running"
+	ToolCallSucceeded = "succeeded"
+	ToolCallFailed    = "failed"
+)
+
+// ToolCallEntry pairs a PreToolUse event with its PostToolUse completion
+type ToolCallEntry struct {
+	ID         string     `json:"id"`
+	ToolName   string     `json:"tool_name"`
+	Input      string     `json:"input"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// CommandEntry records a single Bash tool invocation and its outcome
+type CommandEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	ToolUseID   string    `json:"tool_use_id,omitempty"`
+	Command     string    `json:"command"`
+	Description string    `json:"description"`
+	Background  bool      `json:"background"`
+	TimeoutMs   int       `json:"timeout_ms"`
+	ExitCode    *int      `json:"exit_code,omitempty"`
+	Interrupted bool      `json:"interrupted"`
+	DurationMs  int64     `json:"duration_ms"`
+	Stdout      string    `json:"stdout"`
+	Stderr      string    `json:"stderr"`
+}
+
 // ErrorEntry represents an error that occurred during the session
 type ErrorEntry struct {
 	Timestamp time.Time `json:"timestamp"`
 	Message   string    `json:"message"`
 	Source    string    `json:"source"`
 	Severity  string    `json:"severity"`
+	Type      string    `json:"type,omitempty"`
+	Input     string    `json:"input,omitempty"`
+}
+
+// PolicyDecision records a policy rule matching a tool call
+type PolicyDecision struct {
+	Timestamp time.Time `json:"timestamp"`
+	ToolUseID string    `json:"tool_use_id,omitempty"`
+	ToolName  string    `json:"tool_name"`
+	Input     string    `json:"input"`
+	Agent     string    `json:"agent,omitempty"`
+	Rule      string    `json:"rule"`
+	Decision  string    `json:"decision"`
+	Reason    string    `json:"reason"`
+}
+
+// Stop gate outcomes recorded on GateResult
+const (
+	GatePassed  = "passed"
+	GateBlocked = "blocked"
+	GateCapped  = "capped"
+)
+
+// GateResult records one evaluation of the stop gate
+type GateResult struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Outcome   string      `json:"outcome"`
+	Iteration int         `json:"iteration"`
+	Reason    string      `json:"reason,omitempty"`
+	OpenTodos int         `json:"open_todos"`
+	Checks    []GateCheck `json:"checks,omitempty"`
+}
+
+// GateCheck is the result of a single stop gate check command
+type GateCheck struct {
+	Name       string `json:"name"`
+	Command    string `json:"command"`
+	ExitCode   int    `json:"exit_code"`
+	Passed     bool   `json:"passed"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Output     string `json:"output,omitempty"`
+}
+
+// GateBlocks returns how many times the stop gate has blocked in a row
+// since the most recent prompt
+func (s *SessionState) GateBlocks() int {
+	var since time.Time
+	if len(s.Prompts) > 0 {
+		since = s.Prompts[len(s.Prompts)-1].Timestamp
+	}
+	blocks := 0
+	for i := len(s.GateResults) - 1; i >= 0; i-- {
+		result := s.GateResults[i]
+		if result.Timestamp.Before(since) || result.Outcome != GateBlocked {
+			break
+		}
+		blocks++
+	}
+	return blocks
 }
 
 // PromptEntry tracks user prompts and responses
 type PromptEntry struct {
-	Timestamp time.Time `json:"timestamp"`
-	Prompt    string    `json:"prompt"`
-	Response  string    `json:"response"`
-	ToolsUsed []string  `json:"tools_used"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Prompt      string      `json:"prompt"`
+	Response    string      `json:"response"`
+	ToolsUsed   []string    `json:"tools_used"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+	DurationMs  int64       `json:"duration_ms,omitempty"`
+	Turns       int         `json:"turns,omitempty"`
+	Usage       *TokenUsage `json:"usage,omitempty"`
 }
 
 // NotificationEntry tracks system notifications
@@ -74,3 +217,38 @@ type TodoItem struct {
 	Status     string `json:"status"`
 	ActiveForm string `json:"activeForm"`
 }
+
+// TokenUsage aggregates token counts and estimated cost over a set of messages
+type TokenUsage struct {
+	Messages            int     `json:"messages"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CostUSD             float64 `json:"cost_usd"`
+}
+
+// TotalTokens returns the sum of all token counts
+func (u TokenUsage) TotalTokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationTokens + u.CacheReadTokens
+}
+
+// Add accumulates other into u
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.Messages += other.Messages
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CostUSD += other.CostUSD
+}
+
+// UsageState tracks transcript ingestion progress and session token usage
+type UsageState struct {
+	TranscriptPath string                `json:"transcript_path"`
+	Offset         int64                 `json:"offset"`
+	LastMessageID  string                `json:"last_message_id,omitempty"`
+	LastUsage      TokenUsage            `json:"last_usage,omitzero"` // Already counted for LastMessageID
+	Total          TokenUsage            `json:"total"`
+	ByModel        map[string]TokenUsage `json:"by_model"`
+}
@@ -0,0 +1,87 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PriceFileName is the optional price table override in the .spcstr directory
+const PriceFileName = "prices.json"
+
+// Price is the USD cost per million tokens for a model family
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// PriceTable maps model name prefixes to prices
+type PriceTable map[string]Price
+
+// DefaultPrices returns the built-in price table
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-haiku-4":    {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1},
+		"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+		"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.3, CacheRead: 0.03},
+	}
+}
+
+// LoadPrices returns the default price table with entries from the file at
+// path layered on top. A missing file yields the defaults.
+func LoadPrices(path string) (PriceTable, error) {
+	prices := DefaultPrices()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return prices, nil
+		}
+		return prices, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var overrides PriceTable
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return prices, fmt.Errorf("failed to parse price table %s: %w", path, err)
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+
+	return prices, nil
+}
+
+// Lookup returns the price for the longest prefix matching the model
+func (p PriceTable) Lookup(model string) (Price, bool) {
+	best := ""
+	for prefix := range p {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return p[best], true
+}
+
+// Cost estimates the USD cost of usage on a model; unknown models cost 0
+func (p PriceTable) Cost(model string, usage Usage) float64 {
+	price, ok := p.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return (float64(usage.InputTokens)*price.Input +
+		float64(usage.OutputTokens)*price.Output +
+		float64(usage.CacheCreationTokens)*price.CacheWrite +
+		float64(usage.CacheReadTokens)*price.CacheRead) / 1_000_000
+}
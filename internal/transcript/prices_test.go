@@ -0,0 +1,89 @@
+package transcript
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPriceTableCost(t *testing.T) {
+	prices := DefaultPrices()
+
+	tests := []struct {
+		name  string
+		model string
+		usage Usage
+		want  float64
+	}{
+		{
+			name:  "dated model matches family prefix",
+			model: "claude-sonnet-4-5-20250929",
+			usage: Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			want:  18,
+		},
+		{
+			name:  "longest prefix wins",
+			model: "claude-opus-4-5-20251101",
+			usage: Usage{OutputTokens: 1_000_000},
+			want:  25,
+		},
+		{
+			name:  "cache tokens are priced separately",
+			model: "claude-sonnet-4-20250514",
+			usage: Usage{CacheCreationTokens: 1_000_000, CacheReadTokens: 1_000_000},
+			want:  4.05,
+		},
+		{
+			name:  "unknown model is free",
+			model: "local-model",
+			usage: Usage{InputTokens: 1_000_000},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prices.Cost(tt.model, tt.usage)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPrices(t *testing.T) {
+	dir := t.TempDir()
+
+	prices, err := LoadPrices(filepath.Join(dir, PriceFileName))
+	if err != nil {
+		t.Fatalf("LoadPrices() missing file error: %v", err)
+	}
+	if len(prices) != len(DefaultPrices()) {
+		t.Errorf("LoadPrices() without overrides returned %d entries", len(prices))
+	}
+
+	path := filepath.Join(dir, PriceFileName)
+	overrides := `{"claude-sonnet-4": {"input": 1, "output": 2}, "local-model": {"input": 0.5}}`
+	if err := os.WriteFile(path, []byte(overrides), 0644); err != nil {
+		t.Fatalf("Failed to write price table: %v", err)
+	}
+
+	prices, err = LoadPrices(path)
+	if err != nil {
+		t.Fatalf("LoadPrices() error: %v", err)
+	}
+	if price, _ := prices.Lookup("claude-sonnet-4-5"); price.Input != 1 || price.Output != 2 {
+		t.Errorf("override not applied: %+v", price)
+	}
+	if _, ok := prices.Lookup("local-model-v2"); !ok {
+		t.Error("custom model not added")
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatalf("Failed to write price table: %v", err)
+	}
+	if _, err := LoadPrices(path); err == nil {
+		t.Error("LoadPrices() expected error for malformed file")
+	}
+}
@@ -0,0 +1,283 @@
+// Package transcript reads Claude Code session transcripts and extracts
+// per-message token usage
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxLineSize bounds a single transcript record (tool results included)
+const maxLineSize = 32 * 1024 * 1024
+
+// Usage holds the token counts reported for one assistant message
+type Usage struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// Total returns the sum of all token counts
+func (u Usage) Total() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationTokens + u.CacheReadTokens
+}
+
+// max returns the larger of each token count in u and other
+func (u Usage) max(other Usage) Usage {
+	return Usage{
+		InputTokens:         max(u.InputTokens, other.InputTokens),
+		OutputTokens:        max(u.OutputTokens, other.OutputTokens),
+		CacheCreationTokens: max(u.CacheCreationTokens, other.CacheCreationTokens),
+		CacheReadTokens:     max(u.CacheReadTokens, other.CacheReadTokens),
+	}
+}
+
+// sub returns the token counts in u beyond those in other
+func (u Usage) sub(other Usage) Usage {
+	return Usage{
+		InputTokens:         u.InputTokens - other.InputTokens,
+		OutputTokens:        u.OutputTokens - other.OutputTokens,
+		CacheCreationTokens: u.CacheCreationTokens - other.CacheCreationTokens,
+		CacheReadTokens:     u.CacheReadTokens - other.CacheReadTokens,
+	}
+}
+
+// Record is the usage of a single assistant message in the transcript
+type Record struct {
+	Timestamp time.Time
+	MessageID string
+	Model     string
+	Sidechain bool
+	Usage     Usage
+	// Continued records add to a message counted by an earlier Tail; their
+	// Usage holds only the tokens reported since
+	Continued bool
+}
+
+// Cursor marks how far a transcript has been consumed
+type Cursor struct {
+	Offset        int64
+	LastMessageID string
+	// LastUsage is the usage counted so far for LastMessageID
+	LastUsage Usage
+}
+
+// Exchange is the assistant side of the most recent user prompt
+type Exchange struct {
+	Response string
+	Turns    int
+}
+
+// entry is the subset of a transcript line spcstr cares about
+type entry struct {
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	IsSidechain bool      `json:"isSidechain"`
+	IsMeta      bool      `json:"isMeta"`
+	Message     struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Usage   *Usage          `json:"usage"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// contentBlock is one element of a message content list
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Tail reads the transcript from the cursor and returns usage records for
+// every new assistant message, plus the cursor to resume from. Only
+// complete lines are consumed, so a line still being written is picked up
+// on the next call. Streamed messages span several lines sharing a message
+// ID, and later lines carry the final output tokens, so each message counts
+// the largest usage reported on any of its lines.
+func Tail(ctx context.Context, path string, cursor Cursor) ([]Record, Cursor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, cursor, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, cursor, err
+	}
+	// A shorter file means the transcript was replaced; start over
+	if info.Size() < cursor.Offset {
+		cursor = Cursor{}
+	}
+
+	if _, err := file.Seek(cursor.Offset, io.SeekStart); err != nil {
+		return nil, cursor, err
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var records []Record
+	// Usage of the last message already counted by an earlier Tail
+	counted := cursor.LastUsage
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, cursor, err
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// Partial trailing line; leave it for the next call
+			break
+		}
+		if err != nil {
+			return nil, cursor, err
+		}
+		if len(line) > maxLineSize {
+			return nil, cursor, fmt.Errorf("transcript line at offset %d exceeds %d bytes", cursor.Offset, maxLineSize)
+		}
+		cursor.Offset += int64(len(line))
+
+		record, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		if record.MessageID == "" || record.MessageID != cursor.LastMessageID {
+			if record.MessageID != "" {
+				cursor.LastMessageID = record.MessageID
+				cursor.LastUsage = record.Usage
+				counted = Usage{}
+			}
+			records = append(records, record)
+			continue
+		}
+
+		// A later line of the last message; count only what it adds
+		cursor.LastUsage = cursor.LastUsage.max(record.Usage)
+		if n := len(records); n > 0 && records[n-1].MessageID == record.MessageID {
+			records[n-1].Usage = cursor.LastUsage.sub(counted)
+		} else if added := cursor.LastUsage.sub(counted); added.Total() > 0 {
+			record.Usage = added
+			record.Continued = true
+			records = append(records, record)
+		}
+	}
+
+	return records, cursor, nil
+}
+
+// parseLine extracts a usage record from an assistant transcript line
+func parseLine(line []byte) (Record, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return Record{}, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return Record{}, false
+	}
+	if e.Type != "assistant" || e.Message.Usage == nil {
+		return Record{}, false
+	}
+
+	return Record{
+		Timestamp: e.Timestamp,
+		MessageID: e.Message.ID,
+		Model:     e.Message.Model,
+		Sidechain: e.IsSidechain,
+		Usage:     *e.Message.Usage,
+	}, true
+}
+
+// LastExchange scans the transcript for the most recent user prompt on the
+// main thread and returns the final assistant text written after it along
+// with the number of assistant messages (turns) it took
+func LastExchange(ctx context.Context, path string) (Exchange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Exchange{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var exchange Exchange
+	lastMessageID := ""
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return Exchange{}, err
+		}
+
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.IsSidechain || e.IsMeta {
+			continue
+		}
+
+		switch e.Type {
+		case "user":
+			// Tool results are also user messages; only typed prompts reset
+			if _, isPrompt := promptText(e.Message.Content); isPrompt {
+				exchange = Exchange{}
+				lastMessageID = ""
+			}
+		case "assistant":
+			if e.Message.ID == "" || e.Message.ID != lastMessageID {
+				exchange.Turns++
+				lastMessageID = e.Message.ID
+			}
+			if text := assistantText(e.Message.Content); text != "" {
+				exchange.Response = text
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Exchange{}, err
+	}
+
+	return exchange, nil
+}
+
+// promptText returns the text of a user message typed by the user, which is
+// either a plain string or a list holding text blocks but no tool results
+func promptText(content json.RawMessage) (string, bool) {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text, true
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return "", false
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Type != "text" {
+			return "", false
+		}
+		parts = append(parts, block.Text)
+	}
+	return strings.Join(parts, "\n"), len(parts) > 0
+}
+
+// assistantText joins the text blocks of an assistant message
+func assistantText(content json.RawMessage) string {
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,144 @@
+package transcript
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	userLine      = `{"type":"user","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":"hi"}}` + "\n"
+	assistantLine = `{"type":"assistant","timestamp":"2025-01-01T12:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}` + "\n"
+	streamedLine  = `{"type":"assistant","timestamp":"2025-01-01T12:00:06Z","message":{"id":"msg_1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}` + "\n"
+	sidechainLine = `{"type":"assistant","timestamp":"2025-01-01T12:00:09Z","isSidechain":true,"message":{"id":"msg_2","model":"claude-haiku-4-5","usage":{"input_tokens":5,"output_tokens":7}}}` + "\n"
+)
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	ctx := context.Background()
+
+	// First pass: a complete streamed message plus a torn line
+	torn := sidechainLine[:40]
+	if err := os.WriteFile(path, []byte(userLine+assistantLine+streamedLine+torn), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	records, cursor, err := Tail(ctx, path, Cursor{})
+	if err != nil {
+		t.Fatalf("Tail() error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Tail() returned %d records, want 1 (streamed lines deduped)", len(records))
+	}
+	if got := records[0]; got.MessageID != "msg_1" || got.Usage.Total() != 1130 || got.Sidechain {
+		t.Errorf("unexpected record: %+v", got)
+	}
+	wantOffset := int64(len(userLine + assistantLine + streamedLine))
+	if cursor.Offset != wantOffset || cursor.LastMessageID != "msg_1" {
+		t.Errorf("cursor = %+v, want offset %d after msg_1", cursor, wantOffset)
+	}
+
+	// Second pass: the torn line is completed
+	if err := os.WriteFile(path, []byte(userLine+assistantLine+streamedLine+sidechainLine), 0644); err != nil {
+		t.Fatalf("Failed to rewrite transcript: %v", err)
+	}
+
+	records, cursor, err = Tail(ctx, path, cursor)
+	if err != nil {
+		t.Fatalf("second Tail() error: %v", err)
+	}
+	if len(records) != 1 || records[0].MessageID != "msg_2" || !records[0].Sidechain {
+		t.Errorf("second Tail() records = %+v, want only sidechain msg_2", records)
+	}
+
+	// Nothing new
+	records, next, err := Tail(ctx, path, cursor)
+	if err != nil || len(records) != 0 || next != cursor {
+		t.Errorf("idle Tail() = %v, %+v, %v; want no records and unchanged cursor", records, next, err)
+	}
+}
+
+func TestTailStreamedUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	ctx := context.Background()
+	streamed := func(outputTokens string) string {
+		return `{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":` + outputTokens + `}}}` + "\n"
+	}
+
+	// The first line carries a placeholder output count
+	transcript := streamed("1") + streamed("40")
+	if err := os.WriteFile(path, []byte(transcript), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+	records, cursor, err := Tail(ctx, path, Cursor{})
+	if err != nil {
+		t.Fatalf("Tail() error: %v", err)
+	}
+	if len(records) != 1 || records[0].Usage.OutputTokens != 40 || records[0].Continued {
+		t.Fatalf("Tail() records = %+v, want one message with 40 output tokens", records)
+	}
+
+	// The final line arrives after the cursor and adds only the increase
+	transcript += streamed("90")
+	if err := os.WriteFile(path, []byte(transcript), 0644); err != nil {
+		t.Fatalf("Failed to rewrite transcript: %v", err)
+	}
+	records, cursor, err = Tail(ctx, path, cursor)
+	if err != nil {
+		t.Fatalf("second Tail() error: %v", err)
+	}
+	want := Usage{OutputTokens: 50}
+	if len(records) != 1 || records[0].Usage != want || !records[0].Continued {
+		t.Errorf("second Tail() records = %+v, want a continued record adding 50 output tokens", records)
+	}
+	if cursor.LastUsage.OutputTokens != 90 || cursor.LastUsage.InputTokens != 10 {
+		t.Errorf("cursor usage = %+v, want the final message usage", cursor.LastUsage)
+	}
+
+	// A repeated line adds nothing
+	transcript += streamed("90")
+	if err := os.WriteFile(path, []byte(transcript), 0644); err != nil {
+		t.Fatalf("Failed to rewrite transcript: %v", err)
+	}
+	if records, _, err := Tail(ctx, path, cursor); err != nil || len(records) != 0 {
+		t.Errorf("third Tail() = %+v, %v; want no records", records, err)
+	}
+}
+
+func TestTailMissingFile(t *testing.T) {
+	_, _, err := Tail(context.Background(), filepath.Join(t.TempDir(), "missing.jsonl"), Cursor{})
+	if !os.IsNotExist(err) {
+		t.Errorf("Tail() error = %v, want not-exist", err)
+	}
+}
+
+func TestLastExchange(t *testing.T) {
+	lines := []string{
+		`{"type":"user","message":{"role":"user","content":"first prompt"}}`,
+		`{"type":"assistant","message":{"id":"a1","content":[{"type":"text","text":"old answer"}]}}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"second prompt"}]}}`,
+		`{"type":"assistant","message":{"id":"a2","content":[{"type":"text","text":"Let me look."}]}}`,
+		`{"type":"assistant","message":{"id":"a2","content":[{"type":"tool_use","name":"Read"}]}}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"file"}]}}`,
+		`{"type":"assistant","isSidechain":true,"message":{"id":"s1","content":[{"type":"text","text":"agent chatter"}]}}`,
+		`{"type":"user","isMeta":true,"message":{"role":"user","content":"caveat"}}`,
+		`{"type":"assistant","message":{"id":"a3","content":[{"type":"text","text":"All done."}]}}`,
+	}
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	exchange, err := LastExchange(context.Background(), path)
+	if err != nil {
+		t.Fatalf("LastExchange() error: %v", err)
+	}
+	if exchange.Response != "All done." || exchange.Turns != 2 {
+		t.Errorf("LastExchange() = %+v, want final answer after 2 turns", exchange)
+	}
+}
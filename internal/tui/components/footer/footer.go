@@ -110,6 +110,7 @@ func (m *Model) UpdateForView(viewName string) {
 		m.keybinds = append(baseKeybinds,
 			Keybind{Key: "↑/↓", Description: "Navigate", Global: false},
 			Keybind{Key: "r", Description: "Refresh", Global: false},
+			Keybind{Key: "[/]", Description: "Timeline", Global: false},
 		)
 	default:
 		m.keybinds = baseKeybinds
@@ -83,8 +83,8 @@ func TestUpdateForView(t *testing.T) {
 		{
 			name:             "Observe view",
 			viewName:         "observe",
-			expectedKeybinds: 5, // 3 global + 2 view-specific
-			shouldContain:    []string{"Navigate", "Refresh"},
+			expectedKeybinds: 6, // 3 global + 3 view-specific
+			shouldContain:    []string{"Navigate", "Refresh", "Timeline"},
 		},
 		{
 			name:             "Unknown view",
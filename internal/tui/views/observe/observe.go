@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dylan/spcstr/internal/config"
+	"github.com/dylan/spcstr/internal/metrics"
 	"github.com/dylan/spcstr/internal/state"
 	"github.com/dylan/spcstr/internal/tui/styles"
 	tea "github.com/charmbracelet/bubbletea"
@@ -41,6 +43,7 @@ type ObserveState struct {
 	focusedPane    PaneType
 	scrollOffset   int
 	dashboardScroll int
+	timelineOffset int
 	loading        bool
 	error          string
 	lastUpdate     time.Time
@@ -54,12 +57,20 @@ type SessionInfo struct {
 	AgentCount    int
 	FileCount     int
 	ToolCount     int
+	ErrorCount    int
+	TotalTokens   int64
+	CostUSD       float64
 	TodoSummary   string
 }
 
 type DashboardData struct {
 	Session       *state.SessionState
 	FormattedData map[string]interface{}
+	// Metrics holds the project-wide hook latency and failure metrics
+	Metrics *metrics.File
+	// Capture holds the spcstr hooks that run for the project, its own or
+	// those installed by init --global
+	Capture []config.HookEntry
 }
 
 type PaneStyles struct {
@@ -77,6 +88,15 @@ type PaneStyles struct {
 	StatValue       lipgloss.Style
 }
 
+// timelineRows is the number of tool calls visible in the timeline at once
+const timelineRows = 8
+
+// conversationRows is the number of prompts summarized in the conversation view
+const conversationRows = 5
+
+// agentTreeRows is the number of agent executions shown in the agent tree
+const agentTreeRows = 8
+
 type sessionsLoadedMsg struct {
 	sessions []SessionInfo
 	err      error
@@ -180,13 +200,16 @@ func (m Model) loadSessions() tea.Msg {
 		}
 		
 		info := SessionInfo{
-			ID:         id,
-			CreatedAt:  sessionState.CreatedAt,
-			UpdatedAt:  sessionState.UpdatedAt,
-			Active:     sessionState.SessionActive,
-			AgentCount: len(sessionState.Agents),
-			FileCount:  len(sessionState.Files.New) + len(sessionState.Files.Edited) + len(sessionState.Files.Read),
-			ToolCount:  sumToolUsage(sessionState.ToolsUsed),
+			ID:          id,
+			CreatedAt:   sessionState.CreatedAt,
+			UpdatedAt:   sessionState.UpdatedAt,
+			Active:      sessionState.SessionActive,
+			AgentCount:  len(sessionState.Agents),
+			FileCount:   len(sessionState.Files.New) + len(sessionState.Files.Edited) + len(sessionState.Files.Read),
+			ToolCount:   sumToolUsage(sessionState.ToolsUsed),
+			ErrorCount:  len(sessionState.Errors),
+			TotalTokens: sessionState.Usage.Total.TotalTokens(),
+			CostUSD:     sessionState.Usage.Total.CostUSD,
 		}
 		
 		if sessionState.Todos.Total > 0 {
@@ -216,6 +239,13 @@ func (m Model) loadSessionData(sessionID string) tea.Cmd {
 			Session:       sessionState,
 			FormattedData: formatSessionData(sessionState),
 		}
+		// Hook health is a nice-to-have; a broken metrics file hides the panel
+		if hookMetrics, err := metrics.Load(m.basePath); err == nil {
+			dashboard.Metrics = hookMetrics
+		}
+		if capture, err := config.CapturedHooks(filepath.Dir(m.basePath)); err == nil {
+			dashboard.Capture = capture
+		}
 		
 		return sessionDataMsg{dashboard, nil}
 	}
@@ -233,12 +263,15 @@ func (m Model) watchSessionFile(sessionID string) tea.Cmd {
 			return nil
 		}
 		
-		sessionPath := filepath.Join(m.basePath, "sessions", sessionID, "state.json")
-		err = watcher.Add(sessionPath)
+		sessionDir := filepath.Join(m.basePath, "sessions", sessionID)
+		err = watcher.Add(filepath.Join(sessionDir, state.StateFileName))
 		if err != nil {
 			watcher.Close()
 			return nil
 		}
+		// Hooks append to the journal and only rewrite the state.json
+		// snapshot now and then; sessions from before the journal have none
+		watcher.Add(filepath.Join(sessionDir, state.JournalFileName))
 		
 		m.fileWatcher = watcher
 		
@@ -384,6 +417,16 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			)
 		}
 		
+	case "[":
+		// Scroll tool timeline towards older calls
+		m.state.timelineOffset++
+
+	case "]":
+		// Scroll tool timeline towards newer calls
+		if m.state.timelineOffset > 0 {
+			m.state.timelineOffset--
+		}
+
 	case "r":
 		// Manual refresh
 		if m.state.dashboard != nil && m.state.dashboard.Session != nil {
@@ -475,6 +518,14 @@ func (m Model) renderSessionList(width, height int) string {
 				subInfo += fmt.Sprintf(" | Tasks: %s", session.TodoSummary)
 			}
 			
+			if session.ErrorCount > 0 {
+				subInfo += fmt.Sprintf(" | Errors: %d", session.ErrorCount)
+			}
+			
+			if session.TotalTokens > 0 {
+				subInfo += fmt.Sprintf(" | %s tok %s", formatTokens(session.TotalTokens), formatCost(session.CostUSD))
+			}
+			
 			if i == m.state.selected {
 				item = m.paneStyles.SelectedItem.Render("▸ " + item)
 				subInfo = m.paneStyles.SelectedItem.Render(subInfo)
@@ -582,38 +633,16 @@ func (m Model) formatDashboardContent() string {
 		m.paneStyles.StatValue.Render(lastUpdate),
 	))
 	
+	// Token Usage Section
+	if session.Usage.Total.Messages > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.renderTokenUsage(session)...)
+	}
+	
 	// Agents Section
-	if len(session.Agents) > 0 || len(session.AgentsHistory) > 0 {
+	if len(session.AgentsHistory) > 0 {
 		sections = append(sections, "")
-		sections = append(sections, m.paneStyles.SectionHeader.Render("── AGENTS ──"))
-		
-		if len(session.Agents) > 0 {
-			sections = append(sections, fmt.Sprintf("%s %s",
-				m.paneStyles.StatLabel.Render("Active:"),
-				m.paneStyles.StatValue.Render(strings.Join(session.Agents, ", ")),
-			))
-		}
-		
-		if len(session.AgentsHistory) > 0 {
-			sections = append(sections, fmt.Sprintf("%s %d agents executed",
-				m.paneStyles.StatLabel.Render("History:"),
-				len(session.AgentsHistory),
-			))
-			// Show last 3 agents
-			start := len(session.AgentsHistory) - 3
-			if start < 0 {
-				start = 0
-			}
-			for _, agent := range session.AgentsHistory[start:] {
-				dur := "running"
-				if agent.CompletedAt != nil {
-					dur = formatDuration(agent.CompletedAt.Sub(agent.StartedAt))
-				}
-				sections = append(sections, fmt.Sprintf("  • %s (%s)",
-					agent.Name, dur,
-				))
-			}
-		}
+		sections = append(sections, m.renderAgentTree(session.AgentsHistory)...)
 	}
 	
 	// Files Section
@@ -672,6 +701,192 @@ func (m Model) formatDashboardContent() string {
 		}
 	}
 	
+	// Tool Timeline Section
+	if len(session.ToolCalls) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.renderToolTimeline(session.ToolCalls)...)
+	}
+	
+	// Commands Section
+	if len(session.Commands) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.paneStyles.SectionHeader.Render("── COMMANDS ──"))
+		
+		failed := 0
+		for _, command := range session.Commands {
+			if command.ExitCode != nil && *command.ExitCode != 0 {
+				failed++
+			}
+		}
+		sections = append(sections, fmt.Sprintf("%s %s | %s %s",
+			m.paneStyles.StatLabel.Render("Run:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", len(session.Commands))),
+			m.paneStyles.StatLabel.Render("Failed:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", failed)),
+		))
+		
+		// Show last 5 commands, newest first
+		for i := len(session.Commands) - 1; i >= 0 && i >= len(session.Commands)-5; i-- {
+			command := session.Commands[i]
+			exit := "-"
+			exitStyle := m.paneStyles.StatLabel
+			if command.ExitCode != nil {
+				exit = fmt.Sprintf("%d", *command.ExitCode)
+				exitStyle = m.paneStyles.ActiveIndicator
+				if *command.ExitCode != 0 {
+					exitStyle = m.baseStyles.Error
+				}
+			}
+			
+			text := strings.Join(strings.Fields(command.Command), " ")
+			if len(text) > 50 {
+				text = text[:47] + "..."
+			}
+			if command.Background {
+				text += " &"
+			}
+			sections = append(sections, fmt.Sprintf("  %s %3s %7s  $ %s",
+				m.baseStyles.TextMuted.Render(command.Timestamp.Local().Format("15:04")),
+				exitStyle.Render(exit),
+				formatCallDuration(command.DurationMs),
+				text,
+			))
+		}
+	}
+	
+	// Errors Section
+	if len(session.Errors) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.paneStyles.SectionHeader.Render("── ERRORS ──"))
+		
+		bySeverity := make(map[string]int)
+		for _, entry := range session.Errors {
+			bySeverity[entry.Severity]++
+		}
+		sections = append(sections, fmt.Sprintf("%s %s | %s %s",
+			m.paneStyles.StatLabel.Render("Errors:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", bySeverity["error"])),
+			m.paneStyles.StatLabel.Render("Warnings:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", bySeverity["warning"])),
+		))
+		
+		// Show last 3 errors, newest first
+		for i := len(session.Errors) - 1; i >= 0 && i >= len(session.Errors)-3; i-- {
+			entry := session.Errors[i]
+			icon := m.baseStyles.Error.Render("✗")
+			if entry.Severity != "error" {
+				icon = m.paneStyles.StatLabel.Render("!")
+			}
+			message := entry.Message
+			if len(message) > 50 {
+				message = message[:47] + "..."
+			}
+			sections = append(sections, fmt.Sprintf("  %s %s %s: %s",
+				icon,
+				m.baseStyles.TextMuted.Render(entry.Timestamp.Local().Format("15:04")),
+				entry.Source,
+				message,
+			))
+			if entry.Input != "" {
+				input := entry.Input
+				if len(input) > 50 {
+					input = input[:47] + "..."
+				}
+				sections = append(sections, m.baseStyles.TextMuted.Render("      "+input))
+			}
+		}
+	}
+	
+	// Capture Section
+	if capture := m.renderCapture(m.state.dashboard.Capture); len(capture) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, capture...)
+	}
+
+	// Hook Health Section
+	if health := m.renderHookHealth(m.state.dashboard.Metrics); len(health) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, health...)
+	}
+
+	// Policy Section
+	if len(session.PolicyDecisions) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.paneStyles.SectionHeader.Render("── POLICY ──"))
+		
+		byDecision := make(map[string]int)
+		for _, decision := range session.PolicyDecisions {
+			byDecision[decision.Decision]++
+		}
+		sections = append(sections, fmt.Sprintf("%s %s | %s %s | %s %s",
+			m.paneStyles.StatLabel.Render("Denied:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", byDecision["deny"])),
+			m.paneStyles.StatLabel.Render("Asked:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", byDecision["ask"])),
+			m.paneStyles.StatLabel.Render("Allowed:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", byDecision["allow"])),
+		))
+		
+		// Show last 3 decisions, newest first
+		for i := len(session.PolicyDecisions) - 1; i >= 0 && i >= len(session.PolicyDecisions)-3; i-- {
+			decision := session.PolicyDecisions[i]
+			icon := m.paneStyles.ActiveIndicator.Render("✓")
+			switch decision.Decision {
+			case "deny":
+				icon = m.baseStyles.Error.Render("⊘")
+			case "ask":
+				icon = m.paneStyles.StatLabel.Render("?")
+			}
+			input := decision.Input
+			if len(input) > 40 {
+				input = input[:37] + "..."
+			}
+			sections = append(sections, fmt.Sprintf("  %s %s %s %s %s",
+				icon,
+				m.baseStyles.TextMuted.Render(decision.Timestamp.Local().Format("15:04")),
+				decision.ToolName,
+				input,
+				m.baseStyles.TextMuted.Render("["+decision.Rule+"]"),
+			))
+		}
+	}
+
+	// Stop Gate Section
+	if len(session.GateResults) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.paneStyles.SectionHeader.Render("── STOP GATE ──"))
+
+		last := session.GateResults[len(session.GateResults)-1]
+		icon := m.paneStyles.ActiveIndicator.Render("✓")
+		switch last.Outcome {
+		case state.GateBlocked:
+			icon = m.baseStyles.Error.Render("✗")
+		case state.GateCapped:
+			icon = m.paneStyles.StatLabel.Render("!")
+		}
+		sections = append(sections, fmt.Sprintf("  %s %s %s %s %s",
+			icon,
+			m.baseStyles.TextMuted.Render(last.Timestamp.Local().Format("15:04")),
+			last.Outcome,
+			m.paneStyles.StatLabel.Render("Attempt:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", last.Iteration)),
+		))
+		if last.OpenTodos > 0 {
+			sections = append(sections, m.baseStyles.TextMuted.Render(fmt.Sprintf("      %d open todos", last.OpenTodos)))
+		}
+		for _, check := range last.Checks {
+			checkIcon := m.paneStyles.ActiveIndicator.Render("✓")
+			if !check.Passed {
+				checkIcon = m.baseStyles.Error.Render("✗")
+			}
+			sections = append(sections, fmt.Sprintf("      %s %s %s",
+				checkIcon,
+				check.Name,
+				m.baseStyles.TextMuted.Render(fmt.Sprintf("%.1fs", float64(check.DurationMs)/1000)),
+			))
+		}
+	}
+
 	// Tasks Section
 	if session.Todos.Total > 0 {
 		sections = append(sections, "")
@@ -711,6 +926,12 @@ func (m Model) formatDashboardContent() string {
 		}
 	}
 	
+	// Conversation Section
+	if len(session.Prompts) > 0 {
+		sections = append(sections, "")
+		sections = append(sections, m.renderConversation(session.Prompts)...)
+	}
+	
 	// Activity Section
 	if len(session.Prompts) > 0 || len(session.Notifications) > 0 {
 		sections = append(sections, "")
@@ -778,8 +999,431 @@ func (m Model) formatDashboardContent() string {
 	return strings.Join(sections, "\n")
 }
 
+// renderHookHealth lists the hooks flagged slow or failing by their recent
+// runs. Healthy hooks are left out so the panel only appears when needed.
+func (m Model) renderHookHealth(file *metrics.File) []string {
+	if file == nil {
+		return nil
+	}
+
+	var lines []string
+	for _, name := range file.Names() {
+		h := file.Hooks[name]
+		health := h.Health()
+		if health == metrics.HealthOK {
+			continue
+		}
+
+		icon := m.paneStyles.StatLabel.Render("!")
+		if health == metrics.HealthFailing {
+			icon = m.baseStyles.Error.Render("✗")
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s %s %s %s",
+			icon,
+			name,
+			health,
+			m.paneStyles.StatLabel.Render("p95:"),
+			m.paneStyles.StatValue.Render(formatCallDuration(h.Total.P95Us/1000)),
+		))
+		if health == metrics.HealthFailing && h.LastError != "" {
+			message := h.LastError
+			if len(message) > 50 {
+				message = message[:47] + "..."
+			}
+			lines = append(lines, m.baseStyles.TextMuted.Render("      "+message))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return append([]string{m.paneStyles.SectionHeader.Render("── HOOK HEALTH ──")}, lines...)
+}
+
+// renderCapture shows how many events the installed hooks capture, and
+// which are filtered by a tool matcher or not captured at all
+func (m Model) renderCapture(entries []config.HookEntry) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	all := config.DefaultHookEntries()
+	lines := []string{fmt.Sprintf("  %s %s",
+		m.paneStyles.StatLabel.Render("Events:"),
+		m.paneStyles.StatValue.Render(fmt.Sprintf("%d/%d", len(entries), len(all))),
+	)}
+
+	installed := make(map[string]bool, len(entries))
+	global := 0
+	for _, entry := range entries {
+		installed[entry.Hook] = true
+		if entry.Global {
+			global++
+		}
+		if !entry.CapturesAll() {
+			lines = append(lines, fmt.Sprintf("  %s %s %s",
+				m.paneStyles.StatLabel.Render("~"),
+				entry.Hook,
+				m.baseStyles.TextMuted.Render("only "+entry.Matcher),
+			))
+		}
+	}
+	for _, entry := range all {
+		if !installed[entry.Hook] {
+			lines = append(lines, m.baseStyles.TextMuted.Render(fmt.Sprintf("  - %s not captured", entry.Hook)))
+		}
+	}
+	if global > 0 {
+		lines = append(lines, m.baseStyles.TextMuted.Render(fmt.Sprintf("  %d from init --global", global)))
+	}
+	return append([]string{m.paneStyles.SectionHeader.Render("── CAPTURE ──")}, lines...)
+}
+
+// renderToolTimeline renders a window of tool calls, newest first,
+// scrolled back by the timeline offset
+func (m Model) renderToolTimeline(calls []state.ToolCallEntry) []string {
+	maxOffset := len(calls) - timelineRows
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	if m.state.timelineOffset > maxOffset {
+		m.state.timelineOffset = maxOffset
+	}
+	
+	newest := len(calls) - 1 - m.state.timelineOffset
+	oldest := newest - timelineRows + 1
+	if oldest < 0 {
+		oldest = 0
+	}
+	
+	lines := []string{
+		m.paneStyles.SectionHeader.Render("── TOOL TIMELINE ──"),
+		m.baseStyles.TextMuted.Render(fmt.Sprintf("Showing %d-%d of %d calls ([/] to scroll)",
+			len(calls)-newest, len(calls)-oldest, len(calls))),
+	}
+	
+	for i := newest; i >= oldest; i-- {
+		call := calls[i]
+		statusIcon := "◐"
+		statusStyle := m.paneStyles.StatLabel
+		dur := "running"
+		switch call.Status {
+		case state.ToolCallSucceeded:
+			statusIcon = "✓"
+			statusStyle = m.paneStyles.ActiveIndicator
+			dur = formatCallDuration(call.DurationMs)
+		case state.ToolCallFailed:
+			statusIcon = "✗"
+			statusStyle = m.baseStyles.Error
+			dur = formatCallDuration(call.DurationMs)
+		}
+		
+		input := call.Input
+		if len(input) > 40 {
+			input = input[:37] + "..."
+		}
+		
+		lines = append(lines, fmt.Sprintf("  %s %s %-10s %7s  %s",
+			m.baseStyles.TextMuted.Render(call.StartedAt.Local().Format("15:04:05")),
+			statusStyle.Render(statusIcon),
+			call.ToolName,
+			dur,
+			m.baseStyles.TextMuted.Render(input),
+		))
+	}
+	
+	return lines
+}
+
 // Helper functions
 
+// formatCallDuration formats a tool call duration with sub-second precision
+func (m Model) renderAgentTree(agents []state.AgentExecution) []string {
+	running := 0
+	for _, agent := range agents {
+		if agent.Running() {
+			running++
+		}
+	}
+	
+	lines := []string{
+		m.paneStyles.SectionHeader.Render("── AGENT TREE ──"),
+		fmt.Sprintf("%s %s | %s %s | %s %s",
+			m.paneStyles.StatLabel.Render("Active:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", running)),
+			m.paneStyles.StatLabel.Render("Total:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", len(agents))),
+			m.paneStyles.StatLabel.Render("Peak parallel:"),
+			m.paneStyles.StatValue.Render(fmt.Sprintf("%d", peakAgentConcurrency(agents))),
+		),
+	}
+	
+	nodes := agentTreeOrder(agents)
+	if len(nodes) > agentTreeRows {
+		lines = append(lines, m.baseStyles.TextMuted.Render(
+			fmt.Sprintf("  ... %d earlier agents", len(nodes)-agentTreeRows)))
+		nodes = nodes[len(nodes)-agentTreeRows:]
+	}
+	
+	for _, node := range nodes {
+		agent := node.agent
+		statusIcon := m.paneStyles.StatLabel.Render("◐")
+		dur := "running"
+		if !agent.Running() {
+			statusIcon = m.paneStyles.ActiveIndicator.Render("✓")
+			dur = formatDuration(agent.CompletedAt.Sub(agent.StartedAt))
+		}
+		
+		branch := ""
+		if node.depth > 0 {
+			branch = strings.Repeat("   ", node.depth-1) + "└─ "
+		}
+		parallel := ""
+		if node.parallel {
+			parallel = " ∥"
+		}
+		
+		description := agent.Description
+		if len(description) > 30 {
+			description = description[:27] + "..."
+		}
+		
+		if agent.Usage != nil {
+			dur += ", " + formatCost(agent.Usage.CostUSD)
+		}
+		
+		lines = append(lines, fmt.Sprintf("  %s%s %s%s %s (%s)",
+			branch,
+			statusIcon,
+			m.paneStyles.StatValue.Render(agent.Name),
+			parallel,
+			m.baseStyles.TextMuted.Render(description),
+			dur,
+		))
+	}
+	
+	return lines
+}
+
+// agentTreeNode is an agent execution placed in the rendered tree
+type agentTreeNode struct {
+	agent    state.AgentExecution
+	depth    int
+	parallel bool
+}
+
+// agentTreeOrder flattens executions depth-first so children follow their
+// parent, marking agents that overlapped a sibling in time
+func agentTreeOrder(agents []state.AgentExecution) []agentTreeNode {
+	known := make(map[string]bool, len(agents))
+	for _, agent := range agents {
+		known[agent.ID] = true
+	}
+	
+	children := make(map[string][]state.AgentExecution)
+	for _, agent := range agents {
+		parent := agent.ParentID
+		if !known[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], agent)
+	}
+	
+	var nodes []agentTreeNode
+	var walk func(parentID string, depth int)
+	walk = func(parentID string, depth int) {
+		siblings := children[parentID]
+		for i, agent := range siblings {
+			parallel := false
+			for j, other := range siblings {
+				if i != j && agentsOverlap(agent, other) {
+					parallel = true
+					break
+				}
+			}
+			nodes = append(nodes, agentTreeNode{agent: agent, depth: depth, parallel: parallel})
+			walk(agent.ID, depth+1)
+		}
+	}
+	walk("", 0)
+	
+	return nodes
+}
+
+// agentsOverlap reports whether two executions were running at the same time
+func agentsOverlap(a, b state.AgentExecution) bool {
+	aEnd, bEnd := time.Now(), time.Now()
+	if a.CompletedAt != nil {
+		aEnd = *a.CompletedAt
+	}
+	if b.CompletedAt != nil {
+		bEnd = *b.CompletedAt
+	}
+	return a.StartedAt.Before(bEnd) && b.StartedAt.Before(aEnd)
+}
+
+// peakAgentConcurrency returns the largest number of agents running at once
+func peakAgentConcurrency(agents []state.AgentExecution) int {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, agent := range agents {
+		edges = append(edges, edge{at: agent.StartedAt, delta: 1})
+		if agent.CompletedAt != nil {
+			edges = append(edges, edge{at: *agent.CompletedAt, delta: -1})
+		}
+	}
+	// Ends sort before starts at the same instant so back-to-back runs don't overlap
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+	
+	peak, current := 0, 0
+	for _, e := range edges {
+		current += e.delta
+		if current > peak {
+			peak = current
+		}
+	}
+	return peak
+}
+
+func (m Model) renderConversation(prompts []state.PromptEntry) []string {
+	lines := []string{
+		m.paneStyles.SectionHeader.Render("── CONVERSATION ──"),
+	}
+	
+	start := len(prompts) - conversationRows
+	if start < 0 {
+		start = 0
+	}
+	if start > 0 {
+		lines = append(lines, m.baseStyles.TextMuted.Render(
+			fmt.Sprintf("  ... %d earlier prompts", start)))
+	}
+	
+	for i := start; i < len(prompts); i++ {
+		prompt := prompts[i]
+		lines = append(lines, fmt.Sprintf("  %s %s %s",
+			m.paneStyles.StatValue.Render(">"),
+			m.baseStyles.TextMuted.Render(prompt.Timestamp.Local().Format("15:04")),
+			firstLine(prompt.Prompt, 60),
+		))
+		
+		if prompt.CompletedAt == nil {
+			lines = append(lines, m.baseStyles.TextMuted.Render("    … in progress"))
+			continue
+		}
+		
+		details := []string{formatCallDuration(prompt.DurationMs)}
+		if prompt.Turns > 0 {
+			details = append(details, fmt.Sprintf("%d turns", prompt.Turns))
+		}
+		if len(prompt.ToolsUsed) > 0 {
+			details = append(details, strings.Join(prompt.ToolsUsed, ","))
+		}
+		if prompt.Usage != nil {
+			details = append(details, formatCost(prompt.Usage.CostUSD))
+		}
+		
+		response := firstLine(prompt.Response, 60)
+		if response == "" {
+			response = "(no text response)"
+		}
+		lines = append(lines, fmt.Sprintf("    %s %s %s",
+			m.paneStyles.ActiveIndicator.Render("<"),
+			response,
+			m.baseStyles.TextMuted.Render("("+strings.Join(details, ", ")+")"),
+		))
+	}
+	
+	return lines
+}
+
+// firstLine returns the first line of s shortened to max runes
+func firstLine(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max-3]) + "..."
+	}
+	return s
+}
+
+func (m Model) renderTokenUsage(session *state.SessionState) []string {
+	total := session.Usage.Total
+	lines := []string{
+		m.paneStyles.SectionHeader.Render("── TOKENS ──"),
+		fmt.Sprintf("%s %s | %s %s | %s %s | %s %s",
+			m.paneStyles.StatLabel.Render("In:"),
+			m.paneStyles.StatValue.Render(formatTokens(total.InputTokens)),
+			m.paneStyles.StatLabel.Render("Out:"),
+			m.paneStyles.StatValue.Render(formatTokens(total.OutputTokens)),
+			m.paneStyles.StatLabel.Render("Cache W/R:"),
+			m.paneStyles.StatValue.Render(formatTokens(total.CacheCreationTokens)+"/"+formatTokens(total.CacheReadTokens)),
+			m.paneStyles.StatLabel.Render("Cost:"),
+			m.paneStyles.StatValue.Render(formatCost(total.CostUSD)),
+		),
+	}
+	
+	models := make([]string, 0, len(session.Usage.ByModel))
+	for model := range session.Usage.ByModel {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return session.Usage.ByModel[models[i]].CostUSD > session.Usage.ByModel[models[j]].CostUSD
+	})
+	for _, model := range models {
+		usage := session.Usage.ByModel[model]
+		lines = append(lines, fmt.Sprintf("  %s: %s tok, %s",
+			m.paneStyles.StatLabel.Render(model),
+			formatTokens(usage.TotalTokens()),
+			formatCost(usage.CostUSD),
+		))
+	}
+	
+	for i := len(session.Prompts) - 1; i >= 0; i-- {
+		if usage := session.Prompts[i].Usage; usage != nil {
+			lines = append(lines, fmt.Sprintf("  %s %s tok, %s",
+				m.paneStyles.StatLabel.Render("Last prompt:"),
+				formatTokens(usage.TotalTokens()),
+				formatCost(usage.CostUSD),
+			))
+			break
+		}
+	}
+	
+	return lines
+}
+
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+func formatCost(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func formatCallDuration(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return formatDuration(time.Duration(ms) * time.Millisecond)
+}
+
 func sumToolUsage(tools map[string]int) int {
 	total := 0
 	for _, count := range tools {
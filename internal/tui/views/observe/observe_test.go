@@ -0,0 +1,52 @@
+package observe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+func TestAgentTreeOrder(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) *time.Time {
+		ts := start.Add(time.Duration(seconds) * time.Second)
+		return &ts
+	}
+
+	agents := []state.AgentExecution{
+		{ID: "a", Name: "sm", StartedAt: *at(0), CompletedAt: at(60)},
+		{ID: "b", Name: "dev", StartedAt: *at(70), CompletedAt: at(90)},
+		{ID: "c", Name: "qa", ParentID: "a", StartedAt: *at(10), CompletedAt: at(20)},
+		{ID: "d", Name: "qa", StartedAt: *at(80), CompletedAt: at(100)},
+		{ID: "e", Name: "po", ParentID: "missing", StartedAt: *at(200), CompletedAt: at(210)},
+	}
+
+	nodes := agentTreeOrder(agents)
+
+	want := []struct {
+		id       string
+		depth    int
+		parallel bool
+	}{
+		{"a", 0, false},
+		{"c", 1, false},
+		{"b", 0, true},
+		{"d", 0, true},
+		{"e", 0, false},
+	}
+	if len(nodes) != len(want) {
+		t.Fatalf("agentTreeOrder() returned %d nodes, want %d", len(nodes), len(want))
+	}
+	for i, w := range want {
+		got := nodes[i]
+		if got.agent.ID != w.id || got.depth != w.depth || got.parallel != w.parallel {
+			t.Errorf("node[%d] = {%s %d %v}, want {%s %d %v}",
+				i, got.agent.ID, got.depth, got.parallel, w.id, w.depth, w.parallel)
+		}
+	}
+
+	if peak := peakAgentConcurrency(agents); peak != 2 {
+		t.Errorf("peakAgentConcurrency() = %d, want 2", peak)
+	}
+}
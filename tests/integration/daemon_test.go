@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dylan/spcstr/internal/state"
+)
+
+// TestHookDaemon routes hooks through a running daemon, mixing in events
+// handled in-process, and checks the daemon shuts down cleanly
+func TestHookDaemon(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binPath := filepath.Join(t.TempDir(), "spcstr")
+	buildCmd := exec.Command("go", "build", "-o", binPath, "../../cmd/spcstr")
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("failed to build spcstr binary: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	for _, dir := range []string{"sessions", "logs"} {
+		if err := os.MkdirAll(filepath.Join(projectDir, ".spcstr", dir), 0755); err != nil {
+			t.Fatalf("failed to create .spcstr/%s: %v", dir, err)
+		}
+	}
+
+	daemonCmd := exec.Command(binPath, "daemon", "--cwd", projectDir)
+	if err := daemonCmd.Start(); err != nil {
+		t.Fatalf("failed to start daemon: %v", err)
+	}
+	defer daemonCmd.Process.Kill()
+
+	socketPath := filepath.Join(projectDir, ".spcstr", "daemon.sock")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(socketPath); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("daemon socket never appeared")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sessionID := "daemon-session"
+	runHook := func(hookName, input string) error {
+		cmd := exec.Command(binPath, "hook", hookName, "--cwd", projectDir)
+		cmd.Stdin = strings.NewReader(input)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %v\nOutput: %s", hookName, err, output)
+		}
+		return nil
+	}
+
+	if err := runHook("session_start", `{"session_id": "`+sessionID+`", "source": "startup"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	const processes = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, processes)
+	for i := 0; i < processes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- runHook("post_tool_use", fmt.Sprintf(`{"session_id": "%s", "tool_name": "Read", "tool_input": {"file_path": "file_%d.go"}}`, sessionID, i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Handler errors still fail the hook with exit code 2
+	cmd := exec.Command(binPath, "hook", "post_tool_use", "--cwd", projectDir)
+	cmd.Stdin = strings.NewReader(`{"session_id": "missing-session", "tool_name": "Read"}`)
+	if err := cmd.Run(); err == nil {
+		t.Error("post_tool_use for a missing session succeeded through the daemon")
+	} else if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 2 {
+		t.Errorf("post_tool_use for a missing session exit = %v, want 2", err)
+	}
+
+	// Input that fails validation is reported without blocking
+	cmd = exec.Command(binPath, "hook", "stop", "--cwd", projectDir)
+	cmd.Stdin = strings.NewReader(`{}`)
+	if err := cmd.Run(); err == nil {
+		t.Error("stop without session_id succeeded through the daemon")
+	} else if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Errorf("stop without session_id exit = %v, want 1", err)
+	}
+
+	if err := daemonCmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to signal daemon: %v", err)
+	}
+	if err := daemonCmd.Wait(); err != nil {
+		t.Errorf("daemon exited with error: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("daemon left its socket behind")
+	}
+
+	// The daemon flushes its in-memory state before exiting
+	sessionDir := filepath.Join(projectDir, ".spcstr", "sessions", sessionID)
+	journal, err := os.Stat(filepath.Join(sessionDir, state.JournalFileName))
+	if err != nil {
+		t.Fatalf("failed to stat journal: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(sessionDir, state.StateFileName))
+	if err != nil {
+		t.Fatalf("failed to read state.json: %v", err)
+	}
+	var snapshot struct {
+		JournalOffset int64 `json:"journal_offset"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("failed to parse state.json: %v", err)
+	}
+	if snapshot.JournalOffset != journal.Size() {
+		t.Errorf("state.json reflects %d journal bytes after shutdown, want %d", snapshot.JournalOffset, journal.Size())
+	}
+
+	// With the daemon gone hooks fall back to running in-process
+	if err := runHook("post_tool_use", `{"session_id": "`+sessionID+`", "tool_name": "Read", "tool_input": {"file_path": "after.go"}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionState, err := state.NewStateManager(filepath.Join(projectDir, ".spcstr")).LoadState(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if got := len(sessionState.Files.Read); got != processes+1 {
+		t.Errorf("files.read has %d entries, want %d", got, processes+1)
+	}
+}
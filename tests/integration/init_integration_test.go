@@ -200,7 +200,7 @@ func TestHookCommandAfterInit(t *testing.T) {
 	}
 
 	// Verify that log file was created (if hook succeeded)
-	logFile := filepath.Join(projectDir, ".spcstr", "logs", "session_start.json")
+	logFile := filepath.Join(projectDir, ".spcstr", "logs", "session_start.jsonl")
 	if _, err := os.Stat(logFile); err == nil {
 		// Log file exists, verify it has content
 		data, _ := os.ReadFile(logFile)